@@ -0,0 +1,328 @@
+package linkding
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// UploadBookmarkAssetFile uploads the file at path as a new asset of a
+// specific bookmark, named after the file. The content type is detected from
+// the content of the file, or its extension if the content is not recognized,
+// so PDFs and images are shown as such in Linkding.
+//
+// Unlike UploadBookmarkAsset, the file is streamed to the server rather than
+// read into memory.
+func (c *Client) UploadBookmarkAssetFile(bookmarkID int, path string) (*BookmarkAsset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := detectContentType(file, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.uploadAsset(context.Background(), bookmarkID, filepath.Base(path), contentType, file, info.Size())
+}
+
+// DownloadBookmarkAssetToFile downloads an asset of a specific bookmark into
+// dir, creating it if needed, and returns the path of the file. The file is
+// named as given by the server, or after the display name of the asset if the
+// server gives no name. The name is sanitized so the file is always written
+// into dir, and an existing file of the same name is replaced.
+//
+// The content is written to a partial file in dir first, which is kept when
+// the download fails. Downloading the asset again resumes from the end of the
+// partial file using a Range request, or downloads the whole asset again if
+// the server does not support ranges.
+func (c *Client) DownloadBookmarkAssetToFile(bookmarkID int, assetID int, dir string) (string, error) {
+	return c.downloadToFile(context.Background(), bookmarkID, assetID, dir)
+}
+
+func (c *Client) downloadToFile(ctx context.Context, bookmarkID int, assetID int, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	partial := filepath.Join(dir, fmt.Sprintf(".asset-%d-%d.part", bookmarkID, assetID))
+	file, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", err
+	}
+	res, offset, err := c.downloadFrom(ctx, bookmarkID, assetID, offset)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var name string
+	if _, params, err := mime.ParseMediaType(res.Header.Get("Content-Disposition")); err == nil {
+		name = sanitizeFileName(params["filename"])
+	}
+	if name == "" {
+		asset, err := c.Assets.Get(ctx, bookmarkID, assetID)
+		if err != nil {
+			return "", err
+		}
+		name = sanitizeFileName(asset.DisplayName)
+	}
+	if name == "" {
+		name = fmt.Sprintf("asset-%d", assetID)
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	_, err = io.Copy(file, c.trackDownload(res, offset))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.Rename(partial, path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// ExportAssets downloads the assets of every bookmark, active and archived,
+// into dir, using the concurrency of the client. Each asset is stored as
+// <bookmark ID>/<asset ID>/<file name>, named as by
+// DownloadBookmarkAssetToFile. Pending and failed assets have no content and
+// are skipped.
+//
+// Exporting into the same dir again resumes an interrupted export: assets
+// that were downloaded already are skipped, and partially downloaded ones are
+// resumed. Assets deleted in the meantime are not removed from dir.
+//
+// The result holds an item for each bookmark. An error is only returned if
+// the bookmarks could not be listed.
+func (c *Client) ExportAssets(ctx context.Context, dir string) (*BulkResult, error) {
+	bookmarks, err := c.collectMatching(ctx, ListBookmarksParams{}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]BulkItemResult, len(bookmarks))
+	errs := runPool(ctx, len(bookmarks), c.concurrency, func(ctx context.Context, i int) error {
+		bookmark := bookmarks[i]
+
+		assets, err := collect(AllBookmarkAssets(ctx, c, bookmark.ID, ListBookmarkAssetsParams{Status: AssetStatusComplete}))
+		if err != nil {
+			return err
+		}
+
+		for _, asset := range assets {
+			assetDir := filepath.Join(dir, strconv.Itoa(bookmark.ID), strconv.Itoa(asset.ID))
+			if exported(assetDir) {
+				continue
+			}
+			if _, err := c.downloadToFile(ctx, bookmark.ID, asset.ID, assetDir); err != nil {
+				return fmt.Errorf("asset %d: %w", asset.ID, err)
+			}
+		}
+
+		return nil
+	})
+	for i, err := range errs {
+		items[i].Index = i
+		items[i].BookmarkID = bookmarks[i].ID
+		items[i].Err = err
+	}
+
+	return newBulkResult(items), nil
+}
+
+// exported reports whether the directory of an asset holds its downloaded
+// file, which is the only file not hidden.
+func exported(assetDir string) bool {
+	entries, err := os.ReadDir(assetDir)
+	if err != nil {
+		return false
+	}
+
+	return slices.ContainsFunc(entries, func(entry os.DirEntry) bool {
+		return !strings.HasPrefix(entry.Name(), ".")
+	})
+}
+
+// downloadFrom requests the content of an asset starting at offset, and
+// returns the response along with the offset its body starts at, which is 0
+// when the server sent the whole asset instead.
+func (c *Client) downloadFrom(ctx context.Context, bookmarkID int, assetID int, offset int64) (*http.Response, int64, error) {
+	endpoint := fmt.Sprintf("/api/bookmarks/%d/assets/%d/download/", bookmarkID, assetID)
+
+	if offset > 0 {
+		res, err := c.do(ctx, http.MethodGet, endpoint, nil, "application/json", WithRequestHeader("Range", fmt.Sprintf("bytes=%d-", offset)))
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// Servers ignoring the range send the whole asset, which is used as it
+		// is. A range that is not satisfiable or does not start at the offset
+		// means the partial file does not match the asset.
+		switch {
+		case res.StatusCode == http.StatusOK:
+			return res, 0, nil
+		case res.StatusCode == http.StatusPartialContent && contentRangeStart(res) == offset:
+			return res, offset, nil
+		}
+		res.Body.Close()
+	}
+
+	res, err := c.do(ctx, http.MethodGet, endpoint, nil, "application/json")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, 0, nil
+}
+
+// contentRangeStart returns the first byte of the range in the Content-Range
+// header of res, or -1 if it has none.
+func contentRangeStart(res *http.Response) int64 {
+	var start, end int64
+	if _, err := fmt.Sscanf(res.Header.Get("Content-Range"), "bytes %d-%d/", &start, &end); err != nil {
+		return -1
+	}
+
+	return start
+}
+
+// sanitizeFileName returns name without directories and characters that are
+// not allowed in file names on common systems, or "" if nothing is left.
+func sanitizeFileName(name string) string {
+	// Take the last element of the name, for either kind of separator.
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	// Leading dots would hide the file, and trailing dots and spaces are
+	// dropped by Windows.
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+	name = strings.TrimRight(name, ". ")
+
+	return name
+}
+
+// detectContentType sniffs the content type of a file and rewinds it.
+func detectContentType(file *os.File, path string) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	// Sniffing only tells generic text and binary files apart, for which the
+	// extension is more precise, e.g. for Markdown or JSON files.
+	contentType := http.DetectContentType(head[:n])
+	if generic := strings.HasPrefix(contentType, "text/plain") || contentType == "application/octet-stream"; generic {
+		if byExtension := mime.TypeByExtension(filepath.Ext(path)); byExtension != "" {
+			contentType = byExtension
+		}
+	}
+
+	return contentType, nil
+}
+
+// uploadAsset streams size bytes of r to the server as a file upload.
+func (c *Client) uploadAsset(ctx context.Context, bookmarkID int, name, contentType string, r io.Reader, size int64) (*BookmarkAsset, error) {
+	// The multipart framing is written ahead, so the length of the request is
+	// known without reading the content.
+	var head, tail bytes.Buffer
+	writer := multipart.NewWriter(&head)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, quoteReplacer.Replace(name)))
+	header.Set("Content-Type", contentType)
+	if _, err := writer.CreatePart(header); err != nil {
+		return nil, err
+	}
+	frame := head.Len()
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	tail.Write(head.Bytes()[frame:])
+	head.Truncate(frame)
+
+	body := &streamBody{
+		Reader: io.MultiReader(&head, io.LimitReader(r, size), &tail),
+		length: int64(head.Len()) + size + int64(tail.Len()),
+	}
+
+	res, err := c.sendRequest(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("/api/bookmarks/%d/assets/upload/", bookmarkID),
+		c.trackUpload(body),
+		writer.FormDataContentType(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	var asset BookmarkAsset
+	if err := c.decode(res, &asset); err != nil {
+		return nil, err
+	}
+
+	return &asset, nil
+}
+
+// quoteReplacer escapes quotes in the file names of uploads, like
+// multipart.Writer.CreateFormFile.
+var quoteReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// streamBody is a request body read from a stream of known length.
+type streamBody struct {
+	io.Reader
+	length int64
+}
+
+func (b *streamBody) Len() int64 {
+	return b.length
+}
+
+func (b *streamBody) Close() error {
+	return nil
+}
@@ -0,0 +1,196 @@
+// Package backup saves a whole Linkding account to a single archive and
+// restores it, so an account can be recovered independently of the backups of
+// the server.
+//
+// An archive is a zip file holding a manifest, the bookmarks, tags and user
+// preferences as JSON, and the content of every bookmark asset along with its
+// SHA-256 digest, so the archive can be verified with Verify.
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// FormatVersion is the version of the archive format written by Backup.
+const FormatVersion = 1
+
+// The names of the files in an archive.
+const (
+	manifestFile    = "manifest.json"
+	bookmarksFile   = "bookmarks.json"
+	tagsFile        = "tags.json"
+	preferencesFile = "preferences.json"
+	assetsFile      = "assets.json"
+)
+
+// Manifest describes the contents of an archive.
+type Manifest struct {
+	// The version of the archive format.
+	FormatVersion int `json:"format_version"`
+	// When the backup was taken.
+	CreatedAt time.Time `json:"created_at"`
+	// The version of Linkding the backup was taken from, if it could be
+	// detected.
+	ServerVersion string `json:"server_version,omitempty"`
+	Bookmarks     int    `json:"bookmarks"`
+	Tags          int    `json:"tags"`
+	Assets        int    `json:"assets"`
+}
+
+// Asset is an asset stored in an archive.
+type Asset struct {
+	linkding.BookmarkAsset
+	// The path of the content of the asset within the archive, empty if the
+	// content was not downloaded.
+	File string `json:"file,omitempty"`
+	// The hex encoded SHA-256 digest of the content, which archives written
+	// before digests were recorded do not have.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Options configures Backup.
+type Options struct {
+	// Only record the metadata of assets instead of downloading their
+	// content.
+	SkipAssets bool
+}
+
+// Backup writes an archive of every bookmark, active and archived, every tag,
+// the user preferences and the assets of the bookmarks to w, and returns its
+// manifest.
+//
+// Only assets that were stored successfully by the server have content to
+// download. Pending and failed assets are recorded without a file.
+func Backup(ctx context.Context, c linkding.BookmarkClient, w io.Writer, opts Options) (*Manifest, error) {
+	manifest := &Manifest{FormatVersion: FormatVersion, CreatedAt: time.Now().UTC()}
+	if capabilities, err := c.DetectCapabilities(); err == nil {
+		manifest.ServerVersion = capabilities.Version
+	}
+
+	bookmarks := []linkding.Bookmark{}
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	tags, err := allTags(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, err := c.GetUserPreferences()
+	if err != nil {
+		return nil, err
+	}
+
+	archive := zip.NewWriter(w)
+
+	if err := writeJSON(archive, bookmarksFile, bookmarks); err != nil {
+		return nil, err
+	}
+	if err := writeJSON(archive, tagsFile, tags); err != nil {
+		return nil, err
+	}
+	if err := writeJSON(archive, preferencesFile, preferences); err != nil {
+		return nil, err
+	}
+
+	assets := []Asset{}
+	for _, bookmark := range bookmarks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		for asset, err := range linkding.AllBookmarkAssets(ctx, c, bookmark.ID, linkding.ListBookmarkAssetsParams{}) {
+			if err != nil {
+				return nil, fmt.Errorf("bookmark %d: %w", bookmark.ID, err)
+			}
+
+			stored := Asset{BookmarkAsset: asset}
+			if !opts.SkipAssets && asset.Status == linkding.AssetStatusComplete {
+				stored.File = fmt.Sprintf("assets/%d/%d", bookmark.ID, asset.ID)
+				if stored.SHA256, err = writeAsset(archive, c, stored); err != nil {
+					return nil, fmt.Errorf("bookmark %d: asset %d: %w", bookmark.ID, asset.ID, err)
+				}
+			}
+
+			assets = append(assets, stored)
+		}
+	}
+
+	if err := writeJSON(archive, assetsFile, assets); err != nil {
+		return nil, err
+	}
+
+	manifest.Bookmarks = len(bookmarks)
+	manifest.Tags = len(tags)
+	manifest.Assets = len(assets)
+	if err := writeJSON(archive, manifestFile, manifest); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func allTags(ctx context.Context, c linkding.BookmarkClient) ([]linkding.Tag, error) {
+	tags := []linkding.Tag{}
+	for tag, err := range linkding.AllTags(ctx, c, linkding.ListTagsParams{}) {
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+func writeJSON(archive *zip.Writer, name string, v any) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// writeAsset downloads an asset into the archive and returns the digest of
+// its content.
+func writeAsset(archive *zip.Writer, c linkding.BookmarkClient, asset Asset) (string, error) {
+	content, err := c.DownloadBookmarkAsset(asset.Bookmark, asset.ID)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	file, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     asset.File,
+		Method:   zip.Deflate,
+		Modified: asset.DateCreated,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	checksum := linkding.NewChecksumReader(content)
+	if _, err := io.Copy(file, checksum); err != nil {
+		return "", err
+	}
+
+	return checksum.Sum(), nil
+}
@@ -0,0 +1,203 @@
+package backup_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/backup"
+	"github.com/larcher/go-linkding/linkdingtest"
+)
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := linkdingtest.NewFake()
+
+	golang := create(t, source, linkding.NewBookmark("https://go.dev").Title("Go").Tags("go", "programming").Build())
+	create(t, source, linkding.NewBookmark("https://example.com").Title("Example").Archived().Build())
+	if _, err := source.UploadBookmarkAsset(golang.ID, "notes.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	source.AddBookmarkAsset(golang.ID, linkding.BookmarkAsset{
+		AssetType: linkding.AssetTypeSnapshot,
+		Status:    linkding.AssetStatusPending,
+	}, nil)
+	if _, err := source.CreateTag("unused"); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	manifest, err := backup.Backup(ctx, source, &archive, backup.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Bookmarks != 2 || manifest.Tags != 3 || manifest.Assets != 2 {
+		t.Errorf("manifest = %+v, want 2 bookmarks, 3 tags and 2 assets", manifest)
+	}
+
+	if err := backup.Verify(bytes.NewReader(archive.Bytes()), int64(archive.Len())); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	target := linkdingtest.NewFake()
+	report, err := backup.Restore(ctx, target, bytes.NewReader(archive.Bytes()), int64(archive.Len()), backup.RestoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 2 || report.Failed != 0 {
+		t.Errorf("report = %+v, want 2 created", report.Report)
+	}
+	if report.TagsCreated != 1 {
+		t.Errorf("TagsCreated = %d, want 1", report.TagsCreated)
+	}
+	if len(report.MissingAssets) != 1 || report.MissingAssets[0].Status != linkding.AssetStatusPending {
+		t.Errorf("MissingAssets = %+v, want the pending snapshot", report.MissingAssets)
+	}
+
+	restored := check(t, target, "https://go.dev")
+	if restored.Title != "Go" || !slices.Equal(restored.TagNames, []string{"go", "programming"}) {
+		t.Errorf("restored = %+v, want the title and tags of the backup", restored)
+	}
+	if archived := check(t, target, "https://example.com"); !archived.IsArchived {
+		t.Error("archived bookmark restored as active")
+	}
+	if content := assetContent(t, target, restored.ID); content != "hello" {
+		t.Errorf("asset content = %q, want %q", content, "hello")
+	}
+
+	tags, err := target.ListTags(linkding.ListTagsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(tags.Results, func(tag linkding.Tag) bool { return tag.Name == "unused" }) {
+		t.Errorf("tags = %+v, want the unused tag restored", tags.Results)
+	}
+}
+
+func TestCorruptAsset(t *testing.T) {
+	ctx := context.Background()
+	source := linkdingtest.NewFake()
+
+	bookmark := create(t, source, linkding.NewBookmark("https://go.dev").Build())
+	if _, err := source.UploadBookmarkAsset(bookmark.ID, "notes.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	if _, err := backup.Backup(ctx, source, &archive, backup.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	corrupt := rewrite(t, archive.Bytes(), func(name string, content []byte) []byte {
+		if strings.HasPrefix(name, "assets/") {
+			return []byte("jello")
+		}
+		return content
+	})
+
+	if err := backup.Verify(bytes.NewReader(corrupt), int64(len(corrupt))); !errors.Is(err, backup.ErrChecksumMismatch) {
+		t.Errorf("Verify error = %v, want ErrChecksumMismatch", err)
+	}
+
+	report, err := backup.Restore(ctx, linkdingtest.NewFake(), bytes.NewReader(corrupt), int64(len(corrupt)), backup.RestoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Failed != 1 || !errors.Is(report.Items[0].Err, backup.ErrChecksumMismatch) {
+		t.Errorf("items = %+v, want the bookmark failed with ErrChecksumMismatch", report.Items)
+	}
+}
+
+func create(t *testing.T, f *linkdingtest.Fake, payload linkding.CreateBookmarkRequest) *linkding.Bookmark {
+	t.Helper()
+
+	bookmark, err := f.CreateBookmark(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bookmark
+}
+
+func check(t *testing.T, f *linkdingtest.Fake, url string) *linkding.Bookmark {
+	t.Helper()
+
+	result, err := f.CheckBookmark(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Bookmark == nil {
+		t.Fatalf("%s not restored", url)
+	}
+	return result.Bookmark
+}
+
+func assetContent(t *testing.T, f *linkdingtest.Fake, bookmarkID int) string {
+	t.Helper()
+
+	assets, err := f.ListBookmarkAssets(bookmarkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets.Results) != 1 {
+		t.Fatalf("got %d assets, want 1", len(assets.Results))
+	}
+
+	content, err := f.DownloadBookmarkAsset(bookmarkID, assets.Results[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer content.Close()
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// rewrite returns a copy of a zip archive with the content of every file
+// passed through change.
+func rewrite(t *testing.T, archive []byte, change func(name string, content []byte) []byte) []byte {
+	t.Helper()
+
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	w := zip.NewWriter(&out)
+	for _, file := range r.File {
+		content, err := readFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst, err := w.Create(file.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dst.Write(change(file.Name, content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return out.Bytes()
+}
+
+func readFile(file *zip.File) ([]byte, error) {
+	r, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
@@ -0,0 +1,210 @@
+package backup
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/importer"
+)
+
+// RestoreOptions configures Restore. The import options decide what happens
+// to bookmarks whose URL already exists in the instance.
+type RestoreOptions struct {
+	importer.Options
+	// Do not upload the assets stored in the archive.
+	SkipAssets bool
+}
+
+// Report is the outcome of a restore. The embedded import report covers the
+// bookmarks and their assets.
+type Report struct {
+	importer.Report
+	// The manifest of the restored archive.
+	Manifest Manifest
+	// The number of tags created that were not used by any bookmark.
+	TagsCreated int
+	// The tags that could not be created, with the reason.
+	TagErrors map[string]error
+	// The assets that could not be restored because the archive does not hold
+	// their content, either because they were pending or failed when the
+	// backup was taken or because assets were skipped.
+	MissingAssets []Asset
+}
+
+// Restore replays an archive written by Backup into an instance.
+//
+// Bookmarks are created through the import package, so they are deduplicated
+// by URL against the existing bookmarks, and their assets are uploaded when
+// they are created. The API does not allow setting the dates of bookmarks and
+// assets, nor the metadata scraped by the server, so these are not restored.
+// Snapshots are restored as uploaded files. User preferences are read-only in
+// the API and are not restored either. Assets whose content does not match
+// their recorded digest fail with ErrChecksumMismatch before they are
+// uploaded.
+//
+// Bookmarks that fail to restore are reported in the returned report. An error
+// is only returned if the archive cannot be read, or under the same
+// conditions as importer.Import.
+func Restore(ctx context.Context, c linkding.BookmarkClient, r io.ReaderAt, size int64, opts RestoreOptions) (*Report, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{TagErrors: map[string]error{}}
+	if err := readJSON(archive, manifestFile, &report.Manifest); err != nil {
+		return nil, err
+	}
+	if report.Manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("backup: unsupported archive format version %d", report.Manifest.FormatVersion)
+	}
+
+	var bookmarks []linkding.Bookmark
+	var tags []linkding.Tag
+	var assets []Asset
+	if err := readJSON(archive, bookmarksFile, &bookmarks); err != nil {
+		return nil, err
+	}
+	if err := readJSON(archive, tagsFile, &tags); err != nil {
+		return nil, err
+	}
+	if err := readJSON(archive, assetsFile, &assets); err != nil {
+		return nil, err
+	}
+
+	records := make([]importer.Record, len(bookmarks))
+	index := make(map[int]int, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		records[i] = importer.Record{
+			URL:         bookmark.URL,
+			Title:       bookmark.Title,
+			Description: bookmark.Description,
+			Notes:       bookmark.Notes,
+			Tags:        bookmark.TagNames,
+			Unread:      bookmark.Unread,
+			Shared:      bookmark.Shared,
+			Archived:    bookmark.IsArchived,
+			DateAdded:   bookmark.DateAdded,
+		}
+		index[bookmark.ID] = i
+	}
+
+	for _, asset := range assets {
+		i, ok := index[asset.Bookmark]
+		if !ok {
+			continue
+		}
+		if asset.File == "" || opts.SkipAssets {
+			report.MissingAssets = append(report.MissingAssets, asset)
+			continue
+		}
+
+		records[i].Assets = append(records[i].Assets, importer.Asset{
+			Name: assetName(asset),
+			Open: func() (io.ReadCloser, error) {
+				return openAsset(archive, asset)
+			},
+		})
+	}
+
+	imported, err := importer.Import(ctx, c, records, opts.Options)
+	if imported != nil {
+		report.Report = *imported
+	}
+	if err != nil {
+		return report, err
+	}
+
+	if err := restoreTags(ctx, c, tags, opts.DryRun, report); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// restoreTags creates the tags of the archive that do not exist yet, which
+// are the tags that were not used by any bookmark.
+func restoreTags(ctx context.Context, c linkding.BookmarkClient, tags []linkding.Tag, dryRun bool, report *Report) error {
+	existing, err := allTags(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]bool, len(existing))
+	for _, tag := range existing {
+		names[strings.ToLower(tag.Name)] = true
+	}
+	for _, item := range report.Items {
+		if item.Err != nil || item.Action == importer.ActionSkip {
+			continue
+		}
+		for _, tag := range item.Record.Tags {
+			names[strings.ToLower(tag)] = true
+		}
+	}
+
+	for _, tag := range tags {
+		if names[strings.ToLower(tag.Name)] {
+			continue
+		}
+		names[strings.ToLower(tag.Name)] = true
+
+		if !dryRun {
+			if _, err := c.CreateTag(tag.Name); err != nil {
+				report.TagErrors[tag.Name] = err
+				continue
+			}
+		}
+		report.TagsCreated++
+	}
+
+	return nil
+}
+
+// assetName returns the file name an asset is uploaded with. Snapshots are
+// given an extension, so the server recognizes them as HTML.
+func assetName(asset Asset) string {
+	name := cmp.Or(asset.DisplayName, path.Base(asset.File))
+	if asset.AssetType == linkding.AssetTypeSnapshot && path.Ext(name) == "" {
+		name += ".html"
+	}
+
+	return name
+}
+
+func readJSON(archive *zip.Reader, name string, v any) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("backup: invalid archive: %w", err)
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(v); err != nil {
+		return fmt.Errorf("backup: invalid archive: %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// ReadBookmarks reads the bookmarks stored in an archive written by Backup,
+// e.g. to compare them with an instance using the diff package.
+func ReadBookmarks(r io.ReaderAt, size int64) ([]linkding.Bookmark, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var bookmarks []linkding.Bookmark
+	if err := readJSON(archive, bookmarksFile, &bookmarks); err != nil {
+		return nil, err
+	}
+
+	return bookmarks, nil
+}
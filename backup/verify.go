@@ -0,0 +1,92 @@
+package backup
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/larcher/go-linkding"
+)
+
+// ErrChecksumMismatch is returned when the content of an asset in an archive
+// does not match the digest recorded by Backup.
+var ErrChecksumMismatch = errors.New("backup: checksum mismatch")
+
+// Verify checks that an archive written by Backup is complete and that the
+// content of every asset matches its recorded digest, without restoring it.
+// All problems found are returned joined. The assets of archives written
+// before digests were recorded are only checked for being readable.
+func Verify(r io.ReaderAt, size int64) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	var manifest Manifest
+	var assets []Asset
+	if err := readJSON(archive, manifestFile, &manifest); err != nil {
+		return err
+	}
+	if err := readJSON(archive, assetsFile, &assets); err != nil {
+		return err
+	}
+	if len(assets) != manifest.Assets {
+		return fmt.Errorf("backup: invalid archive: %d assets listed, %d expected", len(assets), manifest.Assets)
+	}
+
+	var errs []error
+	for _, asset := range assets {
+		if asset.File == "" {
+			continue
+		}
+
+		err := func() error {
+			file, err := openAsset(archive, asset)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			_, err = io.Copy(io.Discard, file)
+			return err
+		}()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("bookmark %d: asset %d: %w", asset.Bookmark, asset.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// openAsset opens the content of an asset in an archive. Reading it to the
+// end fails with ErrChecksumMismatch if it does not match its digest.
+func openAsset(archive *zip.Reader, asset Asset) (io.ReadCloser, error) {
+	file, err := archive.Open(asset.File)
+	if err != nil {
+		return nil, err
+	}
+	if asset.SHA256 == "" {
+		return file, nil
+	}
+
+	return &verifiedFile{
+		ReadCloser: file,
+		checksum:   linkding.NewChecksumReader(file),
+		want:       asset.SHA256,
+	}, nil
+}
+
+type verifiedFile struct {
+	io.ReadCloser
+	checksum *linkding.ChecksumReader
+	want     string
+}
+
+func (f *verifiedFile) Read(p []byte) (int, error) {
+	n, err := f.checksum.Read(p)
+	if err == io.EOF && f.checksum.Sum() != f.want {
+		err = ErrChecksumMismatch
+	}
+	return n, err
+}
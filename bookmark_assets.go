@@ -1,12 +1,43 @@
 package linkding
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"time"
 )
 
+// ListBookmarkAssetsParams defines the parameters used when listing the assets
+// of a bookmark.
+//
+// The API does not filter assets, so the type and status filters are applied
+// by the client to each page. A filtered page may hold fewer assets than the
+// limit even if more pages follow, and its count is the number of assets
+// before filtering. Use AllBookmarkAssets to get every matching asset.
+type ListBookmarkAssetsParams struct {
+	// The maximum number of assets to return.
+	Limit int
+	// The offset for pagination.
+	Offset int
+	// Only return assets of this type, e.g. AssetTypeSnapshot.
+	AssetType string
+	// Only return assets in this state, e.g. AssetStatusComplete.
+	Status string
+}
+
+// Matches reports whether an asset passes the type and status filters.
+func (p ListBookmarkAssetsParams) Matches(asset BookmarkAsset) bool {
+	return (p.AssetType == "" || asset.AssetType == p.AssetType) &&
+		(p.Status == "" || asset.Status == p.Status)
+}
+
 // ListBookmarkAssetsResponse represents the response from the Linkding API when
 // listing bookmark assets.
 type ListBookmarkAssetsResponse struct {
@@ -14,6 +45,9 @@ type ListBookmarkAssetsResponse struct {
 	Next     string          `json:"next"`
 	Previous string          `json:"previous"`
 	Results  []BookmarkAsset `json:"results"`
+
+	// Unknown fields, only collected when using DecodeTolerant.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // BookmarkAsset represents a bookmark asset in the Linkding API.
@@ -25,14 +59,46 @@ type BookmarkAsset struct {
 	ContentType string    `json:"content_type"`
 	DisplayName string    `json:"display_name"`
 	Status      string    `json:"status"`
+
+	// Unknown fields, only collected when using DecodeTolerant.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// The types of bookmark assets.
+const (
+	AssetTypeSnapshot = "snapshot"
+	AssetTypeUpload   = "upload"
+)
+
+// The states of bookmark assets.
+const (
+	AssetStatusPending  = "pending"
+	AssetStatusComplete = "complete"
+	AssetStatusFailure  = "failure"
+)
+
+// AssetsService handles the bookmark asset endpoints of the Linkding API.
+type AssetsService interface {
+	List(ctx context.Context, bookmarkID int, params ListBookmarkAssetsParams, opts ...RequestOption) (*ListBookmarkAssetsResponse, error)
+	Get(ctx context.Context, bookmarkID int, id int, opts ...RequestOption) (*BookmarkAsset, error)
+	Download(ctx context.Context, bookmarkID int, id int, opts ...RequestOption) (io.ReadCloser, error)
+	Upload(ctx context.Context, bookmarkID int, name string, r io.Reader, opts ...RequestOption) (*BookmarkAsset, error)
+	Delete(ctx context.Context, bookmarkID int, id int, opts ...RequestOption) error
+}
+
+type assetsService struct {
+	client *Client
 }
 
-// ListBookmarkAssets retrieves a list assets for a specific bookmark.
-func (c *Client) ListBookmarkAssets(bookmarkID int) (*ListBookmarkAssetsResponse, error) {
-	body, err := c.makeRequest(
+// List retrieves a page of the assets of a specific bookmark based on the
+// provided parameters.
+func (s *assetsService) List(ctx context.Context, bookmarkID int, params ListBookmarkAssetsParams, opts ...RequestOption) (*ListBookmarkAssetsResponse, error) {
+	body, err := s.client.makeRequest(
+		ctx,
 		http.MethodGet,
-		fmt.Sprintf("/api/bookmarks/%d/assets/", bookmarkID),
+		buildAssetsQueryString(fmt.Sprintf("/api/bookmarks/%d/assets/", bookmarkID), params),
 		nil,
+		opts...,
 	)
 	if err != nil {
 		return nil, err
@@ -40,19 +106,24 @@ func (c *Client) ListBookmarkAssets(bookmarkID int) (*ListBookmarkAssetsResponse
 	defer body.Close()
 
 	result := &ListBookmarkAssetsResponse{}
-	if err := json.NewDecoder(body).Decode(result); err != nil {
+	if err := s.client.decode(body, result); err != nil {
 		return nil, err
 	}
+	result.Results = slices.DeleteFunc(result.Results, func(asset BookmarkAsset) bool {
+		return !params.Matches(asset)
+	})
 
 	return result, nil
 }
 
-// GetBookmarkAsset retrieves a single asset by ID for a specific bookmark.
-func (c *Client) GetBookmarkAsset(bookmarkID int, id int) (*BookmarkAsset, error) {
-	body, err := c.makeRequest(
+// Get retrieves a single asset by ID for a specific bookmark.
+func (s *assetsService) Get(ctx context.Context, bookmarkID int, id int, opts ...RequestOption) (*BookmarkAsset, error) {
+	body, err := s.client.makeRequest(
+		ctx,
 		http.MethodGet,
 		fmt.Sprintf("/api/bookmarks/%d/assets/%d/", bookmarkID, id),
 		nil,
+		opts...,
 	)
 	if err != nil {
 		return nil, err
@@ -60,22 +131,144 @@ func (c *Client) GetBookmarkAsset(bookmarkID int, id int) (*BookmarkAsset, error
 	defer body.Close()
 
 	bookmark := &BookmarkAsset{}
-	if err := json.NewDecoder(body).Decode(bookmark); err != nil {
+	if err := s.client.decode(body, bookmark); err != nil {
 		return nil, err
 	}
 
 	return bookmark, nil
 }
 
-// TODO: Implement download and upload
+// Download retrieves the content of an asset by ID for a specific bookmark.
+// HTML snapshots are returned uncompressed. The caller must close the returned
+// reader.
+func (s *assetsService) Download(ctx context.Context, bookmarkID int, id int, opts ...RequestOption) (io.ReadCloser, error) {
+	res, err := s.client.do(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("/api/bookmarks/%d/assets/%d/download/", bookmarkID, id),
+		nil,
+		"application/json",
+		opts...,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-// DeleteBookmarkAsset deletes an asset by ID for a specific bookmark.
-func (c *Client) DeleteBookmarkAsset(bookmarkID int, id int) error {
-	_, err := c.makeRequest(
+	return s.client.trackDownload(res, 0), nil
+}
+
+// Upload uploads a file as a new asset of a specific bookmark. The name is the
+// file name shown in Linkding. The content is read into memory before it is
+// sent, as the server does not accept chunked uploads.
+func (s *assetsService) Upload(ctx context.Context, bookmarkID int, name string, r io.Reader, opts ...RequestOption) (*BookmarkAsset, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+
+	part, err := writer.CreateFormFile("file", name)
+	if err == nil {
+		_, err = io.Copy(part, r)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		releaseBuffer(buf)
+		return nil, err
+	}
+
+	body, err := s.client.sendRequest(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("/api/bookmarks/%d/assets/upload/", bookmarkID),
+		s.client.trackUpload(&pooledBody{buf: buf}),
+		writer.FormDataContentType(),
+		opts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var asset BookmarkAsset
+	if err := s.client.decode(body, &asset); err != nil {
+		return nil, err
+	}
+
+	return &asset, nil
+}
+
+// Delete deletes an asset by ID for a specific bookmark.
+func (s *assetsService) Delete(ctx context.Context, bookmarkID int, id int, opts ...RequestOption) error {
+	body, err := s.client.makeRequest(
+		ctx,
 		http.MethodDelete,
 		fmt.Sprintf("/api/bookmarks/%d/assets/%d/", bookmarkID, id),
 		nil,
+		opts...,
 	)
+	if err != nil {
+		return err
+	}
+
+	return body.Close()
+}
+
+// ListBookmarkAssets retrieves a page of the assets of a specific bookmark. It
+// is a shorthand for Assets.List. The params are optional, so existing calls
+// without them keep working; only the first is used.
+func (c *Client) ListBookmarkAssets(bookmarkID int, params ...ListBookmarkAssetsParams) (*ListBookmarkAssetsResponse, error) {
+	return c.Assets.List(context.Background(), bookmarkID, firstAssetsParams(params))
+}
+
+// firstAssetsParams returns the first of the optional params, or the zero
+// params if none are given.
+func firstAssetsParams(params []ListBookmarkAssetsParams) ListBookmarkAssetsParams {
+	if len(params) == 0 {
+		return ListBookmarkAssetsParams{}
+	}
+
+	return params[0]
+}
+
+// GetBookmarkAsset retrieves a single asset by ID for a specific bookmark. It
+// is a shorthand for Assets.Get.
+func (c *Client) GetBookmarkAsset(bookmarkID int, id int) (*BookmarkAsset, error) {
+	return c.Assets.Get(context.Background(), bookmarkID, id)
+}
+
+// DownloadBookmarkAsset retrieves the content of an asset by ID for a specific
+// bookmark. The caller must close the returned reader. It is a shorthand for
+// Assets.Download.
+func (c *Client) DownloadBookmarkAsset(bookmarkID int, id int) (io.ReadCloser, error) {
+	return c.Assets.Download(context.Background(), bookmarkID, id)
+}
+
+// UploadBookmarkAsset uploads a file as a new asset of a specific bookmark. It
+// is a shorthand for Assets.Upload.
+func (c *Client) UploadBookmarkAsset(bookmarkID int, name string, r io.Reader) (*BookmarkAsset, error) {
+	return c.Assets.Upload(context.Background(), bookmarkID, name, r)
+}
+
+// DeleteBookmarkAsset deletes an asset by ID for a specific bookmark. It is a
+// shorthand for Assets.Delete.
+func (c *Client) DeleteBookmarkAsset(bookmarkID int, id int) error {
+	return c.Assets.Delete(context.Background(), bookmarkID, id)
+}
+
+func buildAssetsQueryString(path string, params ListBookmarkAssetsParams) string {
+	values := url.Values{}
+
+	if params.Limit > 0 {
+		values.Set("limit", strconv.Itoa(params.Limit))
+	}
+
+	if params.Offset > 0 {
+		values.Set("offset", strconv.Itoa(params.Offset))
+	}
+
+	if len(values) > 0 {
+		return fmt.Sprintf("%s?%s", path, values.Encode())
+	}
 
-	return err
+	return path
 }
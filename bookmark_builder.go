@@ -0,0 +1,81 @@
+package linkding
+
+import "slices"
+
+// BookmarkBuilder builds a CreateBookmarkRequest:
+//
+//	payload := linkding.NewBookmark("https://go.dev").Title("Go").Tags("go", "lang").Unread().Build()
+//	bookmark, err := client.CreateBookmark(payload)
+//
+// Builders are immutable, each method returns a new builder, so a builder can
+// be shared as a template for several bookmarks.
+type BookmarkBuilder struct {
+	request CreateBookmarkRequest
+}
+
+// NewBookmark returns a builder of a request for a bookmark of url.
+func NewBookmark(url string) BookmarkBuilder {
+	return BookmarkBuilder{request: CreateBookmarkRequest{URL: url}}
+}
+
+// Title returns the builder setting the title.
+func (b BookmarkBuilder) Title(title string) BookmarkBuilder {
+	b.request.Title = title
+	return b
+}
+
+// Description returns the builder setting the description.
+func (b BookmarkBuilder) Description(description string) BookmarkBuilder {
+	b.request.Description = description
+	return b
+}
+
+// Notes returns the builder setting the notes.
+func (b BookmarkBuilder) Notes(notes string) BookmarkBuilder {
+	b.request.Notes = notes
+	return b
+}
+
+// Tags returns the builder also adding the tags. Tags already added are not
+// added twice.
+func (b BookmarkBuilder) Tags(tags ...string) BookmarkBuilder {
+	// Copy the tags, so builders built from the same builder do not share them.
+	names := slices.Clone(b.request.TagNames)
+	for _, tag := range tags {
+		if !containsTag(names, tag) {
+			names = append(names, tag)
+		}
+	}
+	b.request.TagNames = names
+	return b
+}
+
+// Unread returns the builder marking the bookmark as unread.
+func (b BookmarkBuilder) Unread() BookmarkBuilder {
+	b.request.Unread = true
+	return b
+}
+
+// Shared returns the builder sharing the bookmark.
+func (b BookmarkBuilder) Shared() BookmarkBuilder {
+	b.request.Shared = true
+	return b
+}
+
+// Archived returns the builder archiving the bookmark.
+func (b BookmarkBuilder) Archived() BookmarkBuilder {
+	b.request.IsArchived = true
+	return b
+}
+
+// Build returns the request. Its TagNames is never nil, so it is sent as an
+// empty list for bookmarks without tags.
+func (b BookmarkBuilder) Build() CreateBookmarkRequest {
+	request := b.request
+	request.TagNames = slices.Clone(b.request.TagNames)
+	if request.TagNames == nil {
+		request.TagNames = []string{}
+	}
+
+	return request
+}
@@ -0,0 +1,47 @@
+package linkding
+
+import (
+	"net/url"
+	"time"
+)
+
+// HasTag reports whether the bookmark has the tag. Tags are compared ignoring
+// letter case, like Linkding does.
+func (b Bookmark) HasTag(name string) bool {
+	return containsTag(b.TagNames, name)
+}
+
+// Domain returns the host of the bookmark's URL in lowercase and without a
+// leading "www.", or "" if the URL has no host.
+func (b Bookmark) Domain() string {
+	u, err := url.Parse(b.URL)
+	if err != nil {
+		return ""
+	}
+
+	return domain(u.Hostname())
+}
+
+// Age returns the time since the bookmark was added.
+func (b Bookmark) Age() time.Duration {
+	return time.Since(b.DateAdded)
+}
+
+// IsSnapshotAvailable reports whether Linkding saved a snapshot of the
+// bookmarked page to the Internet Archive.
+func (b Bookmark) IsSnapshotAvailable() bool {
+	return b.WebArchiveSnapshotURL != ""
+}
+
+// EffectiveTitle returns the title of the bookmark as shown by Linkding: its
+// title, or else the title of the website, or else its URL.
+func (b Bookmark) EffectiveTitle() string {
+	if b.Title != "" {
+		return b.Title
+	}
+	if b.WebsiteTitle != "" {
+		return b.WebsiteTitle
+	}
+
+	return b.URL
+}
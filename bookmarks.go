@@ -1,11 +1,15 @@
 package linkding
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -34,6 +38,9 @@ type ListBookmarksResponse struct {
 	Next     string     `json:"next"`
 	Previous string     `json:"previous"`
 	Results  []Bookmark `json:"results"`
+
+	// Unknown fields, only collected when using DecodeTolerant.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Bookmark represents a bookmark object in the Linkding API.
@@ -54,6 +61,9 @@ type Bookmark struct {
 	TagNames              []string  `json:"tag_names"`
 	DateAdded             time.Time `json:"date_added"`
 	DateModified          time.Time `json:"date_modified"`
+
+	// Unknown fields, only collected when using DecodeTolerant.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // CreateBookmarkRequest represents the request body when creating or updating
@@ -69,6 +79,19 @@ type CreateBookmarkRequest struct {
 	TagNames    []string `json:"tag_names"`
 }
 
+// PatchBookmarkRequest represents the request body when partially updating a
+// bookmark. Only the fields that are not nil are changed.
+type PatchBookmarkRequest struct {
+	URL         *string   `json:"url,omitempty"`
+	Title       *string   `json:"title,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Notes       *string   `json:"notes,omitempty"`
+	IsArchived  *bool     `json:"is_archived,omitempty"`
+	Unread      *bool     `json:"unread,omitempty"`
+	Shared      *bool     `json:"shared,omitempty"`
+	TagNames    *[]string `json:"tag_names,omitempty"`
+}
+
 // CheckBookmarkResponse represents the response from the Linkding API when
 // checking a if a URL has been bookmarked.
 //
@@ -77,6 +100,9 @@ type CheckBookmarkResponse struct {
 	Bookmark *Bookmark `json:"bookmark"`
 	Metadata Metadata  `json:"metadata"`
 	AutoTags []string  `json:"auto_tags"`
+
+	// Unknown fields, only collected when using DecodeTolerant.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Metadata contains metadata scraped from a website.
@@ -85,65 +111,86 @@ type Metadata struct {
 	Title        string `json:"title"`
 	Description  string `json:"description"`
 	PreviewImage string `json:"preview_image"`
+
+	// Unknown fields, only collected when using DecodeTolerant.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
-// ListBookmarks retrieves a list of bookmarks from Linkding based on the
-// provided parameters.
-func (c *Client) ListBookmarks(params ListBookmarksParams) (*ListBookmarksResponse, error) {
+// BookmarksService handles the bookmark endpoints of the Linkding API.
+type BookmarksService interface {
+	List(ctx context.Context, params ListBookmarksParams, opts ...RequestOption) (*ListBookmarksResponse, error)
+	ListArchived(ctx context.Context, params ListBookmarksParams, opts ...RequestOption) (*ListBookmarksResponse, error)
+	Get(ctx context.Context, id int, opts ...RequestOption) (*Bookmark, error)
+	Check(ctx context.Context, bookmarkUrl string, opts ...RequestOption) (*CheckBookmarkResponse, error)
+	Create(ctx context.Context, payload CreateBookmarkRequest, opts ...RequestOption) (*Bookmark, error)
+	Update(ctx context.Context, id int, payload CreateBookmarkRequest, opts ...RequestOption) (*Bookmark, error)
+	Patch(ctx context.Context, id int, payload PatchBookmarkRequest, opts ...RequestOption) (*Bookmark, error)
+	Archive(ctx context.Context, id int, opts ...RequestOption) error
+	Unarchive(ctx context.Context, id int, opts ...RequestOption) error
+	Delete(ctx context.Context, id int, opts ...RequestOption) error
+}
+
+type bookmarksService struct {
+	client *Client
+}
+
+// List retrieves a list of bookmarks from Linkding based on the provided
+// parameters.
+func (s *bookmarksService) List(ctx context.Context, params ListBookmarksParams, opts ...RequestOption) (*ListBookmarksResponse, error) {
 	path := buildBookmarksQueryString("/api/bookmarks/", params)
 
-	body, err := c.makeRequest(http.MethodGet, path, nil)
+	body, err := s.client.makeRequest(ctx, http.MethodGet, path, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 	defer body.Close()
 
 	result := &ListBookmarksResponse{}
-	if err := json.NewDecoder(body).Decode(result); err != nil {
+	if err := s.client.decode(body, result); err != nil {
 		return nil, err
 	}
 
 	return result, nil
 }
 
-// ListArchivedBookmarks retrieves a list of archived bookmarks from Linkding.
-// It also filters the list based on the provided parameters.
-func (c *Client) ListArchivedBookmarks(params ListBookmarksParams) (*ListBookmarksResponse, error) {
+// ListArchived retrieves a list of archived bookmarks from Linkding. It also
+// filters the list based on the provided parameters.
+func (s *bookmarksService) ListArchived(ctx context.Context, params ListBookmarksParams, opts ...RequestOption) (*ListBookmarksResponse, error) {
 	path := buildBookmarksQueryString("/api/bookmarks/archived/", params)
 
-	body, err := c.makeRequest(http.MethodGet, path, nil)
+	body, err := s.client.makeRequest(ctx, http.MethodGet, path, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 	defer body.Close()
 
 	result := &ListBookmarksResponse{}
-	if err := json.NewDecoder(body).Decode(result); err != nil {
+	if err := s.client.decode(body, result); err != nil {
 		return nil, err
 	}
 
 	return result, nil
 }
 
-// GetBookmark retrieves a single bookmark from Linkding.
-func (c *Client) GetBookmark(id int) (*Bookmark, error) {
-	body, err := c.makeRequest(http.MethodGet, fmt.Sprintf("/api/bookmarks/%d/", id), nil)
+// Get retrieves a single bookmark from Linkding.
+func (s *bookmarksService) Get(ctx context.Context, id int, opts ...RequestOption) (*Bookmark, error) {
+	body, err := s.client.makeRequest(ctx, http.MethodGet, fmt.Sprintf("/api/bookmarks/%d/", id), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 	defer body.Close()
 
 	bookmark := &Bookmark{}
-	if err := json.NewDecoder(body).Decode(bookmark); err != nil {
+	if err := s.client.decode(body, bookmark); err != nil {
 		return nil, err
 	}
 
 	return bookmark, nil
 }
 
-// CheckBookmark checks if a URL is already bookmarked.
-func (c *Client) CheckBookmark(bookmarkUrl string) (*CheckBookmarkResponse, error) {
-	uri, err := url.Parse(bookmarkUrl)
+// Check checks if a URL is already bookmarked.
+func (s *bookmarksService) Check(ctx context.Context, bookmarkUrl string, opts ...RequestOption) (*CheckBookmarkResponse, error) {
+	uri, err := url.Parse(s.client.normalizeURL(bookmarkUrl))
 	if err != nil {
 		return nil, err
 	}
@@ -151,10 +198,12 @@ func (c *Client) CheckBookmark(bookmarkUrl string) (*CheckBookmarkResponse, erro
 	query := url.Values{}
 	query.Set("url", uri.String())
 
-	body, err := c.makeRequest(
+	body, err := s.client.makeRequest(
+		ctx,
 		http.MethodGet,
 		fmt.Sprintf("/api/bookmarks/check/?%s", query.Encode()),
 		nil,
+		opts...,
 	)
 	if err != nil {
 		return nil, err
@@ -162,107 +211,290 @@ func (c *Client) CheckBookmark(bookmarkUrl string) (*CheckBookmarkResponse, erro
 	defer body.Close()
 
 	result := &CheckBookmarkResponse{}
-	if err := json.NewDecoder(body).Decode(result); err != nil {
+	if err := s.client.decode(body, result); err != nil {
 		return nil, err
 	}
 
 	return result, nil
 }
 
-// CreateBookmark creates a new bookmark in Linkding using the provided payload.
-//
-// Warning: Ensure that the TagNames property in the CreateBookmarkRequest is
-// initialized (even if empty) to avoid nil pointer issues.
-func (c *Client) CreateBookmark(payload CreateBookmarkRequest) (*Bookmark, error) {
-	body, err := c.makeRequest(http.MethodPost, "/api/bookmarks/", payload)
+// Create creates a new bookmark in Linkding using the provided payload. Nil
+// TagNames are sent as an empty list, which the server requires.
+func (s *bookmarksService) Create(ctx context.Context, payload CreateBookmarkRequest, opts ...RequestOption) (*Bookmark, error) {
+	s.client.expandPayload(ctx, &payload)
+	payload.URL = s.client.normalizeURL(payload.URL)
+	payload.TagNames = s.client.normalizeTags(orEmpty(payload.TagNames))
+	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
+
+	body, err := s.client.makeRequest(ctx, http.MethodPost, "/api/bookmarks/", payload, opts...)
 	if err != nil {
 		return nil, err
 	}
 	defer body.Close()
 
 	bookmark := &Bookmark{}
-	if err := json.NewDecoder(body).Decode(bookmark); err != nil {
+	if err := s.client.decode(body, bookmark); err != nil {
 		return nil, err
 	}
 
 	return bookmark, nil
 }
 
-// UpdateBookmark updates an existing bookmark in Linkding using the provided
-// payload.
-//
-// Warning: Ensure that the TagNames property in the CreateBookmarkRequest is
-// initialized (even if empty) to avoid nil pointer issues.
-func (c *Client) UpdateBookmark(id int, payload CreateBookmarkRequest) (*Bookmark, error) {
-	body, err := c.makeRequest(http.MethodPut, fmt.Sprintf("/api/bookmarks/%d/", id), payload)
+// Update updates an existing bookmark in Linkding using the provided payload.
+// Nil TagNames are sent as an empty list, removing all tags.
+func (s *bookmarksService) Update(ctx context.Context, id int, payload CreateBookmarkRequest, opts ...RequestOption) (*Bookmark, error) {
+	payload.URL = s.client.normalizeURL(payload.URL)
+	payload.TagNames = s.client.normalizeTags(orEmpty(payload.TagNames))
+	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
+
+	body, err := s.client.makeRequest(ctx, http.MethodPut, fmt.Sprintf("/api/bookmarks/%d/", id), payload, opts...)
 	if err != nil {
 		return nil, err
 	}
 	defer body.Close()
 
 	bookmark := &Bookmark{}
-	if err := json.NewDecoder(body).Decode(bookmark); err != nil {
+	if err := s.client.decode(body, bookmark); err != nil {
 		return nil, err
 	}
 
 	return bookmark, nil
 }
 
-// ArchiveBookmark archives a bookmark from Linkding.
-func (c *Client) ArchiveBookmark(id int) error {
-	_, err := c.makeRequest(http.MethodPost, fmt.Sprintf("/api/bookmarks/%d/archive/", id), nil)
+// Patch updates only the fields of an existing bookmark that are set in the
+// provided payload.
+func (s *bookmarksService) Patch(ctx context.Context, id int, payload PatchBookmarkRequest, opts ...RequestOption) (*Bookmark, error) {
+	if payload.URL != nil {
+		normalized := s.client.normalizeURL(*payload.URL)
+		payload.URL = &normalized
+	}
+	if payload.TagNames != nil {
+		tags := s.client.normalizeTags(*payload.TagNames)
+		payload.TagNames = &tags
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
+
+	body, err := s.client.makeRequest(ctx, http.MethodPatch, fmt.Sprintf("/api/bookmarks/%d/", id), payload, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	bookmark := &Bookmark{}
+	if err := s.client.decode(body, bookmark); err != nil {
+		return nil, err
+	}
+
+	return bookmark, nil
+}
 
-	return err
+// Archive archives a bookmark from Linkding.
+func (s *bookmarksService) Archive(ctx context.Context, id int, opts ...RequestOption) error {
+	body, err := s.client.makeRequest(ctx, http.MethodPost, fmt.Sprintf("/api/bookmarks/%d/archive/", id), nil, opts...)
+	if err != nil {
+		return err
+	}
+
+	return body.Close()
 }
 
-// UnarchiveBookmark unarchives a bookmark from Linkding.
-func (c *Client) UnarchiveBookmark(id int) error {
-	_, err := c.makeRequest(http.MethodPost, fmt.Sprintf("/api/bookmarks/%d/unarchive/", id), nil)
+// Unarchive unarchives a bookmark from Linkding.
+func (s *bookmarksService) Unarchive(ctx context.Context, id int, opts ...RequestOption) error {
+	body, err := s.client.makeRequest(ctx, http.MethodPost, fmt.Sprintf("/api/bookmarks/%d/unarchive/", id), nil, opts...)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return body.Close()
 }
 
-// DeleteBookmark deletes a bookmark from Linkding.
+// Delete deletes a bookmark from Linkding.
+func (s *bookmarksService) Delete(ctx context.Context, id int, opts ...RequestOption) error {
+	body, err := s.client.makeRequest(ctx, http.MethodDelete, fmt.Sprintf("/api/bookmarks/%d/", id), nil, opts...)
+	if err != nil {
+		return err
+	}
+
+	return body.Close()
+}
+
+// ListBookmarks retrieves a list of bookmarks from Linkding based on the
+// provided parameters. It is a shorthand for Bookmarks.List.
+func (c *Client) ListBookmarks(params ListBookmarksParams) (*ListBookmarksResponse, error) {
+	return c.Bookmarks.List(context.Background(), params)
+}
+
+// ListArchivedBookmarks retrieves a list of archived bookmarks from Linkding.
+// It is a shorthand for Bookmarks.ListArchived.
+func (c *Client) ListArchivedBookmarks(params ListBookmarksParams) (*ListBookmarksResponse, error) {
+	return c.Bookmarks.ListArchived(context.Background(), params)
+}
+
+// ListBookmarksByTag retrieves a list of bookmarks with a tag. The tag is added
+// to the query of params, which otherwise works as for ListBookmarks, e.g. for
+// paging through the results.
+func (c *Client) ListBookmarksByTag(tag string, params ListBookmarksParams) (*ListBookmarksResponse, error) {
+	return c.ListBookmarksByTags([]string{tag}, params)
+}
+
+// ListBookmarksByTags retrieves a list of bookmarks with all of the tags. The
+// tags are added to the query of params, which otherwise works as for
+// ListBookmarks.
+func (c *Client) ListBookmarksByTags(tags []string, params ListBookmarksParams) (*ListBookmarksResponse, error) {
+	params.Query = strings.TrimSpace(params.Query + " " + Search().Tag(tags...).String())
+	return c.Bookmarks.List(context.Background(), params)
+}
+
+// CountBookmarks returns the number of bookmarks matching params. Only one
+// bookmark is requested, so counting is cheap however many match. The limit
+// and offset of params are ignored.
+func (c *Client) CountBookmarks(params ListBookmarksParams) (int, error) {
+	return count(c.Bookmarks.List, params)
+}
+
+// CountArchivedBookmarks returns the number of archived bookmarks matching
+// params, like CountBookmarks.
+func (c *Client) CountArchivedBookmarks(params ListBookmarksParams) (int, error) {
+	return count(c.Bookmarks.ListArchived, params)
+}
+
+func count(list listBookmarksFunc, params ListBookmarksParams) (int, error) {
+	params.Limit = 1
+	params.Offset = 0
+
+	page, err := list(context.Background(), params)
+	if err != nil {
+		return 0, err
+	}
+
+	return page.Count, nil
+}
+
+// GetBookmark retrieves a single bookmark from Linkding. It is a shorthand for
+// Bookmarks.Get.
+func (c *Client) GetBookmark(id int) (*Bookmark, error) {
+	return c.Bookmarks.Get(context.Background(), id)
+}
+
+// BookmarkExists reports whether a bookmark exists, archived or not. It sends
+// a HEAD request, so the bookmark is not transferred, and reports a missing
+// bookmark as false instead of ErrNotFound.
+func (c *Client) BookmarkExists(id int) (bool, error) {
+	body, err := c.makeRequest(context.Background(), http.MethodHead, fmt.Sprintf("/api/bookmarks/%d/", id), nil)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, body.Close()
+}
+
+// CheckBookmark checks if a URL is already bookmarked. It is a shorthand for
+// Bookmarks.Check.
+func (c *Client) CheckBookmark(bookmarkUrl string) (*CheckBookmarkResponse, error) {
+	return c.Bookmarks.Check(context.Background(), bookmarkUrl)
+}
+
+// CreateBookmark creates a new bookmark in Linkding using the provided payload.
+// It is a shorthand for Bookmarks.Create.
+//
+// Build the payload with NewBookmark, which always initializes TagNames.
+func (c *Client) CreateBookmark(payload CreateBookmarkRequest) (*Bookmark, error) {
+	return c.Bookmarks.Create(context.Background(), payload)
+}
+
+// UpdateBookmark updates an existing bookmark in Linkding using the provided
+// payload. It is a shorthand for Bookmarks.Update.
+//
+// Build the payload with NewBookmark, which always initializes TagNames.
+func (c *Client) UpdateBookmark(id int, payload CreateBookmarkRequest) (*Bookmark, error) {
+	return c.Bookmarks.Update(context.Background(), id, payload)
+}
+
+// PatchBookmark updates only the fields of an existing bookmark that are set in
+// the provided payload. It is a shorthand for Bookmarks.Patch.
+func (c *Client) PatchBookmark(id int, payload PatchBookmarkRequest) (*Bookmark, error) {
+	return c.Bookmarks.Patch(context.Background(), id, payload)
+}
+
+// ArchiveBookmark archives a bookmark from Linkding. It is a shorthand for
+// Bookmarks.Archive.
+func (c *Client) ArchiveBookmark(id int) error {
+	return c.Bookmarks.Archive(context.Background(), id)
+}
+
+// UnarchiveBookmark unarchives a bookmark from Linkding. It is a shorthand for
+// Bookmarks.Unarchive.
+func (c *Client) UnarchiveBookmark(id int) error {
+	return c.Bookmarks.Unarchive(context.Background(), id)
+}
+
+// DeleteBookmark deletes a bookmark from Linkding. It is a shorthand for
+// Bookmarks.Delete.
 func (c *Client) DeleteBookmark(id int) error {
-	_, err := c.makeRequest(http.MethodDelete, fmt.Sprintf("/api/bookmarks/%d/", id), nil)
+	return c.Bookmarks.Delete(context.Background(), id)
+}
 
-	return err
+// SetPreviewImage uploads a custom preview image of a bookmark, e.g. a
+// generated screenshot. The Linkding API has no endpoint for this, the server
+// loads preview images from the bookmarked page itself, so it currently
+// always returns ErrUnsupported.
+func (c *Client) SetPreviewImage(bookmarkID int, image io.Reader, contentType string) error {
+	return fmt.Errorf("%w: setting the preview image of bookmark %d", ErrUnsupported, bookmarkID)
 }
 
 func buildBookmarksQueryString(path string, params ListBookmarksParams) string {
-	values := url.Values{}
+	values := make(url.Values, 7)
 
 	if params.Query != "" {
-		values.Set("q", params.Query)
+		values["q"] = []string{params.Query}
 	}
 
 	if params.Limit > 0 {
-		values.Set("limit", strconv.Itoa(params.Limit))
+		values["limit"] = []string{strconv.Itoa(params.Limit)}
 	}
 
 	if params.Offset > 0 {
-		values.Set("offset", strconv.Itoa(params.Offset))
+		values["offset"] = []string{strconv.Itoa(params.Offset)}
 	}
 
 	if params.Unread {
-		values.Set("unread", "yes")
+		values["unread"] = []string{"yes"}
 	}
 
 	if !params.AddedSince.IsZero() {
-		values.Set("added_since", params.AddedSince.Format(time.RFC3339))
+		values["added_since"] = []string{params.AddedSince.Format(time.RFC3339)}
 	}
 
 	if !params.ModifiedSince.IsZero() {
-		values.Set("modified_since", params.AddedSince.Format(time.RFC3339))
+		values["modified_since"] = []string{params.ModifiedSince.Format(time.RFC3339)}
 	}
 
 	if params.Sort != "" {
-		values.Set("sort", params.Sort)
+		values["sort"] = []string{params.Sort}
 	}
 
 	if len(values) > 0 {
-		return fmt.Sprintf("%s?%s", path, values.Encode())
+		return path + "?" + values.Encode()
 	}
 
 	return path
 }
+
+// orEmpty returns tags, or an empty list if tags is nil, as the server rejects
+// a null tag_names.
+func orEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+
+	return tags
+}
@@ -0,0 +1,65 @@
+package linkding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNilTagNamesSentAsEmptyList(t *testing.T) {
+	var tagNames json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			TagNames json.RawMessage `json:"tag_names"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		tagNames = body.TagNames
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	ctx := context.Background()
+	payload := CreateBookmarkRequest{URL: "https://go.dev"}
+
+	tests := []struct {
+		name string
+		send func() (*Bookmark, error)
+	}{
+		{"create", func() (*Bookmark, error) { return c.Bookmarks.Create(ctx, payload) }},
+		{"update", func() (*Bookmark, error) { return c.Bookmarks.Update(ctx, 1, payload) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tagNames = nil
+			if _, err := tt.send(); err != nil {
+				t.Fatal(err)
+			}
+			if string(tagNames) != "[]" {
+				t.Errorf("tag_names = %s, want []", tagNames)
+			}
+		})
+	}
+}
+
+func BenchmarkBuildBookmarksQueryString(b *testing.B) {
+	params := ListBookmarksParams{
+		Query:         "#go error handling",
+		Limit:         100,
+		Offset:        200,
+		Unread:        true,
+		AddedSince:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ModifiedSince: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		Sort:          "added_desc",
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buildBookmarksQueryString("/api/bookmarks/", params)
+	}
+}
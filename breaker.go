@@ -0,0 +1,97 @@
+package linkding
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit
+// breaker is open, see WithCircuitBreaker.
+var ErrCircuitOpen = errors.New("linkding: circuit breaker open, server is failing")
+
+// Defaults of CircuitBreakerOptions.
+const (
+	DefaultCircuitBreakerFailures = 5
+	DefaultCircuitBreakerCooldown = 30 * time.Second
+)
+
+// CircuitBreakerOptions configures WithCircuitBreaker.
+type CircuitBreakerOptions struct {
+	// The number of consecutive failed requests opening the circuit. Defaults
+	// to DefaultCircuitBreakerFailures.
+	Failures int
+	// The time requests fail fast once the circuit is open. Defaults to
+	// DefaultCircuitBreakerCooldown.
+	Cooldown time.Duration
+}
+
+// WithCircuitBreaker fails requests fast with ErrCircuitOpen for a cooldown
+// after a number of consecutive requests failed, e.g. to keep a long-running
+// sync from endlessly retrying a server that is down. A request fails when
+// the server cannot be reached or responds with a server error; responses
+// such as 404 Not Found or 429 Too Many Requests show the server is up.
+//
+// After the cooldown a single request is sent to probe the server. The
+// circuit closes if it succeeds, and opens again for another cooldown if it
+// fails. Each retry of a request counts as a request, see WithRetries.
+func WithCircuitBreaker(opts CircuitBreakerOptions) Option {
+	if opts.Failures <= 0 {
+		opts.Failures = DefaultCircuitBreakerFailures
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = DefaultCircuitBreakerCooldown
+	}
+
+	return func(c *Client) {
+		c.breaker = &breaker{failures: opts.Failures, cooldown: opts.Cooldown}
+	}
+}
+
+type breaker struct {
+	failures int
+	cooldown time.Duration
+
+	mu        sync.Mutex
+	failed    int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow returns ErrCircuitOpen if a request must not be sent now. Once the
+// cooldown passed, it lets a single request through until it is recorded.
+func (b *breaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failed < b.failures {
+		return nil
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return ErrCircuitOpen
+	}
+
+	b.probing = true
+	return nil
+}
+
+// record records the outcome of a request allowed by allow. Requests ended by
+// their context say nothing about the server and are not counted.
+func (b *breaker) record(ctx context.Context, res *http.Response, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	switch {
+	case ctx.Err() != nil:
+	case err != nil || res.StatusCode >= http.StatusInternalServerError:
+		b.failed++
+		if b.failed >= b.failures {
+			b.openUntil = time.Now().Add(b.cooldown)
+		}
+	default:
+		b.failed = 0
+	}
+}
@@ -0,0 +1,309 @@
+package linkding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultConcurrency is the number of concurrent requests used by bulk
+// operations when no concurrency is given.
+const DefaultConcurrency = 4
+
+// BulkItemResult is the outcome of a bulk operation for a single item.
+type BulkItemResult struct {
+	// The position of the item in the input of the operation.
+	Index int
+	// The ID of the bookmark the item refers to, zero if there is none.
+	BookmarkID int
+	// The bookmark returned by the server, for operations returning one.
+	Bookmark *Bookmark
+	// The reason the item failed, nil if it succeeded.
+	Err error
+}
+
+// BulkResult reports the outcome of a bulk operation for each of its items.
+type BulkResult struct {
+	Items     []BulkItemResult
+	Succeeded int
+	Failed    int
+}
+
+// Failures returns the results of the items that failed.
+func (r *BulkResult) Failures() []BulkItemResult {
+	failures := []BulkItemResult{}
+	for _, item := range r.Items {
+		if item.Err != nil {
+			failures = append(failures, item)
+		}
+	}
+
+	return failures
+}
+
+// Err joins the errors of all failed items, naming each item, or returns nil
+// if every item succeeded.
+func (r *BulkResult) Err() error {
+	errs := []error{}
+	for _, item := range r.Failures() {
+		if item.BookmarkID != 0 {
+			errs = append(errs, fmt.Errorf("bookmark %d: %w", item.BookmarkID, item.Err))
+		} else {
+			errs = append(errs, fmt.Errorf("item %d: %w", item.Index, item.Err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func newBulkResult(items []BulkItemResult) *BulkResult {
+	result := &BulkResult{Items: items}
+	for _, item := range items {
+		if item.Err != nil {
+			result.Failed++
+		} else {
+			result.Succeeded++
+		}
+	}
+
+	return result
+}
+
+// CreateBookmarks creates many bookmarks in parallel using at most concurrency
+// concurrent requests. Once ctx is done no further bookmarks are created.
+//
+// The result holds an item for each payload, in the same order, along with
+// the created bookmark.
+func (c *Client) CreateBookmarks(ctx context.Context, payloads []CreateBookmarkRequest, concurrency int) *BulkResult {
+	items := make([]BulkItemResult, len(payloads))
+
+	errs := runPool(ctx, len(payloads), concurrency, func(ctx context.Context, i int) error {
+		bookmark, err := c.Bookmarks.Create(ctx, payloads[i])
+		if err != nil {
+			return err
+		}
+
+		items[i].BookmarkID = bookmark.ID
+		items[i].Bookmark = bookmark
+		return nil
+	})
+	for i, err := range errs {
+		items[i].Index = i
+		items[i].Err = err
+	}
+
+	return newBulkResult(items)
+}
+
+// AddTagsToMatching adds tags to every bookmark, active or archived, matching
+// the search query.
+//
+// Bookmarks that already carry all of the tags are left untouched and are not
+// part of the result. The matching bookmarks are collected before any of them
+// is changed, so the changes cannot affect which bookmarks are visited. An
+// error is only returned if the matching bookmarks could not be listed.
+func (c *Client) AddTagsToMatching(ctx context.Context, query string, tags []string) (*BulkResult, error) {
+	return c.updateTagsMatching(ctx, query, tags, nil)
+}
+
+// RemoveTagsFromMatching removes tags from every bookmark, active or archived,
+// matching the search query. It otherwise behaves like AddTagsToMatching.
+func (c *Client) RemoveTagsFromMatching(ctx context.Context, query string, tags []string) (*BulkResult, error) {
+	return c.updateTagsMatching(ctx, query, nil, tags)
+}
+
+func (c *Client) updateTagsMatching(ctx context.Context, query string, add, remove []string) (*BulkResult, error) {
+	bookmarks, err := c.collectMatching(ctx, ListBookmarksParams{Query: query}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Bookmark{}
+	for _, bookmark := range bookmarks {
+		tags := ChangeTags(bookmark.TagNames, add, remove)
+		if !slices.Equal(tags, bookmark.TagNames) {
+			bookmark.TagNames = tags
+			changes = append(changes, bookmark)
+		}
+	}
+
+	return c.updateEach(ctx, changes, func(ctx context.Context, bookmark Bookmark) (*Bookmark, error) {
+		return c.Bookmarks.Patch(ctx, bookmark.ID, PatchBookmarkRequest{TagNames: &bookmark.TagNames})
+	}), nil
+}
+
+// MarkReadMatching marks every bookmark, active or archived, matching the
+// search query as read. Bookmarks that are already read are left untouched and
+// are not part of the result. An error is only returned if the matching
+// bookmarks could not be listed.
+func (c *Client) MarkReadMatching(ctx context.Context, query string) (*BulkResult, error) {
+	return c.setUnreadMatching(ctx, query, false)
+}
+
+// MarkUnreadMatching marks every bookmark, active or archived, matching the
+// search query as unread. It otherwise behaves like MarkReadMatching.
+func (c *Client) MarkUnreadMatching(ctx context.Context, query string) (*BulkResult, error) {
+	return c.setUnreadMatching(ctx, query, true)
+}
+
+func (c *Client) setUnreadMatching(ctx context.Context, query string, unread bool) (*BulkResult, error) {
+	bookmarks, err := c.collectMatching(ctx, ListBookmarksParams{Query: query}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks = filterBookmarks(bookmarks, func(bookmark Bookmark) bool {
+		return bookmark.Unread != unread
+	})
+
+	return c.updateEach(ctx, bookmarks, func(ctx context.Context, bookmark Bookmark) (*Bookmark, error) {
+		return c.Bookmarks.Patch(ctx, bookmark.ID, PatchBookmarkRequest{Unread: &unread})
+	}), nil
+}
+
+// ArchiveMatching archives every active bookmark matching params and, if it is
+// not nil, the filter function.
+//
+// The filter allows selecting bookmarks by criteria the API does not support,
+// e.g. to archive everything that was read and added more than a year ago:
+//
+//	cutoff := time.Now().AddDate(-1, 0, 0)
+//	client.ArchiveMatching(ctx, linkding.ListBookmarksParams{}, func(b linkding.Bookmark) bool {
+//		return !b.Unread && b.DateAdded.Before(cutoff)
+//	})
+//
+// An error is only returned if the matching bookmarks could not be listed.
+func (c *Client) ArchiveMatching(ctx context.Context, params ListBookmarksParams, filter func(Bookmark) bool) (*BulkResult, error) {
+	bookmarks, err := collect(AllBookmarks(ctx, c, params))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.updateEach(ctx, filterBookmarks(bookmarks, filter), func(ctx context.Context, bookmark Bookmark) (*Bookmark, error) {
+		return nil, c.Bookmarks.Archive(ctx, bookmark.ID)
+	}), nil
+}
+
+// UnarchiveMatching unarchives every archived bookmark matching params and, if
+// it is not nil, the filter function. It otherwise behaves like
+// ArchiveMatching.
+func (c *Client) UnarchiveMatching(ctx context.Context, params ListBookmarksParams, filter func(Bookmark) bool) (*BulkResult, error) {
+	bookmarks, err := collect(AllArchivedBookmarks(ctx, c, params))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.updateEach(ctx, filterBookmarks(bookmarks, filter), func(ctx context.Context, bookmark Bookmark) (*Bookmark, error) {
+		return nil, c.Bookmarks.Unarchive(ctx, bookmark.ID)
+	}), nil
+}
+
+// filterBookmarks returns the bookmarks for which filter returns true, or all
+// of them if filter is nil.
+func filterBookmarks(bookmarks []Bookmark, filter func(Bookmark) bool) []Bookmark {
+	if filter == nil {
+		return bookmarks
+	}
+
+	return slices.DeleteFunc(bookmarks, func(bookmark Bookmark) bool {
+		return !filter(bookmark)
+	})
+}
+
+// collectMatching gathers every active bookmark matching params and, if
+// requested, every matching archived bookmark.
+func (c *Client) collectMatching(ctx context.Context, params ListBookmarksParams, archived bool) ([]Bookmark, error) {
+	if archived {
+		return collect(AllBookmarksIncludingArchived(ctx, c, params))
+	}
+	return collect(AllBookmarks(ctx, c, params))
+}
+
+// updateEach calls fn for every bookmark using the client's concurrency and
+// reports the outcome of each call. The bookmark returned by fn, if any, is
+// recorded in the result.
+func (c *Client) updateEach(ctx context.Context, bookmarks []Bookmark, fn func(ctx context.Context, bookmark Bookmark) (*Bookmark, error)) *BulkResult {
+	items := make([]BulkItemResult, len(bookmarks))
+
+	errs := runPool(ctx, len(bookmarks), c.concurrency, func(ctx context.Context, i int) error {
+		bookmark, err := fn(ctx, bookmarks[i])
+		items[i].Bookmark = bookmark
+
+		return err
+	})
+	for i, err := range errs {
+		items[i].Index = i
+		items[i].BookmarkID = bookmarks[i].ID
+		items[i].Err = err
+	}
+
+	return newBulkResult(items)
+}
+
+// ChangeTags returns a copy of the tag names with the tags of remove left out
+// and the tags of add appended unless already present, comparing names while
+// ignoring case like Linkding does.
+func ChangeTags(names, add, remove []string) []string {
+	names = slices.DeleteFunc(slices.Clone(names), func(name string) bool {
+		return containsTag(remove, name)
+	})
+	for _, tag := range add {
+		if !containsTag(names, tag) {
+			names = append(names, tag)
+		}
+	}
+
+	return names
+}
+
+// containsTag reports whether names contains tag, ignoring case like
+// Linkding does.
+func containsTag(names []string, tag string) bool {
+	return slices.ContainsFunc(names, func(name string) bool {
+		return strings.EqualFold(name, tag)
+	})
+}
+
+// runPool calls fn for every index in [0, n) using at most concurrency
+// goroutines and returns the error of each call. Once ctx is done no further
+// calls are started, and the remaining indexes report the context's error.
+func runPool(ctx context.Context, n, concurrency int, fn func(ctx context.Context, i int) error) []error {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	concurrency = min(concurrency, n)
+
+	errs := make([]error, n)
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = fn(ctx, i)
+			}
+		}()
+	}
+
+	for i := range n {
+		if ctx.Err() != nil {
+			errs[i] = ctx.Err()
+			continue
+		}
+
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return errs
+}
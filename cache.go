@@ -0,0 +1,153 @@
+package linkding
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of CacheOptions.
+const (
+	DefaultCacheTTL        = 30 * time.Second
+	DefaultCacheMaxEntries = 1000
+)
+
+// CacheOptions configures WithCache.
+type CacheOptions struct {
+	// How long responses are cached. Defaults to DefaultCacheTTL.
+	TTL time.Duration
+	// The number of cached responses. Once reached, responses are not cached
+	// until entries expire. Defaults to DefaultCacheMaxEntries.
+	MaxEntries int
+}
+
+// WithCache caches the responses of getting, listing and checking bookmarks
+// in memory, e.g. for dashboards reading the same bookmarks over and over.
+// Updating, archiving or deleting a bookmark removes its cached response and
+// those of every list and check, as do created bookmarks, so the client sees
+// its own changes. Changes made by other clients, or in Linkding's UI, are
+// only seen once cached responses expire. Reads with a timeout or additional
+// headers, or with WithNoCache, are always sent to the server.
+//
+// Responses are not cached by default.
+func WithCache(opts CacheOptions) Option {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultCacheMaxEntries
+	}
+
+	return func(c *Client) {
+		c.cache = &responseCache{opts: opts, entries: map[string]*cacheEntry{}}
+	}
+}
+
+// cachedEndpoint matches the endpoints whose responses are cached, capturing
+// the ID of a single bookmark.
+var cachedEndpoint = regexp.MustCompile(`^/api/bookmarks/(?:|archived/|check/|(\d+)/)$`)
+
+// bookmarkEndpoint matches the endpoints changing bookmarks, capturing the ID
+// of the bookmark changed. Assets are left out, they are not part of the
+// responses cached.
+var bookmarkEndpoint = regexp.MustCompile(`^/api/bookmarks/(?:(\d+)/(?:archive/|unarchive/)?)?$`)
+
+type responseCache struct {
+	opts CacheOptions
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	// Incremented by each write, so responses to reads that started before
+	// a write are not cached after it.
+	generation int
+}
+
+type cacheEntry struct {
+	body    []byte
+	expires time.Time
+	// The bookmark of the response, or 0 for lists and checks.
+	bookmarkID int
+}
+
+// cacheable reports whether the response of a GET request for endpoint is
+// cached, and returns the ID of the bookmark it is about.
+func cacheable(endpoint string) (int, bool) {
+	path, _, _ := strings.Cut(endpoint, "?")
+	match := cachedEndpoint.FindStringSubmatch(path)
+	if match == nil {
+		return 0, false
+	}
+
+	id, _ := strconv.Atoi(match[1])
+	return id, true
+}
+
+// get returns the cached response for endpoint, if any.
+func (c *responseCache) get(endpoint string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.entries[endpoint]
+	if e == nil || time.Now().After(e.expires) {
+		return nil, false
+	}
+
+	return e.body, true
+}
+
+// current returns the generation to pass to store for a read starting now.
+func (c *responseCache) current() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// store caches the response of a read that started at generation, unless a
+// bookmark was changed since.
+func (c *responseCache) store(endpoint string, bookmarkID int, body []byte, generation int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+	if len(c.entries) >= c.opts.MaxEntries {
+		now := time.Now()
+		for key, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	if len(c.entries) < c.opts.MaxEntries {
+		c.entries[endpoint] = &cacheEntry{
+			body:       body,
+			expires:    time.Now().Add(c.opts.TTL),
+			bookmarkID: bookmarkID,
+		}
+	}
+}
+
+// invalidate removes the responses affected by a write to endpoint: those of
+// the bookmark written and of every list and check.
+func (c *responseCache) invalidate(endpoint string) {
+	path, _, _ := strings.Cut(endpoint, "?")
+	match := bookmarkEndpoint.FindStringSubmatch(path)
+	if match == nil {
+		return
+	}
+	id, _ := strconv.Atoi(match[1])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for key, e := range c.entries {
+		if e.bookmarkID == 0 || e.bookmarkID == id {
+			delete(c.entries, key)
+		}
+	}
+}
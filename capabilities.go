@@ -0,0 +1,167 @@
+package linkding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Capabilities describes the features available on a Linkding instance.
+type Capabilities struct {
+	// The version reported by the instance, empty if it could not be
+	// determined.
+	Version string
+	// Whether the bookmark assets API is available.
+	Assets bool
+	// Whether the bundles API is available.
+	Bundles bool
+	// Whether bookmarks carry a notes field.
+	Notes bool
+}
+
+type healthResponse struct {
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+// DetectCapabilities probes the Linkding instance to find out which features
+// it supports.
+//
+// The version is read from the health endpoint, and bundles are detected by
+// probing their endpoint. Bookmark-level features (assets and notes) have no
+// endpoint of their own, so they are derived from the version. Only if the
+// version is unknown are they detected by inspecting an existing bookmark, in
+// which case they are reported as unsupported on an account without any
+// bookmarks.
+func (c *Client) DetectCapabilities() (*Capabilities, error) {
+	capabilities := &Capabilities{}
+
+	body, err := c.probe("/health")
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		defer body.Close()
+
+		health := &healthResponse{}
+		if err := json.NewDecoder(body).Decode(health); err == nil {
+			capabilities.Version = health.Version
+		}
+	}
+
+	bundles, err := c.probe("/api/bundles/?limit=1")
+	if err != nil {
+		return nil, err
+	}
+	if bundles != nil {
+		bundles.Close()
+		capabilities.Bundles = true
+	}
+
+	if major, minor, ok := parseVersion(capabilities.Version); ok {
+		capabilities.Notes = versionAtLeast(major, minor, notesVersion)
+		capabilities.Assets = versionAtLeast(major, minor, assetsVersion)
+		return capabilities, nil
+	}
+
+	bookmark, err := c.sampleBookmark()
+	if err != nil {
+		return nil, err
+	}
+	if bookmark == nil {
+		return capabilities, nil
+	}
+
+	_, capabilities.Notes = bookmark["notes"]
+
+	var id int
+	if err := json.Unmarshal(bookmark["id"], &id); err != nil {
+		return nil, fmt.Errorf("linkding: decoding bookmark id: %w", err)
+	}
+
+	assets, err := c.probe(fmt.Sprintf("/api/bookmarks/%d/assets/", id))
+	if err != nil {
+		return nil, err
+	}
+	if assets != nil {
+		assets.Close()
+		capabilities.Assets = true
+	}
+
+	return capabilities, nil
+}
+
+// The first versions with notes on bookmarks and the bookmark assets API, as
+// major and minor version.
+var (
+	notesVersion  = [2]int{1, 21}
+	assetsVersion = [2]int{1, 36}
+)
+
+// parseVersion returns the major and minor version of a version such as
+// "1.36.0", ignoring anything after them.
+func parseVersion(version string) (major, minor int, ok bool) {
+	majorPart, rest, found := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	if !found {
+		return 0, 0, false
+	}
+	minorPart, _, _ := strings.Cut(rest, ".")
+	minorPart = strings.TrimRightFunc(minorPart, func(r rune) bool { return r < '0' || r > '9' })
+
+	major, err := strconv.Atoi(majorPart)
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(minorPart)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return major, minor, true
+}
+
+func versionAtLeast(major, minor int, min [2]int) bool {
+	return major > min[0] || major == min[0] && minor >= min[1]
+}
+
+// probe requests the given endpoint and returns a nil body, rather than an
+// error, when the endpoint does not exist.
+func (c *Client) probe(endpoint string) (io.ReadCloser, error) {
+	body, err := c.makeRequest(context.Background(), http.MethodGet, endpoint, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+
+	return body, err
+}
+
+// sampleBookmark returns the raw fields of the first active or archived
+// bookmark, or nil if the account has no bookmarks.
+func (c *Client) sampleBookmark() (map[string]json.RawMessage, error) {
+	for _, endpoint := range []string{"/api/bookmarks/?limit=1", "/api/bookmarks/archived/?limit=1"} {
+		body, err := c.makeRequest(context.Background(), http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		result := struct {
+			Results []map[string]json.RawMessage `json:"results"`
+		}{}
+		err = json.NewDecoder(body).Decode(&result)
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(result.Results) > 0 {
+			return result.Results[0], nil
+		}
+	}
+
+	return nil, nil
+}
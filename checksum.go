@@ -0,0 +1,94 @@
+package linkding
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+)
+
+// ChecksumReader computes the SHA-256 digest of the content read through it,
+// e.g. of an asset while it is downloaded or uploaded:
+//
+//	content := linkding.NewChecksumReader(file)
+//	asset, err := client.UploadBookmarkAsset(bookmarkID, name, content)
+//	digest := content.Sum()
+//
+// The Client methods ending in WithChecksum do this for the common cases.
+type ChecksumReader struct {
+	r    io.Reader
+	hash hash.Hash
+}
+
+// NewChecksumReader returns a reader computing the digest of what is read
+// from r.
+func NewChecksumReader(r io.Reader) *ChecksumReader {
+	return &ChecksumReader{r: r, hash: sha256.New()}
+}
+
+func (r *ChecksumReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.hash.Write(p[:n])
+	return n, err
+}
+
+// Sum returns the hex encoded digest of the content read so far.
+func (r *ChecksumReader) Sum() string {
+	return hex.EncodeToString(r.hash.Sum(nil))
+}
+
+// UploadBookmarkAssetWithChecksum uploads a file as a new asset of a specific
+// bookmark, like UploadBookmarkAsset, and also returns the hex encoded SHA-256
+// digest of the uploaded content.
+func (c *Client) UploadBookmarkAssetWithChecksum(bookmarkID int, name string, r io.Reader) (*BookmarkAsset, string, error) {
+	content := NewChecksumReader(r)
+	asset, err := c.UploadBookmarkAsset(bookmarkID, name, content)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return asset, content.Sum(), nil
+}
+
+// DownloadBookmarkAssetWithChecksum copies the content of an asset of a
+// specific bookmark to w and returns its hex encoded SHA-256 digest.
+func (c *Client) DownloadBookmarkAssetWithChecksum(bookmarkID int, id int, w io.Writer) (string, error) {
+	body, err := c.DownloadBookmarkAsset(bookmarkID, id)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	content := NewChecksumReader(body)
+	if _, err := io.Copy(w, content); err != nil {
+		return "", err
+	}
+
+	return content.Sum(), nil
+}
+
+// DownloadBookmarkAssetToFileWithChecksum downloads an asset of a specific
+// bookmark into dir, like DownloadBookmarkAssetToFile, and returns the path of
+// the file and the hex encoded SHA-256 digest of its content. The digest
+// covers the whole file, including the part downloaded by an earlier call
+// that was interrupted.
+func (c *Client) DownloadBookmarkAssetToFileWithChecksum(bookmarkID int, assetID int, dir string) (string, string, error) {
+	path, err := c.DownloadBookmarkAssetToFile(bookmarkID, assetID, dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	content := NewChecksumReader(file)
+	if _, err := io.Copy(io.Discard, content); err != nil {
+		return "", "", err
+	}
+
+	return path, content.Sum(), nil
+}
@@ -0,0 +1,65 @@
+package linkding
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAssetChecksums(t *testing.T) {
+	const content = "hello"
+	sum := sha256.Sum256([]byte(content))
+	want := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			io.Copy(io.Discard, r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":2}`))
+		case r.URL.Path == "/api/bookmarks/1/assets/2/download/":
+			w.Header().Set("Content-Disposition", `attachment; filename="hello.txt"`)
+			w.Write([]byte(content))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	c := NewClient(server.URL, "token")
+
+	t.Run("upload", func(t *testing.T) {
+		_, got, err := c.UploadBookmarkAssetWithChecksum(1, "hello.txt", bytes.NewBufferString(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("digest = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("download", func(t *testing.T) {
+		var w bytes.Buffer
+		got, err := c.DownloadBookmarkAssetWithChecksum(1, 2, &w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want || w.String() != content {
+			t.Errorf("digest = %s and content %q, want %s and %q", got, w.String(), want, content)
+		}
+	})
+
+	t.Run("download to file", func(t *testing.T) {
+		_, got, err := c.DownloadBookmarkAssetToFileWithChecksum(1, 2, t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("digest = %s, want %s", got, want)
+		}
+	})
+}
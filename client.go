@@ -2,32 +2,102 @@ package linkding
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
+	"sync"
+	"time"
 )
 
 // Client handles all interactions with the Linkding API.
+//
+// The API is organized into services, one for each group of endpoints. Each
+// service is an interface, so it can be replaced independently, e.g. with a
+// mock. The methods on Client itself are shorthands for the service methods
+// that use a background context.
 type Client struct {
-	baseURL string
-	token   string
-	http    *http.Client
+	baseURL       string
+	token         string
+	authorization string
+	http          *http.Client
+
+	decodeMode    DecodeMode
+	concurrency   int
+	urlNormalizer *URLNormalizer
+	tagNormalizer *TagNormalizer
+	expand        *ExpandOptions
+	progress      ProgressFunc
+	retries       int
+	onRetry       RetryFunc
+	breaker       *breaker
+	coalescer     *coalescer
+	cache         *responseCache
+	header        http.Header
+
+	Bookmarks BookmarksService
+	Tags      TagsService
+	Assets    AssetsService
+	User      UserService
 }
 
+// BookmarkClient is the set of operations offered by Client. Code that only
+// depends on this interface can be exercised without a Linkding server, for
+// example using the in-memory fake in the linkdingtest package.
+type BookmarkClient interface {
+	ListBookmarks(params ListBookmarksParams) (*ListBookmarksResponse, error)
+	ListArchivedBookmarks(params ListBookmarksParams) (*ListBookmarksResponse, error)
+	GetBookmark(id int) (*Bookmark, error)
+	CheckBookmark(bookmarkUrl string) (*CheckBookmarkResponse, error)
+	CreateBookmark(payload CreateBookmarkRequest) (*Bookmark, error)
+	UpdateBookmark(id int, payload CreateBookmarkRequest) (*Bookmark, error)
+	PatchBookmark(id int, payload PatchBookmarkRequest) (*Bookmark, error)
+	ArchiveBookmark(id int) error
+	UnarchiveBookmark(id int) error
+	DeleteBookmark(id int) error
+
+	ListTags(params ListTagsParams) (*ListTagsResponse, error)
+	GetTag(id int) (*Tag, error)
+	CreateTag(name string) (*Tag, error)
+
+	ListBookmarkAssets(bookmarkID int, params ...ListBookmarkAssetsParams) (*ListBookmarkAssetsResponse, error)
+	GetBookmarkAsset(bookmarkID int, id int) (*BookmarkAsset, error)
+	UploadBookmarkAsset(bookmarkID int, name string, r io.Reader) (*BookmarkAsset, error)
+	DownloadBookmarkAsset(bookmarkID int, id int) (io.ReadCloser, error)
+	DeleteBookmarkAsset(bookmarkID int, id int) error
+
+	GetUserPreferences() (*UserPreferences, error)
+	DetectCapabilities() (*Capabilities, error)
+}
+
+var _ BookmarkClient = (*Client)(nil)
+
 // NewClient creates a new Linkding API client using the given URL and token.
 //
 // The URL provided must be a complete URL. It must contain a schema and the
 // domain for the API. Do not include the prefix path of the API.
 // e.g. "https://linkding.example.org".
-func NewClient(baseURL, token string) *Client {
-	return &Client{
-		baseURL: baseURL,
-		token:   token,
-		http:    &http.Client{},
+//
+// The behavior of the client can be customized with options.
+func NewClient(baseURL, token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:       baseURL,
+		token:         token,
+		authorization: "Token " + token,
+		http:          &http.Client{},
+	}
+	c.Bookmarks = &bookmarksService{client: c}
+	c.Tags = &tagsService{client: c}
+	c.Assets = &assetsService{client: c}
+	c.User = &userService{client: c}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
 }
 
 var (
@@ -35,58 +105,284 @@ var (
 	ErrUnauthorized        = errors.New("linkding: unauthorized")
 	ErrNotFound            = errors.New("linkding: not found")
 	ErrBadRequest          = errors.New("linkding: bad request")
+	ErrTooManyRequests     = errors.New("linkding: too many requests")
 )
 
-func (c *Client) makeRequest(method, endpoint string, payload interface{}) (io.ReadCloser, error) {
-	uri, err := url.Parse(c.baseURL + endpoint)
-	if err != nil {
-		return nil, err
+func (c *Client) makeRequest(ctx context.Context, method, endpoint string, payload interface{}, opts ...RequestOption) (io.ReadCloser, error) {
+	switch {
+	case method == http.MethodGet && payload == nil:
+		return c.read(ctx, endpoint, opts...)
+	case method == http.MethodHead:
+		// HEAD requests change nothing, and their empty responses are not
+		// cached.
+		return c.sendRequest(ctx, method, endpoint, nil, "application/json", opts...)
+	}
+	if c.cache != nil {
+		// Reads sent while the write is in flight may see either state.
+		c.cache.invalidate(endpoint)
+		defer c.cache.invalidate(endpoint)
 	}
 
-	var body io.Reader
+	var body requestBody
 	if payload != nil {
-		payloadBytes, err := json.Marshal(payload)
-		if err != nil {
+		buf := bufferPool.Get().(*bytes.Buffer)
+		if err := json.NewEncoder(buf).Encode(payload); err != nil {
+			releaseBuffer(buf)
 			return nil, err
 		}
 
-		body = bytes.NewReader(payloadBytes)
+		body = &pooledBody{buf: buf}
+	}
+
+	return c.sendRequest(ctx, method, endpoint, body, "application/json", opts...)
+}
+
+// read sends a GET request, answering it from the cache or sharing the
+// response of an identical request in flight if enabled. Reads with a timeout
+// or additional headers are sent as they are asked for.
+func (c *Client) read(ctx context.Context, endpoint string, opts ...RequestOption) (io.ReadCloser, error) {
+	send := func(ctx context.Context) (io.ReadCloser, error) {
+		return c.sendRequest(ctx, http.MethodGet, endpoint, nil, "application/json", opts...)
+	}
+
+	var config requestConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if c.cache != nil && config.timeout == 0 && config.header == nil && !config.noCache {
+		if bookmarkID, ok := cacheable(endpoint); ok {
+			if body, ok := c.cache.get(endpoint); ok {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+
+			generation := c.cache.current()
+			uncached := send
+			send = func(ctx context.Context) (io.ReadCloser, error) {
+				body, err := readAll(uncached(ctx))
+				if err != nil {
+					return nil, err
+				}
+				c.cache.store(endpoint, bookmarkID, body, generation)
+
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+	}
+
+	if c.coalescer != nil && len(opts) == 0 {
+		return c.coalescer.do(ctx, endpoint, send)
 	}
 
-	req, err := http.NewRequest(method, uri.String(), body)
+	return send(ctx)
+}
+
+// requestBody is an encoded request body of known length, as the server does
+// not accept chunked requests.
+type requestBody interface {
+	io.ReadCloser
+	Len() int64
+}
+
+// sendRequest sends a request with an already encoded body, which may be nil,
+// and maps error responses the same way as makeRequest.
+func (c *Client) sendRequest(ctx context.Context, method, endpoint string, body requestBody, contentType string, opts ...RequestOption) (io.ReadCloser, error) {
+	res, err := c.do(ctx, method, endpoint, body, contentType, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Authorization", fmt.Sprintf("Token %s", c.token))
+	return res.Body, nil
+}
+
+// do is sendRequest returning the whole response, for the callers that need
+// its headers.
+func (c *Client) do(ctx context.Context, method, endpoint string, body requestBody, contentType string, opts ...RequestOption) (*http.Response, error) {
+	var config requestConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if config.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, config.timeout)
+	}
+
+	// See retryable for which failures are retried. Encoded payloads are
+	// copied so they can be sent again, as the transport releases a pooled
+	// body once it is sent. Streamed uploads are only sent once.
+	retries := c.retries
+	if config.noRetry {
+		retries = 0
+	}
+	var payload []byte
+	switch b := body.(type) {
+	case nil:
+	case *pooledBody:
+		if retries > 0 {
+			payload = bytes.Clone(b.buf.Bytes())
+			b.Close()
+		}
+	default:
+		retries = 0
+	}
 
-	res, err := c.http.Do(req)
+	for attempt := 0; ; attempt++ {
+		if c.breaker != nil {
+			if err := c.breaker.allow(time.Now()); err != nil {
+				if body != nil {
+					body.Close()
+				}
+				cancel()
+				return nil, err
+			}
+		}
+
+		attemptBody := body
+		if payload != nil {
+			attemptBody = &streamBody{Reader: bytes.NewReader(payload), length: int64(len(payload))}
+		}
+
+		res, err := c.send(ctx, method, endpoint, attemptBody, contentType, config.header)
+		if c.breaker != nil {
+			c.breaker.record(ctx, res, err)
+		}
+		delay, ok := retryAfter(res, time.Now())
+		if !ok {
+			delay = retryDelay << attempt
+		}
+		if attempt < retries && retryable(method, res, err) && delay <= MaxRetryAfter && ctx.Err() == nil {
+			if c.onRetry != nil {
+				c.onRetry(attempt+1, delay, retryReason(res, err))
+			}
+
+			if res != nil {
+				res.Body.Close()
+			}
+			if err := sleep(ctx, delay); err != nil {
+				cancel()
+				return nil, err
+			}
+			continue
+		}
+		if err == nil {
+			err = check(res)
+		}
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		// The timeout covers reading the body, so it ends when the body is
+		// closed.
+		res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+		return res, nil
+	}
+}
+
+// send sends a single request.
+func (c *Client) send(ctx context.Context, method, endpoint string, body requestBody, contentType string, header http.Header) (*http.Response, error) {
+	// The body is passed as an untyped nil when there is no payload, as
+	// otherwise the request would be sent with an empty chunked body.
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = body
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, reqBody)
 	if err != nil {
+		if body != nil {
+			body.Close()
+		}
 		return nil, err
 	}
+	if body != nil {
+		req.ContentLength = body.Len()
+	}
 
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", c.authorization)
+
+	return c.http.Do(req)
+}
+
+// check maps error responses to errors, closing their body.
+func check(res *http.Response) error {
 	switch res.StatusCode {
 	case http.StatusInternalServerError:
 		res.Body.Close()
-		return nil, ErrInternalServerError
+		return ErrInternalServerError
 	case http.StatusUnauthorized:
 		res.Body.Close()
-		return nil, ErrUnauthorized
+		return ErrUnauthorized
 	case http.StatusNotFound:
 		res.Body.Close()
-		return nil, ErrNotFound
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		res.Body.Close()
+		return ErrTooManyRequests
 	case http.StatusBadRequest:
 		defer res.Body.Close()
 
 		bodyBytes, err := io.ReadAll(res.Body)
 		if err != nil {
-			return nil, fmt.Errorf("%w (%v)", ErrBadRequest, err)
+			return fmt.Errorf("%w (%v)", ErrBadRequest, err)
 		}
 
-		return nil, fmt.Errorf("%w (%s)", ErrBadRequest, string(bodyBytes))
+		return fmt.Errorf("%w (%s)", ErrBadRequest, string(bodyBytes))
 	}
 
-	return res.Body, nil
+	return nil
+}
+
+// bufferPool holds the buffers used to encode request bodies.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// maxPooledBufferSize is the capacity above which buffers are dropped instead
+// of being returned to the pool, so a single large request does not pin its
+// memory forever.
+const maxPooledBufferSize = 64 << 10
+
+func releaseBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// pooledBody is a request body backed by a pooled buffer. The buffer is
+// returned to the pool when the transport closes the body, which may happen
+// after the response has been received.
+type pooledBody struct {
+	buf  *bytes.Buffer
+	once sync.Once
+}
+
+func (b *pooledBody) Read(p []byte) (int, error) {
+	return b.buf.Read(p)
+}
+
+func (b *pooledBody) Len() int64 {
+	return int64(b.buf.Len())
+}
+
+func (b *pooledBody) Close() error {
+	b.once.Do(func() {
+		releaseBuffer(b.buf)
+	})
+
+	return nil
 }
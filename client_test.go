@@ -0,0 +1,32 @@
+package linkding
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func BenchmarkMakeRequest(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	payload := CreateBookmarkRequest{URL: "https://go.dev", Title: "Go", TagNames: []string{"go", "programming"}}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		body, err := c.makeRequest(ctx, http.MethodPost, "/api/bookmarks/", payload)
+		if err != nil {
+			b.Fatal(err)
+		}
+		io.Copy(io.Discard, body)
+		body.Close()
+	}
+}
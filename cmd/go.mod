@@ -0,0 +1,19 @@
+module github.com/larcher/go-linkding/cmd
+
+go 1.23.0
+
+require (
+	github.com/larcher/go-linkding v0.0.0
+	github.com/spf13/cobra v1.10.1
+)
+
+require (
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/spf13/pflag v1.0.9 // indirect
+	go.etcd.io/bbolt v1.4.3 // indirect
+	golang.org/x/net v0.43.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
+)
+
+replace github.com/larcher/go-linkding => ../
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/stats"
+)
+
+// exporter computes the statistics of an account periodically and serves the
+// latest as metrics.
+type exporter struct {
+	client  *linkding.Client
+	topTags int
+
+	mu          sync.Mutex
+	report      *stats.Report
+	up          bool
+	latency     time.Duration
+	duration    time.Duration
+	lastSuccess time.Time
+	errors      int
+}
+
+// run computes the statistics every interval until ctx is done.
+func (e *exporter) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.collect(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *exporter) collect(ctx context.Context) {
+	start := time.Now()
+	_, err := e.client.Bookmarks.List(ctx, linkding.ListBookmarksParams{Limit: 1})
+	latency := time.Since(start)
+
+	var report *stats.Report
+	if err == nil {
+		report, err = stats.Account(ctx, e.client)
+	}
+	duration := time.Since(start)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.duration = duration
+	e.up = err == nil
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Print(err)
+			e.errors++
+		}
+		return
+	}
+	e.report = report
+	e.latency = latency
+	e.lastSuccess = time.Now()
+}
+
+// ServeHTTP writes the metrics in Prometheus' text format.
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var buf bytes.Buffer
+	metric := func(name, kind, help string, value float64) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", name, help, name, kind, name, value)
+	}
+
+	metric("linkding_up", "gauge", "Whether the latest collection succeeded.", boolValue(e.up))
+	metric("linkding_collection_duration_seconds", "gauge", "The duration of the latest collection.", e.duration.Seconds())
+	metric("linkding_collection_errors_total", "counter", "The number of failed collections.", float64(e.errors))
+
+	// Nothing else is known until a collection succeeded.
+	if e.report != nil {
+		report := e.report
+		metric("linkding_last_success_timestamp_seconds", "gauge", "The time of the latest successful collection.", float64(e.lastSuccess.Unix()))
+		metric("linkding_api_latency_seconds", "gauge", "The duration of a request listing one bookmark.", e.latency.Seconds())
+		metric("linkding_bookmarks", "gauge", "The number of bookmarks, active and archived.", float64(report.Total))
+		metric("linkding_bookmarks_unread", "gauge", "The number of unread bookmarks.", float64(report.Unread))
+		metric("linkding_bookmarks_archived", "gauge", "The number of archived bookmarks.", float64(report.Archived))
+		metric("linkding_bookmarks_shared", "gauge", "The number of shared bookmarks.", float64(report.Shared))
+		metric("linkding_bookmarks_untagged", "gauge", "The number of bookmarks without tags.", float64(report.Untagged))
+		metric("linkding_tags", "gauge", "The number of tags in use.", float64(len(report.ByTag)))
+
+		if e.topTags > 0 {
+			fmt.Fprintf(&buf, "# HELP linkding_tag_bookmarks The number of bookmarks per tag, for the most used tags.\n# TYPE linkding_tag_bookmarks gauge\n")
+			for _, count := range stats.Top(report.ByTag, e.topTags) {
+				fmt.Fprintf(&buf, "linkding_tag_bookmarks{tag=\"%s\"} %d\n", labelReplacer.Replace(count.Key), count.Count)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// labelReplacer escapes label values.
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
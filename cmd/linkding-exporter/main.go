@@ -0,0 +1,92 @@
+// Command linkding-exporter serves statistics about the bookmarks of a Linkding
+// account as Prometheus metrics, for dashboards of homelab services.
+//
+// The statistics are computed in the background, as reading every bookmark of
+// a large account takes longer than a scrape should, so scrapes return the
+// result of the latest run:
+//
+//	linkding-exporter -profile home -listen :9877 -interval 5m
+//
+// The metrics:
+//
+//	linkding_up                               1 if the latest run succeeded
+//	linkding_bookmarks                        the number of bookmarks
+//	linkding_bookmarks_unread                 the number of unread bookmarks
+//	linkding_bookmarks_archived               the number of archived bookmarks
+//	linkding_bookmarks_shared                 the number of shared bookmarks
+//	linkding_bookmarks_untagged               the number of untagged bookmarks
+//	linkding_tags                             the number of tags in use
+//	linkding_tag_bookmarks{tag}               the number of bookmarks per tag
+//	linkding_api_latency_seconds              the duration of a small API request
+//	linkding_collection_duration_seconds      the duration of the latest run
+//	linkding_last_success_timestamp_seconds   the time of the latest successful run
+//	linkding_collection_errors_total          the number of failed runs
+//
+// The server is selected with a profile of the configuration file, see the
+// config package, or with the LINKDING_URL and LINKDING_TOKEN environment
+// variables.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/larcher/go-linkding/config"
+)
+
+func main() {
+	configPath := flag.String("config", "", "the configuration file (default the user configuration directory)")
+	profileName := flag.String("profile", "", "the profile of the server to use")
+	listen := flag.String("listen", ":9877", "the address to serve metrics on")
+	interval := flag.Duration("interval", 5*time.Minute, "the time between computing the statistics")
+	topTags := flag.Int("tags", 50, "the number of tags with their own series, the most used first; 0 for none")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("linkding-exporter: ")
+
+	var cfg *config.Config
+	var err error
+	if *configPath != "" {
+		cfg, err = config.Load(*configPath)
+	} else {
+		cfg, err = config.LoadDefault()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	profile, err := cfg.Profile(*profileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	e := &exporter{client: profile.Client(), topTags: *topTags}
+	go e.run(ctx, *interval)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", e)
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><a href="/metrics">Metrics</a></body></html>`))
+	})
+
+	server := &http.Server{Addr: *listen, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
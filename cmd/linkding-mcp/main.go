@@ -0,0 +1,64 @@
+// Command linkding-mcp is a Model Context Protocol server giving AI assistants
+// access to the bookmarks of a Linkding server. Assistants can search
+// bookmarks, read their notes and the text of their snapshots, and save new
+// links.
+//
+// The server speaks MCP over standard input and output. It uses the profile of
+// the configuration file selected with -profile, see the config package, or the
+// LINKDING_URL and LINKDING_TOKEN environment variables. A typical entry in
+// the configuration of an assistant:
+//
+//	{
+//	  "mcpServers": {
+//	    "linkding": {
+//	      "command": "linkding-mcp",
+//	      "args": ["-profile", "home"]
+//	    }
+//	  }
+//	}
+//
+// With -read-only, the tools changing bookmarks are left out.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/larcher/go-linkding/config"
+)
+
+func main() {
+	configPath := flag.String("config", "", "the configuration file (default the user configuration directory)")
+	profileName := flag.String("profile", "", "the profile of the server to use")
+	readOnly := flag.Bool("read-only", false, "only provide the tools reading bookmarks")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("linkding-mcp: ")
+
+	var cfg *config.Config
+	var err error
+	if *configPath != "" {
+		cfg, err = config.Load(*configPath)
+	} else {
+		cfg, err = config.LoadDefault()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	profile, err := cfg.Profile(*profileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	s := &server{tools: newTools(profile.Client(), *readOnly)}
+	if err := s.serve(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		log.Fatal(err)
+	}
+}
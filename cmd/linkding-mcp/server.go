@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"slices"
+)
+
+// protocolVersions are the versions of the protocol supported, latest first.
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// The error codes of JSON-RPC.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// server answers the requests of an MCP client.
+type server struct {
+	tools []tool
+}
+
+// serve reads requests from r, one JSON message per line, and writes the
+// responses to w until r is exhausted or ctx is done.
+func (s *server) serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	encoder := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			err := encoder.Encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, err.Error()}})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		// Notifications, e.g. notifications/initialized, get no response.
+		if req.ID == nil {
+			continue
+		}
+
+		resp := response{JSONRPC: "2.0", ID: req.ID}
+		resp.Result, resp.Error = s.handle(ctx, req)
+		if err := encoder.Encode(resp); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (s *server) handle(ctx context.Context, req request) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		version := protocolVersions[0]
+		if slices.Contains(protocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "linkding", "version": "1.0.0"},
+			"instructions": "Tools for the user's Linkding bookmarks. Search before adding a link, " +
+				"as adding a URL that is already bookmarked updates the bookmark.",
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		i := slices.IndexFunc(s.tools, func(t tool) bool { return t.Name == params.Name })
+		if i < 0 {
+			return nil, &rpcError{codeInvalidParams, "unknown tool " + params.Name}
+		}
+
+		return s.tools[i].call(ctx, params.Arguments), nil
+	case "":
+		return nil, &rpcError{codeInvalidRequest, "missing method"}
+	default:
+		return nil, &rpcError{codeMethodNotFound, "unknown method " + req.Method}
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/extract"
+)
+
+// DefaultSearchLimit is the number of bookmarks returned by search_bookmarks
+// if the assistant does not ask for a number.
+const DefaultSearchLimit = 20
+
+// tool is a tool offered to the assistant.
+type tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+	Annotations annotations    `json:"annotations"`
+
+	run func(ctx context.Context, arguments json.RawMessage) (any, error)
+}
+
+type annotations struct {
+	ReadOnlyHint bool `json:"readOnlyHint"`
+}
+
+// newTool returns a tool calling fn with the arguments decoded into T.
+func newTool[T any](name, description string, readOnly bool, properties map[string]any, required []string, fn func(ctx context.Context, args T) (any, error)) tool {
+	return tool{
+		Name:        name,
+		Description: description,
+		InputSchema: map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		},
+		Annotations: annotations{ReadOnlyHint: readOnly},
+		run: func(ctx context.Context, arguments json.RawMessage) (any, error) {
+			var args T
+			if len(arguments) > 0 {
+				if err := json.Unmarshal(arguments, &args); err != nil {
+					return nil, fmt.Errorf("invalid arguments: %w", err)
+				}
+			}
+			return fn(ctx, args)
+		},
+	}
+}
+
+// call runs the tool and returns the result of tools/call. Errors are
+// reported to the assistant in the result, so it can correct its call.
+func (t tool) call(ctx context.Context, arguments json.RawMessage) any {
+	v, err := t.run(ctx, arguments)
+	if err != nil {
+		return textResult(err.Error(), true)
+	}
+
+	text, ok := v.(string)
+	if !ok {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return textResult(err.Error(), true)
+		}
+		text = string(data)
+	}
+
+	return textResult(text, false)
+}
+
+func textResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []any{map[string]any{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// bookmark is a bookmark as shown to the assistant, leaving out the fields
+// only of use to Linkding's UI.
+type bookmark struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Notes       string    `json:"notes,omitempty"`
+	Tags        []string  `json:"tags"`
+	Unread      bool      `json:"unread"`
+	Archived    bool      `json:"archived"`
+	Added       time.Time `json:"added"`
+}
+
+func newBookmark(b linkding.Bookmark, notes bool) bookmark {
+	view := bookmark{
+		ID:          b.ID,
+		URL:         b.URL,
+		Title:       cmp.Or(b.Title, b.WebsiteTitle),
+		Description: cmp.Or(b.Description, b.WebsiteDescription),
+		Tags:        b.TagNames,
+		Unread:      b.Unread,
+		Archived:    b.IsArchived,
+		Added:       b.DateAdded,
+	}
+	if view.Tags == nil {
+		view.Tags = []string{}
+	}
+	if notes {
+		view.Notes = b.Notes
+	}
+
+	return view
+}
+
+// newTools returns the tools using c. With readOnly, the tools changing
+// bookmarks are left out.
+func newTools(c *linkding.Client, readOnly bool) []tool {
+	id := map[string]any{"type": "integer", "description": "The ID of the bookmark."}
+	tags := func(description string) map[string]any {
+		return map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": description}
+	}
+
+	tools := []tool{
+		newTool("search_bookmarks",
+			"Search the bookmarks. The query uses Linkding's search syntax: words match the title, "+
+				"description, notes and URL, #tag matches a tag, and !untagged and !unread match "+
+				"untagged and unread bookmarks. An empty query lists the most recent bookmarks.",
+			true,
+			map[string]any{
+				"query":    map[string]any{"type": "string", "description": "The search query."},
+				"archived": map[string]any{"type": "boolean", "description": "Search the archived bookmarks instead."},
+				"unread":   map[string]any{"type": "boolean", "description": "Only return unread bookmarks."},
+				"limit":    map[string]any{"type": "integer", "description": fmt.Sprintf("The maximum number of bookmarks returned, %d by default.", DefaultSearchLimit)},
+			},
+			[]string{},
+			func(ctx context.Context, args struct {
+				Query    string `json:"query"`
+				Archived bool   `json:"archived"`
+				Unread   bool   `json:"unread"`
+				Limit    int    `json:"limit"`
+			}) (any, error) {
+				params := linkding.ListBookmarksParams{
+					Query:  args.Query,
+					Unread: args.Unread,
+					Limit:  cmp.Or(args.Limit, DefaultSearchLimit),
+				}
+				list := c.Bookmarks.List
+				if args.Archived {
+					list = c.Bookmarks.ListArchived
+				}
+
+				resp, err := list(ctx, params)
+				if err != nil {
+					return nil, err
+				}
+				results := make([]bookmark, len(resp.Results))
+				for i, b := range resp.Results {
+					results[i] = newBookmark(b, false)
+				}
+
+				return map[string]any{"count": resp.Count, "bookmarks": results}, nil
+			}),
+
+		newTool("get_bookmark",
+			"Get a bookmark, including its notes.",
+			true,
+			map[string]any{"id": id},
+			[]string{"id"},
+			func(ctx context.Context, args struct {
+				ID int `json:"id"`
+			}) (any, error) {
+				b, err := c.Bookmarks.Get(ctx, args.ID)
+				if err != nil {
+					return nil, err
+				}
+				return newBookmark(*b, true), nil
+			}),
+
+		newTool("get_notes",
+			"Get the notes of a bookmark, in Markdown.",
+			true,
+			map[string]any{"id": id},
+			[]string{"id"},
+			func(ctx context.Context, args struct {
+				ID int `json:"id"`
+			}) (any, error) {
+				b, err := c.Bookmarks.Get(ctx, args.ID)
+				if err != nil {
+					return nil, err
+				}
+				return cmp.Or(b.Notes, "The bookmark has no notes."), nil
+			}),
+
+		newTool("get_content",
+			"Get the text of the page of a bookmark from the latest snapshot archived by Linkding.",
+			true,
+			map[string]any{"id": id},
+			[]string{"id"},
+			func(ctx context.Context, args struct {
+				ID int `json:"id"`
+			}) (any, error) {
+				article, err := extract.Snapshot(ctx, c, args.ID)
+				if errors.Is(err, extract.ErrNoSnapshot) {
+					return nil, errors.New("the bookmark has no snapshot")
+				}
+				if err != nil {
+					return nil, err
+				}
+				return strings.TrimSpace(article.Title + "\n\n" + article.Text), nil
+			}),
+
+		newTool("list_tags",
+			"List the names of all tags.",
+			true,
+			map[string]any{},
+			[]string{},
+			func(ctx context.Context, args struct{}) (any, error) {
+				names := []string{}
+				for tag, err := range linkding.AllTags(ctx, c, linkding.ListTagsParams{Limit: 1000}) {
+					if err != nil {
+						return nil, err
+					}
+					names = append(names, tag.Name)
+				}
+				slices.Sort(names)
+
+				return names, nil
+			}),
+	}
+	if readOnly {
+		return tools
+	}
+
+	return append(tools,
+		newTool("add_bookmark",
+			"Bookmark a URL. The title and description are filled in from the page if not given. "+
+				"Adding a URL that is already bookmarked updates the bookmark.",
+			false,
+			map[string]any{
+				"url":         map[string]any{"type": "string", "description": "The URL to bookmark."},
+				"title":       map[string]any{"type": "string", "description": "The title."},
+				"description": map[string]any{"type": "string", "description": "The description."},
+				"notes":       map[string]any{"type": "string", "description": "The notes, in Markdown."},
+				"tags":        tags("The tags."),
+				"unread":      map[string]any{"type": "boolean", "description": "Mark the bookmark as unread, to read later."},
+			},
+			[]string{"url"},
+			func(ctx context.Context, args struct {
+				URL         string   `json:"url"`
+				Title       string   `json:"title"`
+				Description string   `json:"description"`
+				Notes       string   `json:"notes"`
+				Tags        []string `json:"tags"`
+				Unread      bool     `json:"unread"`
+			}) (any, error) {
+				b, err := c.Bookmarks.Create(ctx, linkding.CreateBookmarkRequest{
+					URL:         args.URL,
+					Title:       args.Title,
+					Description: args.Description,
+					Notes:       args.Notes,
+					TagNames:    args.Tags,
+					Unread:      args.Unread,
+				})
+				if err != nil {
+					return nil, err
+				}
+				return newBookmark(*b, true), nil
+			}),
+
+		newTool("update_notes",
+			"Replace the notes of a bookmark, or append to them.",
+			false,
+			map[string]any{
+				"id":     id,
+				"notes":  map[string]any{"type": "string", "description": "The notes, in Markdown."},
+				"append": map[string]any{"type": "boolean", "description": "Append to the notes instead of replacing them."},
+			},
+			[]string{"id", "notes"},
+			func(ctx context.Context, args struct {
+				ID     int    `json:"id"`
+				Notes  string `json:"notes"`
+				Append bool   `json:"append"`
+			}) (any, error) {
+				notes := args.Notes
+				if args.Append {
+					b, err := c.Bookmarks.Get(ctx, args.ID)
+					if err != nil {
+						return nil, err
+					}
+					if b.Notes != "" {
+						notes = b.Notes + "\n\n" + notes
+					}
+				}
+
+				b, err := c.Bookmarks.Patch(ctx, args.ID, linkding.PatchBookmarkRequest{Notes: &notes})
+				if err != nil {
+					return nil, err
+				}
+				return newBookmark(*b, true), nil
+			}),
+
+		newTool("tag_bookmark",
+			"Add tags to or remove tags from a bookmark.",
+			false,
+			map[string]any{
+				"id":     id,
+				"add":    tags("The tags to add."),
+				"remove": tags("The tags to remove."),
+			},
+			[]string{"id"},
+			func(ctx context.Context, args struct {
+				ID     int      `json:"id"`
+				Add    []string `json:"add"`
+				Remove []string `json:"remove"`
+			}) (any, error) {
+				b, err := c.Bookmarks.Get(ctx, args.ID)
+				if err != nil {
+					return nil, err
+				}
+
+				names := linkding.ChangeTags(b.TagNames, args.Add, args.Remove)
+				b, err = c.Bookmarks.Patch(ctx, args.ID, linkding.PatchBookmarkRequest{TagNames: &names})
+				if err != nil {
+					return nil, err
+				}
+				return newBookmark(*b, false), nil
+			}),
+
+		newTool("mark_read",
+			"Mark a bookmark as read or unread.",
+			false,
+			map[string]any{
+				"id":     id,
+				"unread": map[string]any{"type": "boolean", "description": "Mark the bookmark as unread instead."},
+			},
+			[]string{"id"},
+			func(ctx context.Context, args struct {
+				ID     int  `json:"id"`
+				Unread bool `json:"unread"`
+			}) (any, error) {
+				b, err := c.Bookmarks.Patch(ctx, args.ID, linkding.PatchBookmarkRequest{Unread: &args.Unread})
+				if err != nil {
+					return nil, err
+				}
+				return newBookmark(*b, false), nil
+			}),
+
+		newTool("archive_bookmark",
+			"Archive a bookmark, or move it out of the archive.",
+			false,
+			map[string]any{
+				"id":   id,
+				"undo": map[string]any{"type": "boolean", "description": "Unarchive the bookmark instead."},
+			},
+			[]string{"id"},
+			func(ctx context.Context, args struct {
+				ID   int  `json:"id"`
+				Undo bool `json:"undo"`
+			}) (any, error) {
+				if args.Undo {
+					return fmt.Sprintf("Unarchived bookmark %d.", args.ID), c.Bookmarks.Unarchive(ctx, args.ID)
+				}
+				return fmt.Sprintf("Archived bookmark %d.", args.ID), c.Bookmarks.Archive(ctx, args.ID)
+			}),
+	)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/larcher/go-linkding"
+)
+
+func TestAddBookmarkWithoutTags(t *testing.T) {
+	var tagNames json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			TagNames json.RawMessage `json:"tag_names"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		tagNames = body.TagNames
+		w.Write([]byte(`{"id":1,"url":"https://go.dev","tag_names":[]}`))
+	}))
+	defer server.Close()
+
+	tools := newTools(linkding.NewClient(server.URL, "token"), false)
+	i := slices.IndexFunc(tools, func(t tool) bool { return t.Name == "add_bookmark" })
+	if i < 0 {
+		t.Fatal("add_bookmark not found")
+	}
+
+	if _, err := tools[i].run(context.Background(), json.RawMessage(`{"url":"https://go.dev"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if string(tagNames) != "[]" {
+		t.Errorf("tag_names = %s, want []", tagNames)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/offline"
+)
+
+// maxMessageSize is the size of the largest message accepted from the
+// browser. Browsers allow up to 4 GiB, but bookmarks are small.
+const maxMessageSize = 1 << 20
+
+// message is a message sent by the extension.
+type message struct {
+	Action      string   `json:"action"`
+	URL         string   `json:"url"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Notes       string   `json:"notes"`
+	Tags        []string `json:"tags"`
+	Unread      bool     `json:"unread"`
+	Shared      bool     `json:"shared"`
+}
+
+// reply is the answer to a message.
+type reply struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	// Whether the bookmark was queued to be saved once the server can be
+	// reached.
+	Queued   bool               `json:"queued,omitempty"`
+	Bookmark *linkding.Bookmark `json:"bookmark,omitempty"`
+	// For check, whether the URL is bookmarked, and the title and tags
+	// Linkding suggests for it otherwise.
+	Bookmarked *bool              `json:"bookmarked,omitempty"`
+	Metadata   *linkding.Metadata `json:"metadata,omitempty"`
+	AutoTags   []string           `json:"auto_tags,omitempty"`
+	// The number of changes waiting to be saved.
+	Pending int `json:"pending"`
+	// For replay, the number of changes saved.
+	Applied int `json:"applied,omitempty"`
+}
+
+// host answers the messages of a browser extension.
+type host struct {
+	client *offline.Client
+}
+
+// serve answers messages read from r until r is closed, which is how the
+// browser stops the host.
+func (h *host) serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	// Save what was queued by earlier runs, if the server is back.
+	h.replay(ctx)
+
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.NativeEndian, &size); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if size > maxMessageSize {
+			return fmt.Errorf("message of %d bytes exceeds the limit of %d", size, maxMessageSize)
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return err
+		}
+
+		var msg message
+		var resp reply
+		if err := json.Unmarshal(data, &msg); err != nil {
+			resp = reply{Error: "invalid message: " + err.Error()}
+		} else {
+			resp = h.handle(ctx, msg)
+		}
+		if ops, err := h.client.Pending(); err == nil {
+			resp.Pending = len(ops)
+		}
+
+		if err := writeMessage(w, resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (h *host) handle(ctx context.Context, msg message) reply {
+	switch msg.Action {
+	case "save":
+		if msg.URL == "" {
+			return reply{Error: "missing url"}
+		}
+		// Queued changes are replayed first, so the save is not queued
+		// behind them needlessly.
+		h.replay(ctx)
+
+		bookmark, err := h.client.CreateBookmark(linkding.CreateBookmarkRequest{
+			URL:         msg.URL,
+			Title:       msg.Title,
+			Description: msg.Description,
+			Notes:       msg.Notes,
+			TagNames:    msg.Tags,
+			Unread:      msg.Unread,
+			Shared:      msg.Shared,
+		})
+		if errors.Is(err, offline.ErrQueued) {
+			return reply{OK: true, Queued: true}
+		}
+		if err != nil {
+			return reply{Error: err.Error()}
+		}
+		return reply{OK: true, Bookmark: bookmark}
+	case "check":
+		if msg.URL == "" {
+			return reply{Error: "missing url"}
+		}
+
+		check, err := h.client.CheckBookmark(msg.URL)
+		if err != nil {
+			return reply{Error: err.Error()}
+		}
+		bookmarked := check.Bookmark != nil
+		return reply{
+			OK:         true,
+			Bookmark:   check.Bookmark,
+			Bookmarked: &bookmarked,
+			Metadata:   &check.Metadata,
+			AutoTags:   check.AutoTags,
+		}
+	case "status":
+		return reply{OK: true}
+	case "replay":
+		report, err := h.client.Replay(ctx, offline.ReplayOptions{})
+		if err != nil {
+			return reply{Error: err.Error()}
+		}
+		return reply{OK: true, Applied: report.Applied}
+	default:
+		return reply{Error: fmt.Sprintf("unknown action %q", msg.Action)}
+	}
+}
+
+// replay saves the queued changes, logging what could not be saved.
+func (h *host) replay(ctx context.Context) {
+	ops, err := h.client.Pending()
+	if err != nil || len(ops) == 0 {
+		return
+	}
+
+	report, err := h.client.Replay(ctx, offline.ReplayOptions{})
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	for _, conflict := range report.Conflicts {
+		log.Printf("skipped queued change of %s, changed on the server meanwhile", conflict.Current.URL)
+	}
+	for seq, err := range report.Failed {
+		log.Printf("queued change %d: %v", seq, err)
+	}
+}
+
+// writeMessage writes v to w in the framing of native messaging: the length
+// of the JSON in native byte order, followed by the JSON.
+func writeMessage(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := binary.Write(w, binary.NativeEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+
+	return err
+}
@@ -0,0 +1,123 @@
+// Command linkding-native-host is a native messaging host, letting browser
+// extensions for Chrome, Chromium and Firefox save and check bookmarks through
+// the client.
+//
+// Bookmarks saved while the server cannot be reached are queued in a file and
+// saved once it is back, see the offline package, so saving from the browser
+// works offline.
+//
+// The browser starts the host when the extension connects to it, as described
+// by a manifest installed for the browser. The manifest is printed with:
+//
+//	linkding-native-host -manifest chrome -extension abcdefghijklmnopabcdefghijklmnop
+//	linkding-native-host -manifest firefox -extension linkding@example.org
+//
+// Browsers pass no flags of their own choosing to the host, so the server is
+// selected with the default profile of the configuration file, see the config
+// package, or with the LINKDING_PROFILE, LINKDING_URL and LINKDING_TOKEN
+// environment variables.
+//
+// Messages are JSON objects with an "action" and its fields:
+//
+//	{"action": "save", "url": "https://go.dev", "title": "Go", "tags": ["go"]}
+//	{"action": "check", "url": "https://go.dev"}
+//	{"action": "status"}
+//	{"action": "replay"}
+//
+// Each message is answered with a JSON object, with "ok" set to false and an
+// "error" if the action failed. A bookmark saved offline is answered with
+// "queued" set to true.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/larcher/go-linkding/config"
+	"github.com/larcher/go-linkding/offline"
+)
+
+// Name is the name of the host, which extensions connect to.
+const Name = "org.linkding.native_host"
+
+func main() {
+	manifest := flag.String("manifest", "", "print the manifest for a browser, chrome or firefox, and exit")
+	extension := flag.String("extension", "", "the ID of the extension allowed to connect, for -manifest")
+	queuePath := flag.String("queue", "", "the file queueing bookmarks saved offline (default in the user cache directory)")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("linkding-native-host: ")
+
+	if *manifest != "" {
+		if err := printManifest(*manifest, *extension); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		log.Fatal(err)
+	}
+	profile, err := cfg.Profile("")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *queuePath == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			log.Fatal(err)
+		}
+		*queuePath = filepath.Join(dir, "linkding", "native-host-queue.json")
+	}
+	if err := os.MkdirAll(filepath.Dir(*queuePath), 0o700); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	h := &host{client: offline.NewClient(profile.Client(), offline.NewFileQueue(*queuePath))}
+	if err := h.serve(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		log.Fatal(err)
+	}
+}
+
+// printManifest prints the manifest registering the host with a browser.
+func printManifest(browser, extension string) error {
+	if extension == "" {
+		return fmt.Errorf("-manifest needs the ID of the extension, see -extension")
+	}
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	manifest := map[string]any{
+		"name":        Name,
+		"description": "Save bookmarks to Linkding",
+		"path":        path,
+		"type":        "stdio",
+	}
+	switch browser {
+	case "chrome":
+		manifest["allowed_origins"] = []string{"chrome-extension://" + extension + "/"}
+	case "firefox":
+		manifest["allowed_extensions"] = []string{extension}
+	default:
+		return fmt.Errorf("unknown browser %q, want chrome or firefox", browser)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(manifest)
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/launcher"
+	"github.com/spf13/cobra"
+)
+
+func newAddCommand(a *app) *cobra.Command {
+	var payload linkding.CreateBookmarkRequest
+
+	cmd := &cobra.Command{
+		Use:   "add URL",
+		Short: "Add a bookmark",
+		Long: "Add a bookmark. The title and description are filled in by the server if\n" +
+			"not given. Adding a URL that is already bookmarked updates the bookmark.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			payload.URL = args[0]
+
+			bookmark, err := a.client.Bookmarks.Create(cmd.Context(), payload)
+			if err != nil {
+				return err
+			}
+
+			if a.json {
+				return printJSON(cmd, bookmark)
+			}
+			a.printf(cmd, "Added bookmark %d: %s\n", bookmark.ID, bookmark.EffectiveTitle())
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&payload.Title, "title", "", "the title")
+	flags.StringVar(&payload.Description, "description", "", "the description")
+	flags.StringVar(&payload.Notes, "notes", "", "the notes, in Markdown")
+	flags.StringSliceVarP(&payload.TagNames, "tag", "t", nil, "a tag, can be repeated or comma-separated")
+	flags.BoolVar(&payload.Unread, "unread", false, "mark as unread")
+	flags.BoolVar(&payload.Shared, "shared", false, "share the bookmark")
+	flags.BoolVar(&payload.IsArchived, "archived", false, "add to the archive")
+
+	return cmd
+}
+
+// listFlags are the flags of the commands listing bookmarks.
+type listFlags struct {
+	archived bool
+	unread   bool
+	tags     []string
+	sort     string
+	limit    int
+	format   string
+	lines    launcher.LinesOptions
+}
+
+func (f *listFlags) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.BoolVar(&f.archived, "archived", false, "list archived bookmarks")
+	flags.BoolVar(&f.unread, "unread", false, "only list unread bookmarks")
+	flags.StringSliceVarP(&f.tags, "tag", "t", nil, "only list bookmarks with the tag, can be repeated")
+	flags.StringVar(&f.sort, "sort", "", "the order: added_asc, added_desc, title_asc or title_desc")
+	flags.IntVarP(&f.limit, "limit", "n", 0, "list at most this many bookmarks (default all)")
+	flags.StringVar(&f.format, "format", "table", "the output: table, alfred for Alfred and Raycast script filters, or lines for rofi and dmenu")
+	registerLinesFlags(cmd, &f.lines)
+}
+
+// registerLinesFlags registers the flags of the lines format.
+func registerLinesFlags(cmd *cobra.Command, opts *launcher.LinesOptions) {
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Delimiter, "delimiter", launcher.DefaultDelimiter, "the delimiter between the fields of the lines format")
+	flags.BoolVarP(&opts.NullSeparated, "null", "0", false, "end lines of the lines format with a NUL byte")
+}
+
+// list prints the bookmarks matching the query and flags.
+func (f *listFlags) list(a *app, cmd *cobra.Command, query string) error {
+	terms := []string{}
+	if query != "" {
+		terms = append(terms, query)
+	}
+	if tags := linkding.Search().Tag(f.tags...); !tags.IsZero() {
+		terms = append(terms, tags.String())
+	}
+	params := linkding.ListBookmarksParams{
+		Query:  strings.Join(terms, " "),
+		Unread: f.unread,
+		Sort:   f.sort,
+	}
+
+	all := linkding.AllBookmarks
+	if f.archived {
+		all = linkding.AllArchivedBookmarks
+	}
+
+	bookmarks := []linkding.Bookmark{}
+	for bookmark, err := range all(cmd.Context(), a.client, params) {
+		if err != nil {
+			return err
+		}
+		bookmarks = append(bookmarks, bookmark)
+		if f.limit > 0 && len(bookmarks) >= f.limit {
+			break
+		}
+	}
+
+	if a.json {
+		return printJSON(cmd, bookmarks)
+	}
+
+	switch f.format {
+	case "table":
+	case "alfred":
+		_, err := launcher.WriteAlfred(cmd.OutOrStdout(), seq(bookmarks), launcher.AlfredOptions{Empty: "No bookmarks found"})
+		return err
+	case "lines":
+		_, err := launcher.WriteLines(cmd.OutOrStdout(), seq(bookmarks), f.lines)
+		return err
+	default:
+		return fmt.Errorf("unknown output format %q", f.format)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTITLE\tURL\tTAGS")
+	for _, bookmark := range bookmarks {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", bookmark.ID, truncate(bookmark.EffectiveTitle(), 60), bookmark.URL, strings.Join(bookmark.TagNames, " "))
+	}
+
+	return w.Flush()
+}
+
+func newListCommand(a *app) *cobra.Command {
+	f := &listFlags{}
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List bookmarks",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return f.list(a, cmd, "")
+		},
+	}
+	f.register(cmd)
+
+	return cmd
+}
+
+func newSearchCommand(a *app) *cobra.Command {
+	f := &listFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "search QUERY...",
+		Short: "Search bookmarks",
+		Long: "Search bookmarks with Linkding's search syntax: words match the title,\n" +
+			"description, notes and URL, #tag matches a tag and !unread and !untagged\n" +
+			"match unread and untagged bookmarks.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return f.list(a, cmd, strings.Join(args, " "))
+		},
+	}
+	f.register(cmd)
+
+	return cmd
+}
+
+func newOpenCommand(a *app) *cobra.Command {
+	var markRead, inLinkding, edit bool
+
+	cmd := &cobra.Command{
+		Use:   "open ID",
+		Short: "Open a bookmark in the browser",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+
+			bookmark, err := a.client.Bookmarks.Get(cmd.Context(), ids[0])
+			if err != nil {
+				return err
+			}
+			target := bookmark.URL
+			switch {
+			case edit:
+				target = a.client.EditURL(bookmark.ID)
+			case inLinkding:
+				target = a.client.WebURL(*bookmark)
+			}
+			if err := openBrowser(target); err != nil {
+				return err
+			}
+
+			if markRead && bookmark.Unread {
+				_, err = a.client.MarkRead(cmd.Context(), bookmark.ID)
+			}
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&markRead, "mark-read", false, "mark the bookmark as read")
+	cmd.Flags().BoolVar(&inLinkding, "linkding", false, "open the bookmark in Linkding instead")
+	cmd.Flags().BoolVar(&edit, "edit", false, "open the form editing the bookmark in Linkding instead")
+
+	return cmd
+}
+
+func newResolveCommand(a *app) *cobra.Command {
+	var opts launcher.LinesOptions
+	var open bool
+
+	cmd := &cobra.Command{
+		Use:   "resolve [SELECTION]",
+		Short: "Print the URL of a line picked from the lines format",
+		Long: "Print the URL of a line of \"list --format lines\" picked in rofi, dmenu or\n" +
+			"fzf, read from the argument or standard input:\n\n" +
+			"  linkding list --format lines | rofi -dmenu | linkding resolve --open",
+		Args: cobra.MaximumNArgs(1),
+		// Resolving needs no server.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var selection string
+			if len(args) > 0 {
+				selection = args[0]
+			} else {
+				data, err := io.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+				selection = string(data)
+			}
+			if strings.TrimSpace(selection) == "" {
+				// Nothing picked, e.g. the picker was cancelled.
+				return nil
+			}
+
+			url, err := launcher.ResolveSelection(selection, opts)
+			if err != nil {
+				return err
+			}
+			if open {
+				return openBrowser(url)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), url)
+			return nil
+		},
+	}
+	registerLinesFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&open, "open", false, "open the URL in the browser instead of printing it")
+
+	return cmd
+}
+
+func newTagCommand(a *app) *cobra.Command {
+	var remove []string
+
+	cmd := &cobra.Command{
+		Use:   "tag ID [TAG...]",
+		Short: "Add tags to or remove tags from a bookmark",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args[:1])
+			if err != nil {
+				return err
+			}
+
+			bookmark, err := a.client.Bookmarks.Get(cmd.Context(), ids[0])
+			if err != nil {
+				return err
+			}
+
+			tags := linkding.ChangeTags(bookmark.TagNames, args[1:], remove)
+			bookmark, err = a.client.Bookmarks.Patch(cmd.Context(), bookmark.ID, linkding.PatchBookmarkRequest{TagNames: &tags})
+			if err != nil {
+				return err
+			}
+
+			if a.json {
+				return printJSON(cmd, bookmark)
+			}
+			a.printf(cmd, "Tags of bookmark %d: %s\n", bookmark.ID, strings.Join(bookmark.TagNames, " "))
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVarP(&remove, "remove", "r", nil, "a tag to remove, can be repeated or comma-separated")
+
+	return cmd
+}
+
+func newArchiveCommand(a *app) *cobra.Command {
+	var undo bool
+
+	cmd := &cobra.Command{
+		Use:   "archive ID...",
+		Short: "Archive bookmarks",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				if undo {
+					err = a.client.Bookmarks.Unarchive(cmd.Context(), id)
+				} else {
+					err = a.client.Bookmarks.Archive(cmd.Context(), id)
+				}
+				if err != nil {
+					return fmt.Errorf("bookmark %d: %w", id, err)
+				}
+			}
+
+			if undo {
+				a.printf(cmd, "Unarchived %d bookmarks\n", len(ids))
+			} else {
+				a.printf(cmd, "Archived %d bookmarks\n", len(ids))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&undo, "undo", false, "unarchive the bookmarks instead")
+
+	return cmd
+}
+
+func newDeleteCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete ID...",
+		Aliases: []string{"rm"},
+		Short:   "Delete bookmarks",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				if err := a.client.Bookmarks.Delete(cmd.Context(), id); err != nil {
+					return fmt.Errorf("bookmark %d: %w", id, err)
+				}
+			}
+
+			a.printf(cmd, "Deleted %d bookmarks\n", len(ids))
+			return nil
+		},
+	}
+}
+
+func parseIDs(args []string) ([]int, error) {
+	ids := make([]int, len(args))
+	for i, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid bookmark ID %q", arg)
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
+
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return errors.New("cannot open a browser: " + err.Error())
+	}
+
+	return cmd.Process.Release()
+}
+
+// seq yields the bookmarks for the writers of bookmarks.
+func seq(bookmarks []linkding.Bookmark) iter.Seq2[linkding.Bookmark, error] {
+	return func(yield func(linkding.Bookmark, error) bool) {
+		for _, bookmark := range bookmarks {
+			if !yield(bookmark, nil) {
+				return
+			}
+		}
+	}
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,159 @@
+// Command linkding manages the bookmarks of a Linkding server from the command
+// line.
+//
+// The server is selected with a profile of the configuration file, see the
+// config package, or with the LINKDING_URL and LINKDING_TOKEN environment
+// variables. Commands listing bookmarks print a table, or JSON with --json for
+// use in scripts.
+//
+// Usage:
+//
+//	linkding add https://go.dev --tag go --tag programming
+//	linkding list --tag go
+//	linkding search "error handling"
+//	linkding open 42
+//	linkding list --format lines | rofi -dmenu | linkding resolve --open
+//	linkding tag 42 reading --remove inbox
+//	linkding archive 42
+//	linkding delete 42
+//	linkding import bookmarks.html
+//	linkding export --format csv -o bookmarks.csv
+//	linkding backup -o linkding.zip
+//	linkding proxy --listen localhost:8080 --ttl 1m
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/config"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := newRootCommand().ExecuteContext(ctx); err != nil {
+		os.Exit(1)
+	}
+}
+
+// app holds the state shared by the commands.
+type app struct {
+	configPath string
+	profile    string
+	json       bool
+
+	server config.Profile
+	client *linkding.Client
+}
+
+func newRootCommand() *cobra.Command {
+	a := &app{}
+
+	root := &cobra.Command{
+		Use:          "linkding",
+		Short:        "Manage the bookmarks of a Linkding server",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if offline(cmd) {
+				return nil
+			}
+			return a.connect()
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&a.configPath, "config", "", "the configuration file (default the user configuration directory)")
+	flags.StringVarP(&a.profile, "profile", "p", "", "the profile of the server to use")
+	flags.BoolVar(&a.json, "json", false, "print JSON instead of tables")
+
+	root.AddCommand(
+		newAddCommand(a),
+		newListCommand(a),
+		newSearchCommand(a),
+		newOpenCommand(a),
+		newResolveCommand(a),
+		newTagCommand(a),
+		newArchiveCommand(a),
+		newDeleteCommand(a),
+		newImportCommand(a),
+		newExportCommand(a),
+		newBackupCommand(a),
+		newVerifyCommand(a),
+		newProxyCommand(a),
+	)
+
+	return root
+}
+
+// retries is the number of times requests failing because the server is
+// unavailable or rate limited are sent again.
+const retries = 3
+
+// offline reports whether cmd works without a server, so it does not need a
+// configured profile: help, shell completion and verifying a backup.
+func offline(cmd *cobra.Command) bool {
+	for ; cmd != nil; cmd = cmd.Parent() {
+		switch cmd.Name() {
+		case "help", "completion", "verify", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return true
+		}
+	}
+
+	return false
+}
+
+// connect creates the client of the selected profile.
+func (a *app) connect() error {
+	var cfg *config.Config
+	var err error
+	if a.configPath != "" {
+		cfg, err = config.Load(a.configPath)
+	} else {
+		cfg, err = config.LoadDefault()
+	}
+	if err != nil {
+		return err
+	}
+
+	profile, err := cfg.Profile(a.profile)
+	if err != nil {
+		return err
+	}
+	a.server = profile
+	a.client = profile.Client(
+		linkding.WithRetries(retries),
+		linkding.WithRetryNotify(func(_ int, delay time.Duration, reason error) {
+			if errors.Is(reason, linkding.ErrTooManyRequests) {
+				fmt.Fprintf(os.Stderr, "rate limited, retrying in %s\n", delay.Round(time.Second))
+			} else {
+				fmt.Fprintf(os.Stderr, "%v, retrying in %s\n", reason, delay.Round(time.Second))
+			}
+		}),
+	)
+
+	return nil
+}
+
+// printJSON prints v as indented JSON.
+func printJSON(cmd *cobra.Command, v any) error {
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// printf prints a message for humans, which is left out with --json.
+func (a *app) printf(cmd *cobra.Command, format string, args ...any) {
+	if !a.json {
+		fmt.Fprintf(cmd.OutOrStdout(), format, args...)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/larcher/go-linkding/proxy"
+	"github.com/spf13/cobra"
+)
+
+func newProxyCommand(a *app) *cobra.Command {
+	var listen string
+	var useToken bool
+	var opts proxy.Options
+
+	cmd := &cobra.Command{
+		Use:   "proxy",
+		Short: "Serve the API of the server through a caching proxy",
+		Long: "Serve the API of the server through a caching proxy, for dashboards polling\n" +
+			"it often. Responses of the list, check, tag and user endpoints are cached;\n" +
+			"any write through the proxy invalidates the cached responses of its token.\n" +
+			"Clients send their own tokens, unless --use-token is given.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if useToken {
+				opts.Token = a.server.Token
+			}
+			opts.Header = http.Header{}
+			for key, value := range a.server.Headers {
+				opts.Header.Set(key, value)
+			}
+			handler, err := proxy.New(a.server.URL, opts)
+			if err != nil {
+				return err
+			}
+
+			server := &http.Server{Addr: listen, Handler: handler}
+			go func() {
+				<-cmd.Context().Done()
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				server.Shutdown(ctx)
+			}()
+
+			a.printf(cmd, "Proxying %s on %s\n", a.server.URL, listen)
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&listen, "listen", "localhost:8080", "the address to listen on")
+	flags.DurationVar(&opts.TTL, "ttl", proxy.DefaultTTL, "how long responses are cached")
+	flags.IntVar(&opts.MaxEntries, "max-entries", proxy.DefaultMaxEntries, "the number of cached responses")
+	flags.BoolVar(&useToken, "use-token", false, "send the token of the profile for requests without one; only listen on a trusted network")
+
+	return cmd
+}
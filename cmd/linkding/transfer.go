@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/backup"
+	"github.com/larcher/go-linkding/export"
+	"github.com/larcher/go-linkding/importer"
+	"github.com/spf13/cobra"
+)
+
+func newImportCommand(a *app) *cobra.Command {
+	var format, duplicates string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Import bookmarks from a file",
+		Long: "Import bookmarks from a file exported by a browser or another bookmark\n" +
+			"manager. The format is detected from the file extension if not given:\n" +
+			"netscape (.html), csv (.csv), pocket, raindrop (CSV) or shiori (JSON).",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			if format == "" {
+				format = map[string]string{".html": "netscape", ".htm": "netscape", ".csv": "csv", ".json": "shiori"}[strings.ToLower(filepath.Ext(args[0]))]
+			}
+			records, err := readRecords(file, format)
+			if err != nil {
+				return err
+			}
+
+			opts := importer.Options{DryRun: dryRun}
+			switch duplicates {
+			case "skip":
+				opts.Duplicates = importer.SkipDuplicates
+			case "update":
+				opts.Duplicates = importer.UpdateDuplicates
+			case "merge":
+				opts.Duplicates = importer.MergeDuplicates
+			default:
+				return fmt.Errorf("unknown duplicate policy %q", duplicates)
+			}
+
+			report, err := importer.Import(cmd.Context(), a.client, records, opts)
+			if err != nil {
+				return err
+			}
+
+			if a.json {
+				if err := printJSON(cmd, newImportReportJSON(report)); err != nil {
+					return err
+				}
+			} else {
+				for _, item := range report.Items {
+					if item.Err != nil {
+						fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", item.Record.URL, item.Err)
+					}
+				}
+				a.printf(cmd, "Created %d, updated %d, skipped %d, failed %d\n", report.Created, report.Updated, report.Skipped, report.Failed)
+			}
+			if report.Failed > 0 {
+				return fmt.Errorf("%d records failed", report.Failed)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&format, "format", "f", "", "the format: netscape, csv, pocket, raindrop or shiori")
+	flags.StringVar(&duplicates, "duplicates", "skip", "what to do with URLs already bookmarked: skip, update or merge")
+	flags.BoolVar(&dryRun, "dry-run", false, "report what would be done without changing anything")
+
+	return cmd
+}
+
+// importReportJSON is an import report as printed with --json, with the
+// errors of the items as strings since errors marshal as empty objects.
+type importReportJSON struct {
+	*importer.Report
+	Items []importItemJSON
+}
+
+type importItemJSON struct {
+	importer.ItemResult
+	Err string `json:",omitempty"`
+}
+
+func newImportReportJSON(report *importer.Report) importReportJSON {
+	items := make([]importItemJSON, len(report.Items))
+	for i, item := range report.Items {
+		items[i] = importItemJSON{ItemResult: item}
+		if item.Err != nil {
+			items[i].Err = item.Err.Error()
+		}
+	}
+
+	return importReportJSON{Report: report, Items: items}
+}
+
+func readRecords(r io.Reader, format string) ([]importer.Record, error) {
+	switch format {
+	case "netscape":
+		return importer.ReadNetscapeHTML(r, importer.NetscapeOptions{})
+	case "csv":
+		return importer.ReadCSV(r, importer.CSVOptions{})
+	case "pocket":
+		return importer.ReadPocket(r, importer.PocketOptions{})
+	case "raindrop":
+		return importer.ReadRaindropCSV(r, importer.RaindropOptions{})
+	case "shiori":
+		return importer.ReadShiori(r, importer.ShioriOptions{})
+	case "":
+		return nil, errors.New("cannot detect the format of the file, use --format")
+	default:
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+}
+
+func newExportCommand(a *app) *cobra.Command {
+	var format, output string
+	var archived bool
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export bookmarks to a file",
+		Long: "Export bookmarks in the Netscape format understood by browsers, as CSV or\n" +
+			"in Wallabag's import format.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all := linkding.AllBookmarks
+			if archived {
+				all = linkding.AllBookmarksIncludingArchived
+			}
+			bookmarks := all(cmd.Context(), a.client, linkding.ListBookmarksParams{})
+
+			var write func(io.Writer) (int, error)
+			switch format {
+			case "netscape":
+				write = func(w io.Writer) (int, error) {
+					return export.WriteNetscapeHTML(w, bookmarks, export.NetscapeOptions{})
+				}
+			case "csv":
+				write = func(w io.Writer) (int, error) {
+					return export.WriteCSV(w, bookmarks, export.CSVOptions{})
+				}
+			case "wallabag":
+				write = func(w io.Writer) (int, error) {
+					return export.WriteWallabag(w, bookmarks, export.WallabagOptions{})
+				}
+			default:
+				return fmt.Errorf("unknown export format %q", format)
+			}
+
+			count, err := writeOutput(cmd, output, write)
+			if err != nil {
+				return err
+			}
+
+			if output != "" && output != "-" {
+				a.printf(cmd, "Exported %d bookmarks to %s\n", count, output)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&format, "format", "f", "netscape", "the format: netscape, csv or wallabag")
+	flags.StringVarP(&output, "output", "o", "", "the file written (default standard output)")
+	flags.BoolVar(&archived, "archived", false, "also export archived bookmarks")
+
+	return cmd
+}
+
+func newBackupCommand(a *app) *cobra.Command {
+	var output string
+	var opts backup.Options
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up bookmarks, tags and assets to a ZIP archive",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var manifest *backup.Manifest
+			_, err := writeOutput(cmd, output, func(w io.Writer) (int, error) {
+				var err error
+				manifest, err = backup.Backup(cmd.Context(), a.client, w, opts)
+				return 0, err
+			})
+			if err != nil {
+				return err
+			}
+
+			if a.json {
+				return printJSON(cmd, manifest)
+			}
+			a.printf(cmd, "Backed up %d bookmarks, %d tags and %d assets to %s\n", manifest.Bookmarks, manifest.Tags, manifest.Assets, output)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&output, "output", "o", "", "the archive written")
+	flags.BoolVar(&opts.SkipAssets, "skip-assets", false, "only record the metadata of assets")
+	cmd.MarkFlagRequired("output")
+
+	return cmd
+}
+
+func newVerifyCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify ARCHIVE",
+		Short: "Check the assets of a backup archive against their checksums",
+		Args:  cobra.ExactArgs(1),
+		// Verifying needs no server.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			info, err := file.Stat()
+			if err != nil {
+				return err
+			}
+			if err := backup.Verify(file, info.Size()); err != nil {
+				return err
+			}
+
+			a.printf(cmd, "%s is intact\n", args[0])
+			return nil
+		},
+	}
+}
+
+// writeOutput calls write with the named file, or standard output for an
+// empty name or "-". A file is removed again if write fails.
+func writeOutput(cmd *cobra.Command, name string, write func(io.Writer) (int, error)) (int, error) {
+	if name == "" || name == "-" {
+		return write(cmd.OutOrStdout())
+	}
+
+	file, err := os.Create(name)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(name)
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,84 @@
+package linkding
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// WithRequestCoalescing sends concurrent identical reads, such as several
+// parts of a UI listing the same bookmarks at once, as a single request whose
+// response is shared by all callers. Reads made with request options are sent
+// on their own. Requests are not coalesced by default.
+func WithRequestCoalescing() Option {
+	return func(c *Client) {
+		c.coalescer = &coalescer{calls: map[string]*call{}}
+	}
+}
+
+// coalescer shares the responses of concurrent requests with the same key.
+type coalescer struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// do returns the response body of the request in flight for key, or sends it
+// with send if there is none.
+func (g *coalescer) do(ctx context.Context, key string, send func(context.Context) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	for {
+		g.mu.Lock()
+		if c := g.calls[key]; c != nil {
+			g.mu.Unlock()
+			select {
+			case <-c.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+
+			// The request was ended by the context of the caller sending it,
+			// which says nothing about the request of this caller.
+			if (errors.Is(c.err, context.Canceled) || errors.Is(c.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+				continue
+			}
+			return c.result()
+		}
+		c := &call{done: make(chan struct{})}
+		g.calls[key] = c
+		g.mu.Unlock()
+
+		c.body, c.err = readAll(send(ctx))
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+
+		return c.result()
+	}
+}
+
+// result returns a reader of the shared body, so each caller reads all of it.
+func (c *call) result() (io.ReadCloser, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return io.NopCloser(bytes.NewReader(c.body)), nil
+}
+
+func readAll(body io.ReadCloser, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
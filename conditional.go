@@ -0,0 +1,133 @@
+package linkding
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultConditionalCacheEntries is the number of responses kept by the
+// transport of NewConditionalTransport.
+const DefaultConditionalCacheEntries = 1000
+
+// WithConditionalRequests revalidates the responses of reads instead of
+// downloading them again, see NewConditionalTransport. It cuts the bandwidth
+// of pollers checking for changes, as unchanged lists are answered with an
+// empty 304 Not Modified response.
+func WithConditionalRequests() Option {
+	return func(c *Client) {
+		c.http.Transport = NewConditionalTransport(c.http.Transport)
+	}
+}
+
+// NewConditionalTransport returns a transport sending requests with base, or
+// http.DefaultTransport if base is nil, that keeps the JSON responses of GET
+// requests carrying an ETag or Last-Modified header. Further requests for the
+// same URL are sent with If-None-Match and If-Modified-Since headers, and a
+// 304 Not Modified response is answered with the kept response. Responses are
+// kept per Authorization header, so the transport can be shared by clients of
+// different users.
+//
+// The last DefaultConditionalCacheEntries responses are kept, in memory.
+func NewConditionalTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &conditionalTransport{base: base, entries: map[string]*validatedResponse{}}
+}
+
+type conditionalTransport struct {
+	base http.RoundTripper
+
+	mu      sync.Mutex
+	entries map[string]*validatedResponse
+	// The keys of entries in the order they were added, to drop the oldest.
+	order []string
+}
+
+type validatedResponse struct {
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+func (t *conditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Range requests and requests that are already conditional are left to
+	// the caller.
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" ||
+		req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	key := req.Header.Get("Authorization") + " " + req.URL.String()
+	t.mu.Lock()
+	entry := t.entries[key]
+	t.mu.Unlock()
+
+	if entry != nil {
+		req = req.Clone(req.Context())
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry != nil && res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+
+		res.StatusCode = http.StatusOK
+		res.Status = "200 OK"
+		res.Header = entry.header.Clone()
+		res.ContentLength = int64(len(entry.body))
+		res.Body = io.NopCloser(bytes.NewReader(entry.body))
+		return res, nil
+	}
+
+	// Only API responses are kept, not the content of downloaded assets.
+	etag, lastModified := res.Header.Get("ETag"), res.Header.Get("Last-Modified")
+	if res.StatusCode != http.StatusOK || etag == "" && lastModified == "" ||
+		!strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.store(key, &validatedResponse{
+		etag:         etag,
+		lastModified: lastModified,
+		header:       res.Header.Clone(),
+		body:         body,
+	})
+
+	return res, nil
+}
+
+func (t *conditionalTransport) store(key string, entry *validatedResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.entries[key]; !ok {
+		if len(t.order) >= DefaultConditionalCacheEntries {
+			delete(t.entries, t.order[0])
+			t.order = t.order[1:]
+		}
+		t.order = append(t.order, key)
+	}
+	t.entries[key] = entry
+}
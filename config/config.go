@@ -0,0 +1,151 @@
+// Package config loads the connection settings of Linkding servers, so tools
+// built on the client share one configuration instead of each asking for a
+// URL and token.
+//
+// The configuration is a JSON file holding named profiles, e.g. one for a
+// personal and one for a work server:
+//
+//	{
+//	  "default": "home",
+//	  "profiles": {
+//	    "home": {"url": "https://links.example.org", "token": "..."},
+//	    "work": {"url": "https://links.example.com", "token": "..."}
+//	  }
+//	}
+//
+// Servers behind a gateway, e.g. Cloudflare Access, may need additional
+// headers, which a profile lists under "headers".
+//
+// The environment variables LINKDING_PROFILE, LINKDING_URL and LINKDING_TOKEN
+// select a profile and override its settings, so a server can also be used
+// without a configuration file.
+package config
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/larcher/go-linkding"
+)
+
+// The environment variables read by Config.Profile.
+const (
+	EnvProfile = "LINKDING_PROFILE"
+	EnvURL     = "LINKDING_URL"
+	EnvToken   = "LINKDING_TOKEN"
+)
+
+// DefaultProfile is the name of the profile used when none is selected.
+const DefaultProfile = "default"
+
+// Profile holds the settings of a server.
+type Profile struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+	// Headers sent with every request, for servers behind a gateway such as
+	// Cloudflare Access or an authenticating proxy.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Client returns a client for the server of the profile, sending its
+// headers.
+func (p Profile) Client(opts ...linkding.Option) *linkding.Client {
+	for key, value := range p.Headers {
+		opts = append(opts, linkding.WithHeader(key, value))
+	}
+
+	return linkding.NewClient(p.URL, p.Token, opts...)
+}
+
+// Config is the contents of a configuration file.
+type Config struct {
+	// The name of the profile used when none is selected. Defaults to
+	// DefaultProfile.
+	Default  string             `json:"default,omitempty"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// DefaultPath returns the path of the configuration file in the user's
+// configuration directory, e.g. ~/.config/linkding/config.json on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "linkding", "config.json"), nil
+}
+
+// Load reads the configuration file at path. A missing file is an empty
+// configuration, as the profile can come from the environment.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{Profiles: map[string]Profile{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("config: reading %s: %w", path, err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = map[string]Profile{}
+	}
+
+	return &config, nil
+}
+
+// LoadDefault reads the configuration file at DefaultPath.
+func LoadDefault() (*Config, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return Load(path)
+}
+
+// Save writes the configuration to path, creating its directory if needed.
+// The file is only readable by the user, as it holds tokens.
+func (c *Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// Profile returns the settings of a profile, with the URL and token
+// overridden by the environment variables if they are set. An empty name
+// selects the profile named by LINKDING_PROFILE, or else the default profile.
+// A profile that does not exist is only an error if the environment does not
+// provide both the URL and token.
+func (c *Config) Profile(name string) (Profile, error) {
+	name = cmp.Or(name, os.Getenv(EnvProfile), c.Default, DefaultProfile)
+
+	profile, ok := c.Profiles[name]
+	profile.URL = cmp.Or(os.Getenv(EnvURL), profile.URL)
+	profile.Token = cmp.Or(os.Getenv(EnvToken), profile.Token)
+
+	switch {
+	case profile.URL != "" && profile.Token != "":
+		return profile, nil
+	case !ok:
+		return Profile{}, fmt.Errorf("config: no profile %q; set %s and %s or add it to the configuration file", name, EnvURL, EnvToken)
+	default:
+		return Profile{}, fmt.Errorf("config: profile %q needs a URL and a token", name)
+	}
+}
@@ -0,0 +1,127 @@
+// Package convert turns the HTML snapshots of bookmarks into other formats,
+// e.g. PDF, so archives hold documents that open without a browser.
+//
+// Conversions are done by a Converter. A converter using wkhtmltopdf is
+// included when building with the wkhtmltopdf build tag.
+package convert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+)
+
+// ErrNotSnapshot is returned by Snapshot for assets that are not snapshots.
+var ErrNotSnapshot = errors.New("convert: asset is not a snapshot")
+
+// Converter converts HTML documents.
+type Converter interface {
+	// Convert reads an HTML document from src and writes the converted
+	// document to dst.
+	Convert(ctx context.Context, dst io.Writer, src io.Reader) error
+}
+
+// ConverterFunc adapts a function to a Converter.
+type ConverterFunc func(ctx context.Context, dst io.Writer, src io.Reader) error
+
+func (f ConverterFunc) Convert(ctx context.Context, dst io.Writer, src io.Reader) error {
+	return f(ctx, dst, src)
+}
+
+// Snapshot downloads a snapshot of a bookmark and writes it converted to dst.
+// It returns ErrNotSnapshot if the asset is not a snapshot.
+func Snapshot(ctx context.Context, c linkding.BookmarkClient, bookmarkID int, assetID int, conv Converter, dst io.Writer) error {
+	asset, err := c.GetBookmarkAsset(bookmarkID, assetID)
+	if err != nil {
+		return err
+	}
+	if asset.AssetType != linkding.AssetTypeSnapshot {
+		return ErrNotSnapshot
+	}
+
+	content, err := c.DownloadBookmarkAsset(bookmarkID, assetID)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	return conv.Convert(ctx, dst, content)
+}
+
+// Dir converts every HTML file below dir, e.g. the snapshots downloaded by
+// Client.ExportAssets, and stores each converted document next to its
+// snapshot, with the extension replaced by ext, e.g. ".pdf". It returns the
+// paths of the documents written.
+//
+// Snapshots that were converted already are skipped, so converting the same
+// dir again only converts new snapshots. Snapshots that fail to convert do not
+// stop the others, and their errors are returned joined.
+func Dir(ctx context.Context, dir string, conv Converter, ext string) ([]string, error) {
+	written := []string{}
+	var errs []error
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		lower := strings.ToLower(path)
+		if entry.IsDir() || !strings.HasSuffix(lower, ".html") && !strings.HasSuffix(lower, ".htm") {
+			return nil
+		}
+
+		target := strings.TrimSuffix(path, filepath.Ext(path)) + ext
+		if _, err := os.Stat(target); err == nil {
+			return nil
+		}
+
+		if err := convertFile(ctx, conv, target, path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			return nil
+		}
+		written = append(written, target)
+
+		return nil
+	})
+	if err != nil {
+		return written, err
+	}
+
+	return written, errors.Join(errs...)
+}
+
+// convertFile converts the file at source into target, removing target again
+// if the conversion fails.
+func convertFile(ctx context.Context, conv Converter, target, source string) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+
+	err = conv.Convert(ctx, dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+
+	return nil
+}
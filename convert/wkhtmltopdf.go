@@ -0,0 +1,44 @@
+//go:build wkhtmltopdf
+
+package convert
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// WKHTMLToPDF converts snapshots to PDF using wkhtmltopdf, which must be
+// installed. Snapshots are single HTML files with their resources inlined, so
+// the conversion needs no network access.
+type WKHTMLToPDF struct {
+	// The path of the wkhtmltopdf executable. Defaults to "wkhtmltopdf",
+	// looked up in the PATH.
+	Path string
+	// Additional arguments, e.g. "--page-size", "A4".
+	Args []string
+}
+
+func (w WKHTMLToPDF) Convert(ctx context.Context, dst io.Writer, src io.Reader) error {
+	args := append([]string{"--quiet"}, w.Args...)
+	args = append(args, "-", "-")
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cmp.Or(w.Path, "wkhtmltopdf"), args...)
+	cmd.Stdin = src
+	cmd.Stdout = dst
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("convert: wkhtmltopdf: %w: %s", err, message)
+		}
+		return fmt.Errorf("convert: wkhtmltopdf: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+// Package cursor persists the position of change feeds, so that "everything
+// since the last run" survives process restarts.
+//
+// A cursor holds a single point in time, typically the latest modification
+// date of the server seen by a watcher or an incremental export. Cursors are
+// stored in a file each, or by name in a directory or a bbolt database.
+package cursor
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Cursor is a persisted point in time.
+type Cursor interface {
+	// Load returns the stored time, or the zero time if none was stored yet.
+	Load() (time.Time, error)
+	// Save stores a time, replacing the previous one.
+	Save(t time.Time) error
+}
+
+// Store holds named cursors.
+type Store interface {
+	// Cursor returns the cursor with the given name.
+	Cursor(name string) Cursor
+}
+
+// File returns a cursor stored in the file at path as an RFC 3339 timestamp.
+// The file is replaced atomically, so an interrupted save does not lose the
+// previous value.
+func File(path string) Cursor {
+	return fileCursor(path)
+}
+
+type fileCursor string
+
+func (c fileCursor) Load() (time.Time, error) {
+	data, err := os.ReadFile(string(c))
+	if errors.Is(err, fs.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+}
+
+func (c fileCursor) Save(t time.Time) error {
+	tmp := string(c) + ".tmp"
+	if err := os.WriteFile(tmp, []byte(t.Format(time.RFC3339Nano)+"\n"), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, string(c))
+}
+
+// Dir is a Store keeping each cursor in a file of the directory, named after
+// the cursor. The directory must exist.
+type Dir string
+
+// Cursor returns the cursor with the given name.
+func (d Dir) Cursor(name string) Cursor {
+	return File(filepath.Join(string(d), name))
+}
+
+var cursorsBucket = []byte("cursors")
+
+// Bolt is a Store keeping cursors in a bucket of a bbolt database, which can
+// be shared with other data, such as a localstore.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// NewBolt returns a store using db.
+func NewBolt(db *bolt.DB) *Bolt {
+	return &Bolt{db: db}
+}
+
+// Cursor returns the cursor with the given name.
+func (b *Bolt) Cursor(name string) Cursor {
+	return &boltCursor{db: b.db, key: []byte(name)}
+}
+
+type boltCursor struct {
+	db  *bolt.DB
+	key []byte
+}
+
+func (c *boltCursor) Load() (time.Time, error) {
+	var t time.Time
+
+	err := c.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(cursorsBucket)
+		if bucket == nil {
+			return nil
+		}
+
+		data := bucket.Get(c.key)
+		if data == nil {
+			return nil
+		}
+		return t.UnmarshalText(data)
+	})
+
+	return t, err
+}
+
+func (c *boltCursor) Save(t time.Time) error {
+	data, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(cursorsBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(c.key, data)
+	})
+}
@@ -0,0 +1,135 @@
+package linkding
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+var extraType = reflect.TypeOf(map[string]json.RawMessage(nil))
+
+// decode reads a JSON response into v according to the client's DecodeMode.
+func (c *Client) decode(r io.Reader, v any) error {
+	switch c.decodeMode {
+	case DecodeStrict:
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+
+		return decoder.Decode(v)
+	case DecodeTolerant:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(data, v); err != nil {
+			return err
+		}
+
+		collectExtra(data, reflect.ValueOf(v))
+
+		return nil
+	default:
+		return json.NewDecoder(r).Decode(v)
+	}
+}
+
+// collectExtra walks v alongside its JSON representation and stores the
+// fields that were not decoded into the Extra map of every struct that has
+// one. Values that do not line up with the JSON are skipped, as the regular
+// decoding has already reported any real error.
+func collectExtra(data []byte, v reflect.Value) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return
+		}
+
+		for i := 0; i < v.Len() && i < len(items); i++ {
+			collectExtra(items[i], v.Index(i))
+		}
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return
+		}
+
+		known := map[string]bool{}
+		var extra reflect.Value
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Name == "Extra" && field.Type == extraType {
+				extra = v.Field(i)
+				continue
+			}
+
+			name := jsonName(field)
+			if name == "" {
+				continue
+			}
+			// Keys match field names ignoring case, like encoding/json does.
+			known[strings.ToLower(name)] = true
+
+			if raw, ok := lookupField(fields, name); ok {
+				collectExtra(raw, v.Field(i))
+			}
+		}
+
+		if !extra.IsValid() {
+			return
+		}
+
+		unknown := map[string]json.RawMessage{}
+		for name, raw := range fields {
+			if !known[strings.ToLower(name)] {
+				unknown[name] = raw
+			}
+		}
+		if len(unknown) > 0 {
+			extra.Set(reflect.ValueOf(unknown))
+		}
+	}
+}
+
+// lookupField returns the value of the key of a field, preferring an exact
+// match over one differing in case, as encoding/json does.
+func lookupField(fields map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if raw, ok := fields[name]; ok {
+		return raw, true
+	}
+	for key, raw := range fields {
+		if strings.EqualFold(key, name) {
+			return raw, true
+		}
+	}
+
+	return nil, false
+}
+
+// jsonName returns the JSON key of a struct field, or an empty string if the
+// field is not encoded.
+func jsonName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
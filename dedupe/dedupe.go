@@ -0,0 +1,244 @@
+// Package dedupe finds bookmarks of an account that point to the same page and
+// merges them into one.
+//
+// Linkding rejects a second bookmark for the exact same URL, but URLs that
+// only differ in tracking parameters, letter case of the host or fragments
+// slip through, especially when importing from several sources. Bookmarks are
+// therefore grouped by their normalized URL.
+package dedupe
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/larcher/go-linkding"
+)
+
+// Options configures Find and Dedupe.
+type Options struct {
+	// Maps a URL to the key bookmarks are grouped by. Defaults to
+	// linkding.NormalizeURL.
+	Normalize func(url string) string
+	// Also group bookmarks on the same host whose titles only differ in
+	// letter case, whitespace and punctuation, e.g. the same article
+	// bookmarked through different paths.
+	MatchTitles bool
+	// Only report the groups that would be merged, without changing anything.
+	DryRun bool
+}
+
+// Group is a set of bookmarks considered duplicates of each other.
+type Group struct {
+	// The bookmark that is kept, which is the one added first.
+	Keep linkding.Bookmark
+	// The bookmarks that are deleted, sorted by date added.
+	Duplicates []linkding.Bookmark
+	// The kept bookmark after merging: it carries the tags of every bookmark
+	// of the group, and the longest description and notes.
+	Merged linkding.Bookmark
+}
+
+// Report is the outcome of Dedupe.
+type Report struct {
+	// The groups of duplicates found, sorted by the URL of the kept bookmark.
+	Groups []Group
+	// The number of bookmarks deleted.
+	Deleted int
+	// The reasons merging or deleting failed, by bookmark ID. When the kept
+	// bookmark could not be updated, its duplicates are not deleted.
+	Failed map[int]error
+}
+
+// Find groups bookmarks that are duplicates of each other. Bookmarks without
+// duplicates are not part of the result.
+func Find(bookmarks []linkding.Bookmark, opts Options) []Group {
+	normalize := opts.Normalize
+	if normalize == nil {
+		normalize = linkding.NormalizeURL
+	}
+
+	// The bookmarks are sorted by date added, so the first bookmark of each
+	// group is the one kept. Groups are joined with a union-find, as a
+	// bookmark can match one group by URL and another one by title.
+	bookmarks = slices.Clone(bookmarks)
+	slices.SortStableFunc(bookmarks, func(a, b linkding.Bookmark) int {
+		if c := a.DateAdded.Compare(b.DateAdded); c != 0 {
+			return c
+		}
+		return a.ID - b.ID
+	})
+
+	parents := make([]int, len(bookmarks))
+	for i := range parents {
+		parents[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		if parents[i] != i {
+			parents[i] = root(parents[i])
+		}
+		return parents[i]
+	}
+
+	firstByKey := map[string]int{}
+	join := func(key string, i int) {
+		if first, ok := firstByKey[key]; ok {
+			a, b := root(first), root(i)
+			parents[max(a, b)] = min(a, b)
+		} else {
+			firstByKey[key] = i
+		}
+	}
+
+	for i, bookmark := range bookmarks {
+		join("url "+normalize(bookmark.URL), i)
+		if opts.MatchTitles {
+			if key := titleKey(bookmark); key != "" {
+				join("title "+key, i)
+			}
+		}
+	}
+
+	members := map[int][]linkding.Bookmark{}
+	for i, bookmark := range bookmarks {
+		r := root(i)
+		members[r] = append(members[r], bookmark)
+	}
+
+	groups := []Group{}
+	for _, group := range members {
+		if len(group) > 1 {
+			groups = append(groups, Group{
+				Keep:       group[0],
+				Duplicates: group[1:],
+				Merged:     merge(group),
+			})
+		}
+	}
+	slices.SortFunc(groups, func(a, b Group) int {
+		return strings.Compare(a.Keep.URL, b.Keep.URL)
+	})
+
+	return groups
+}
+
+// Dedupe finds the duplicates among the bookmarks of an account, active and
+// archived, and merges each group into the bookmark added first, deleting the
+// others. Run it with opts.DryRun first to review the groups.
+//
+// An error is only returned if the bookmarks could not be listed.
+func Dedupe(ctx context.Context, c linkding.BookmarkClient, opts Options) (*Report, error) {
+	bookmarks, err := fetch(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Groups: Find(bookmarks, opts), Failed: map[int]error{}}
+	if opts.DryRun {
+		return report, nil
+	}
+
+	for _, group := range report.Groups {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		if patch, changed := mergePatch(group.Keep, group.Merged); changed {
+			if _, err := c.PatchBookmark(group.Keep.ID, patch); err != nil {
+				report.Failed[group.Keep.ID] = err
+				continue
+			}
+		}
+
+		for _, duplicate := range group.Duplicates {
+			if err := c.DeleteBookmark(duplicate.ID); err != nil {
+				report.Failed[duplicate.ID] = err
+				continue
+			}
+			report.Deleted++
+		}
+	}
+
+	return report, nil
+}
+
+// merge returns the first bookmark of a group with the tags of all of them,
+// and the longest description and notes.
+func merge(group []linkding.Bookmark) linkding.Bookmark {
+	merged := group[0]
+	merged.TagNames = slices.Clone(merged.TagNames)
+
+	for _, bookmark := range group[1:] {
+		for _, tag := range bookmark.TagNames {
+			if !slices.ContainsFunc(merged.TagNames, func(name string) bool { return strings.EqualFold(name, tag) }) {
+				merged.TagNames = append(merged.TagNames, tag)
+			}
+		}
+		if len(bookmark.Description) > len(merged.Description) {
+			merged.Description = bookmark.Description
+		}
+		if len(bookmark.Notes) > len(merged.Notes) {
+			merged.Notes = bookmark.Notes
+		}
+	}
+
+	return merged
+}
+
+func mergePatch(keep, merged linkding.Bookmark) (linkding.PatchBookmarkRequest, bool) {
+	patch := linkding.PatchBookmarkRequest{}
+	if !slices.Equal(keep.TagNames, merged.TagNames) {
+		patch.TagNames = &merged.TagNames
+	}
+	if keep.Description != merged.Description {
+		patch.Description = &merged.Description
+	}
+	if keep.Notes != merged.Notes {
+		patch.Notes = &merged.Notes
+	}
+
+	return patch, patch.TagNames != nil || patch.Description != nil || patch.Notes != nil
+}
+
+// titleKey returns the host of a bookmark followed by its title reduced to
+// lowercase letters and digits, or an empty string if it has no title.
+func titleKey(bookmark linkding.Bookmark) string {
+	title := bookmark.Title
+	if title == "" {
+		title = bookmark.WebsiteTitle
+	}
+
+	reduced := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, title)
+	if reduced == "" {
+		return ""
+	}
+
+	u, err := url.Parse(bookmark.URL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") + " " + reduced
+}
+
+// fetch lists the active and archived bookmarks.
+func fetch(ctx context.Context, c linkding.BookmarkClient) ([]linkding.Bookmark, error) {
+	bookmarks := []linkding.Bookmark{}
+
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return bookmarks, nil
+}
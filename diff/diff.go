@@ -0,0 +1,223 @@
+// Package diff compares two sets of Linkding bookmarks, such as two instances
+// or an instance and a backup, and reports what was added, removed and
+// changed.
+//
+// Bookmarks are matched by URL, as IDs differ between instances.
+package diff
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// Field identifies a bookmark field that is compared. The names match the
+// fields of the Linkding API.
+type Field string
+
+const (
+	FieldTitle              Field = "title"
+	FieldDescription        Field = "description"
+	FieldNotes              Field = "notes"
+	FieldWebsiteTitle       Field = "website_title"
+	FieldWebsiteDescription Field = "website_description"
+	FieldTags               Field = "tag_names"
+	FieldUnread             Field = "unread"
+	FieldShared             Field = "shared"
+	FieldArchived           Field = "is_archived"
+	FieldDateAdded          Field = "date_added"
+	FieldDateModified       Field = "date_modified"
+)
+
+// DefaultFields are the fields compared when no fields are configured. They
+// are the fields that can be set through the API, so two instances kept in
+// sync compare equal.
+var DefaultFields = []Field{
+	FieldTitle,
+	FieldDescription,
+	FieldNotes,
+	FieldTags,
+	FieldUnread,
+	FieldShared,
+	FieldArchived,
+}
+
+// Options configures Compare.
+type Options struct {
+	// The fields to compare. Defaults to DefaultFields.
+	Fields []Field
+}
+
+// FieldChange is a field whose value differs between two bookmarks. Values
+// are formatted as text: tags are sorted and joined by spaces, and dates are
+// formatted as RFC 3339.
+type FieldChange struct {
+	Field Field
+	Old   string
+	New   string
+}
+
+// Change is a bookmark present in both sets with differing fields.
+type Change struct {
+	Old    linkding.Bookmark
+	New    linkding.Bookmark
+	Fields []FieldChange
+}
+
+// Result is the difference between two sets of bookmarks. Each list is sorted
+// by URL.
+type Result struct {
+	// Bookmarks only present in the new set.
+	Added []linkding.Bookmark
+	// Bookmarks only present in the old set.
+	Removed []linkding.Bookmark
+	// Bookmarks present in both sets with differing fields.
+	Changed []Change
+	// The number of bookmarks present in both sets with equal fields.
+	Unchanged int
+}
+
+// Empty reports whether the sets are equal.
+func (r *Result) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// Compare compares an old and a new set of bookmarks. If a URL occurs more
+// than once in a set, the last bookmark with that URL is used.
+func Compare(old, new []linkding.Bookmark, opts Options) *Result {
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+
+	oldByURL := byURL(old)
+	newByURL := byURL(new)
+	result := &Result{
+		Added:   []linkding.Bookmark{},
+		Removed: []linkding.Bookmark{},
+		Changed: []Change{},
+	}
+
+	for url, before := range oldByURL {
+		after, ok := newByURL[url]
+		if !ok {
+			result.Removed = append(result.Removed, before)
+			continue
+		}
+
+		if changes := compareFields(before, after, fields); len(changes) > 0 {
+			result.Changed = append(result.Changed, Change{Old: before, New: after, Fields: changes})
+		} else {
+			result.Unchanged++
+		}
+	}
+
+	for url, after := range newByURL {
+		if _, ok := oldByURL[url]; !ok {
+			result.Added = append(result.Added, after)
+		}
+	}
+
+	compareURL := func(a, b linkding.Bookmark) int { return strings.Compare(a.URL, b.URL) }
+	slices.SortFunc(result.Added, compareURL)
+	slices.SortFunc(result.Removed, compareURL)
+	slices.SortFunc(result.Changed, func(a, b Change) int { return compareURL(a.New, b.New) })
+
+	return result
+}
+
+// Instances compares the bookmarks, active and archived, of two instances.
+func Instances(ctx context.Context, old, new linkding.BookmarkClient, opts Options) (*Result, error) {
+	before, err := Fetch(ctx, old)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := Fetch(ctx, new)
+	if err != nil {
+		return nil, err
+	}
+
+	return Compare(before, after, opts), nil
+}
+
+// Fetch retrieves every bookmark of an instance, active and archived, to be
+// compared with Compare.
+func Fetch(ctx context.Context, c linkding.BookmarkClient) ([]linkding.Bookmark, error) {
+	bookmarks := []linkding.Bookmark{}
+
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return bookmarks, nil
+}
+
+func byURL(bookmarks []linkding.Bookmark) map[string]linkding.Bookmark {
+	result := make(map[string]linkding.Bookmark, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		result[bookmark.URL] = bookmark
+	}
+
+	return result
+}
+
+func compareFields(old, new linkding.Bookmark, fields []Field) []FieldChange {
+	changes := []FieldChange{}
+	for _, field := range fields {
+		before, after := fieldValue(old, field), fieldValue(new, field)
+		if before != after {
+			changes = append(changes, FieldChange{Field: field, Old: before, New: after})
+		}
+	}
+
+	return changes
+}
+
+func fieldValue(bookmark linkding.Bookmark, field Field) string {
+	switch field {
+	case FieldTitle:
+		return bookmark.Title
+	case FieldDescription:
+		return bookmark.Description
+	case FieldNotes:
+		return bookmark.Notes
+	case FieldWebsiteTitle:
+		return bookmark.WebsiteTitle
+	case FieldWebsiteDescription:
+		return bookmark.WebsiteDescription
+	case FieldTags:
+		tags := slices.Clone(bookmark.TagNames)
+		slices.SortFunc(tags, func(a, b string) int {
+			return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		})
+		return strings.Join(tags, " ")
+	case FieldUnread:
+		return strconv.FormatBool(bookmark.Unread)
+	case FieldShared:
+		return strconv.FormatBool(bookmark.Shared)
+	case FieldArchived:
+		return strconv.FormatBool(bookmark.IsArchived)
+	case FieldDateAdded:
+		return formatDate(bookmark.DateAdded)
+	case FieldDateModified:
+		return formatDate(bookmark.DateModified)
+	}
+
+	return ""
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
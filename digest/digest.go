@@ -0,0 +1,190 @@
+// Package digest renders reports of the bookmarks added in a period, such as
+// "bookmarks of the week", as Markdown or HTML for posting to wikis or
+// sending as newsletters.
+//
+// A Digest is built from the bookmarks of an account and rendered with a
+// template. The default templates in Markdown and HTML can be replaced by any
+// text/template or html/template template, which receive the Digest as data.
+package digest
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// GroupBy selects how the bookmarks of a digest are grouped.
+type GroupBy int
+
+const (
+	// NoGroups lists all bookmarks in a single group.
+	NoGroups GroupBy = iota
+	// GroupByTag lists bookmarks under each of their tags, so bookmarks with
+	// several tags appear several times.
+	GroupByTag
+	// GroupByDomain lists bookmarks under the domain of their URL.
+	GroupByDomain
+)
+
+// Options configures a digest.
+type Options struct {
+	// The title of the digest. Defaults to "Bookmarks".
+	Title string
+	// The period of the digest. Only bookmarks added since Since and before
+	// Until are included. Until defaults to now.
+	Since time.Time
+	Until time.Time
+	// Filters the bookmarks, e.g. with a query or only unread bookmarks. The
+	// added since date is set from Since.
+	Params linkding.ListBookmarksParams
+	// Also include archived bookmarks.
+	IncludeArchived bool
+	GroupBy         GroupBy
+}
+
+// Digest is the data passed to templates.
+type Digest struct {
+	Title string
+	Since time.Time
+	Until time.Time
+	// The number of bookmarks in the digest.
+	Total  int
+	Groups []Group
+}
+
+// Group is a group of bookmarks of a digest. Bookmarks are sorted by the date
+// they were added, oldest first.
+type Group struct {
+	// The tag or domain of the group. It is empty for the single group when
+	// bookmarks are not grouped, and for the group of untagged bookmarks.
+	Name      string
+	Bookmarks []linkding.Bookmark
+}
+
+// Template is a parsed text/template or html/template template.
+type Template interface {
+	Execute(w io.Writer, data any) error
+}
+
+// Week returns the period of the last seven days up to now, for
+// Options.Since and Options.Until.
+func Week(now time.Time) (since, until time.Time) {
+	return now.AddDate(0, 0, -7), now
+}
+
+// Month returns the period of the last month up to now, for Options.Since and
+// Options.Until.
+func Month(now time.Time) (since, until time.Time) {
+	return now.AddDate(0, -1, 0), now
+}
+
+// Build fetches the bookmarks added in the period of opts and returns their
+// digest.
+func Build(ctx context.Context, c linkding.BookmarkClient, opts Options) (*Digest, error) {
+	params := opts.Params
+	params.AddedSince = opts.Since
+
+	all := linkding.AllBookmarks
+	if opts.IncludeArchived {
+		all = linkding.AllBookmarksIncludingArchived
+	}
+
+	bookmarks := []linkding.Bookmark{}
+	for bookmark, err := range all(ctx, c, params) {
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return New(bookmarks, opts), nil
+}
+
+// New returns the digest of the bookmarks added in the period of opts. Params
+// and IncludeArchived are ignored, as the bookmarks are already given.
+func New(bookmarks []linkding.Bookmark, opts Options) *Digest {
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	bookmarks = slices.DeleteFunc(slices.Clone(bookmarks), func(bookmark linkding.Bookmark) bool {
+		return bookmark.DateAdded.Before(opts.Since) || !bookmark.DateAdded.Before(until)
+	})
+	slices.SortStableFunc(bookmarks, func(a, b linkding.Bookmark) int {
+		return a.DateAdded.Compare(b.DateAdded)
+	})
+
+	return &Digest{
+		Title:  cmp.Or(opts.Title, "Bookmarks"),
+		Since:  opts.Since,
+		Until:  until,
+		Total:  len(bookmarks),
+		Groups: group(bookmarks, opts.GroupBy),
+	}
+}
+
+// Render writes the digest using tmpl, e.g. Markdown or HTML.
+func (d *Digest) Render(w io.Writer, tmpl Template) error {
+	return tmpl.Execute(w, d)
+}
+
+// group splits the bookmarks into groups sorted by name, with the group of
+// bookmarks without a name last.
+func group(bookmarks []linkding.Bookmark, by GroupBy) []Group {
+	if by == NoGroups {
+		if len(bookmarks) == 0 {
+			return []Group{}
+		}
+		return []Group{{Bookmarks: bookmarks}}
+	}
+
+	groups := map[string]*Group{}
+	add := func(name string, bookmark linkding.Bookmark) {
+		key := strings.ToLower(name)
+		if groups[key] == nil {
+			groups[key] = &Group{Name: name}
+		}
+		groups[key].Bookmarks = append(groups[key].Bookmarks, bookmark)
+	}
+
+	for _, bookmark := range bookmarks {
+		switch by {
+		case GroupByTag:
+			if len(bookmark.TagNames) == 0 {
+				add("", bookmark)
+			}
+			for _, tag := range bookmark.TagNames {
+				add(tag, bookmark)
+			}
+		case GroupByDomain:
+			add(bookmark.Domain(), bookmark)
+		}
+	}
+
+	result := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	slices.SortFunc(result, func(a, b Group) int {
+		if (a.Name == "") != (b.Name == "") {
+			if a.Name == "" {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return result
+}
+
+// description returns the description of a bookmark as shown by Linkding.
+func description(bookmark linkding.Bookmark) string {
+	return cmp.Or(bookmark.Description, bookmark.WebsiteDescription)
+}
@@ -0,0 +1,180 @@
+package digest
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/cursor"
+)
+
+// DefaultEvery is the time between scheduled digests when not configured.
+const DefaultEvery = 7 * 24 * time.Hour
+
+// EmailOptions configures how digests are emailed.
+type EmailOptions struct {
+	// The address of the SMTP server, as "host:port".
+	Addr string
+	// The authentication used with the server, e.g. smtp.PlainAuth. No
+	// authentication is used when nil.
+	Auth smtp.Auth
+	From string
+	To   []string
+	// The subject of the email. Defaults to the title of the digest.
+	Subject string
+	// The templates of the plain text and HTML parts of the email. They
+	// default to Markdown and HTML.
+	Text Template
+	HTML Template
+}
+
+// Message returns the digest as an email message with a plain text and an
+// HTML part, ready to be sent over SMTP.
+func Message(d *Digest, opts EmailOptions) ([]byte, error) {
+	text := cmp.Or[Template](opts.Text, Markdown)
+	html := cmp.Or[Template](opts.HTML, HTML)
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		tmpl        Template
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if err := d.Render(qp, part.tmpl); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", opts.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(opts.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", cmp.Or(opts.Subject, d.Title)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+// Send emails the digest.
+func Send(d *Digest, opts EmailOptions) error {
+	if opts.Addr == "" || opts.From == "" || len(opts.To) == 0 {
+		return errors.New("digest: email requires a server address, a sender and recipients")
+	}
+
+	msg, err := Message(d, opts)
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(opts.Addr, opts.Auth, opts.From, opts.To, msg)
+}
+
+// ScheduleOptions configures Schedule.
+type ScheduleOptions struct {
+	// Selects and groups the bookmarks of each digest, e.g. Params.Unread for
+	// a reading list. The period is set by the schedule.
+	Digest Options
+	Email  EmailOptions
+	// The time between digests, which is also the period each digest covers.
+	// Defaults to DefaultEvery.
+	Every time.Duration
+	// Persists the end of the period of the last digest sent, so a restarted
+	// schedule continues where it left off instead of starting over.
+	Cursor cursor.Cursor
+	// Do not send digests without bookmarks.
+	SkipEmpty bool
+	// Called when a digest could not be built or sent, if set. The period
+	// of a failed digest is covered by the next one.
+	OnError func(err error)
+}
+
+// Schedule emails a digest of the bookmarks added in each period, until ctx is
+// done. The first digest is sent one period after the end of the last digest
+// stored in the cursor, or one period from now.
+func Schedule(ctx context.Context, c linkding.BookmarkClient, opts ScheduleOptions) error {
+	every := cmp.Or(opts.Every, DefaultEvery)
+
+	last := time.Now()
+	if opts.Cursor != nil {
+		stored, err := opts.Cursor.Load()
+		if err != nil {
+			return err
+		}
+		if !stored.IsZero() {
+			last = stored
+		}
+	}
+
+	since, next := last, last.Add(every)
+	for {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		until := time.Now()
+		if err := sendPeriod(ctx, c, opts, since, until); err != nil {
+			if opts.OnError != nil {
+				opts.OnError(err)
+			}
+			next = next.Add(every)
+			continue
+		}
+		since, next = until, until.Add(every)
+	}
+}
+
+func sendPeriod(ctx context.Context, c linkding.BookmarkClient, opts ScheduleOptions, since, until time.Time) error {
+	digestOpts := opts.Digest
+	digestOpts.Since = since
+	digestOpts.Until = until
+
+	d, err := Build(ctx, c, digestOpts)
+	if err != nil {
+		return err
+	}
+
+	if d.Total > 0 || !opts.SkipEmpty {
+		if err := Send(d, opts.Email); err != nil {
+			return err
+		}
+	}
+
+	if opts.Cursor != nil {
+		return opts.Cursor.Save(until)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package digest
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// Funcs are the functions available to the default templates, which custom
+// templates can use as well:
+//
+//   - title returns the title of a bookmark as shown by Linkding, falling back
+//     to the website title and the URL.
+//   - description returns the description of a bookmark, falling back to the
+//     website description.
+//   - date formats a time as "2006-01-02".
+//   - markdown escapes the characters of text that have a meaning in Markdown.
+var Funcs = map[string]any{
+	"title":       linkding.Bookmark.EffectiveTitle,
+	"description": description,
+	"date":        func(t time.Time) string { return t.Format(time.DateOnly) },
+	"markdown":    escapeMarkdown,
+}
+
+// Markdown is the default Markdown template.
+var Markdown = texttemplate.Must(texttemplate.New("digest.md").Funcs(Funcs).Parse(`# {{markdown .Title}}
+
+{{.Total}} bookmarks added from {{date .Since}} to {{date .Until}}.
+{{range .Groups}}{{if .Name}}
+## {{markdown .Name}}
+{{else if gt (len $.Groups) 1}}
+## Other
+{{end}}
+{{range .Bookmarks}}- [{{markdown (title .)}}]({{.URL}}){{with description .}}: {{markdown .}}{{end}}
+{{end}}{{end}}`))
+
+// HTML is the default HTML template.
+var HTML = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(Funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Total}} bookmarks added from {{date .Since}} to {{date .Until}}.</p>
+{{range .Groups}}{{if .Name}}<h2>{{.Name}}</h2>
+{{else if gt (len $.Groups) 1}}<h2>Other</h2>
+{{end}}<ul>
+{{range .Bookmarks}}<li><a href="{{.URL}}">{{title .}}</a>{{with description .}}<br>{{.}}{{end}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+	"#", `\#`,
+	"\n", " ",
+)
+
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
@@ -0,0 +1,177 @@
+package linkding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultShortenerHosts are the hosts of common link shorteners, whose links
+// are expanded when no hosts are configured.
+var DefaultShortenerHosts = []string{
+	"bit.ly",
+	"buff.ly",
+	"dlvr.it",
+	"goo.gl",
+	"is.gd",
+	"lnkd.in",
+	"ow.ly",
+	"rb.gy",
+	"t.co",
+	"t.ly",
+	"tiny.cc",
+	"tinyurl.com",
+	"trib.al",
+	"youtu.be",
+}
+
+// DefaultMaxHops is the number of redirects followed when expanding a URL
+// when not configured.
+const DefaultMaxHops = 10
+
+// DefaultExpandTimeout is the time allowed for expanding a URL when not
+// configured.
+const DefaultExpandTimeout = 10 * time.Second
+
+// ExpandOptions configures how short links are expanded.
+type ExpandOptions struct {
+	// The hosts whose links are expanded. Defaults to DefaultShortenerHosts.
+	Hosts []string
+	// Expand the links of every host, e.g. to resolve redirects of tracking
+	// links in newsletters. Hosts is ignored when set.
+	AllHosts bool
+	// The number of redirects followed at most. Defaults to DefaultMaxHops.
+	MaxHops int
+	// The time allowed for following all redirects of a link. Defaults to
+	// DefaultExpandTimeout.
+	Timeout time.Duration
+	// The client used to follow redirects. Its CheckRedirect function is
+	// ignored. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Add the original link to the notes of bookmarks created with an
+	// expanded URL.
+	KeepOriginal bool
+}
+
+// ExpandURL follows the redirects of rawURL and returns the URL it finally
+// points to. Links of hosts that are not expanded according to opts are
+// returned unchanged. If the redirects cannot be followed to the end, the last
+// URL reached is returned along with the error.
+func ExpandURL(ctx context.Context, rawURL string, opts ExpandOptions) (string, error) {
+	current, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL, err
+	}
+	if !opts.AllHosts && !expandsHost(opts.Hosts, current.Hostname()) {
+		return rawURL, nil
+	}
+
+	maxHops := opts.MaxHops
+	if maxHops <= 0 {
+		maxHops = DefaultMaxHops
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultExpandTimeout
+	}
+
+	client := &http.Client{}
+	if opts.HTTPClient != nil {
+		*client = *opts.HTTPClient
+	}
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for range maxHops {
+		location, err := nextHop(ctx, client, current)
+		if err != nil {
+			return current.String(), err
+		}
+		if location == nil {
+			return current.String(), nil
+		}
+		current = location
+	}
+
+	return current.String(), fmt.Errorf("linkding: more than %d redirects expanding %s", maxHops, rawURL)
+}
+
+// nextHop requests u and returns the URL it redirects to, or nil if it does
+// not redirect. HEAD requests are tried first, as they avoid downloading the
+// target page, but some shorteners only answer GET requests.
+func nextHop(ctx context.Context, client *http.Client, u *url.URL) (*url.URL, error) {
+	var res *http.Response
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err = client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusMethodNotAllowed && res.StatusCode != http.StatusNotImplemented {
+			break
+		}
+	}
+
+	if res.StatusCode < 300 || res.StatusCode >= 400 {
+		return nil, nil
+	}
+
+	location, err := res.Location()
+	if errors.Is(err, http.ErrNoLocation) {
+		return nil, nil
+	}
+
+	return location, err
+}
+
+func expandsHost(hosts []string, host string) bool {
+	if hosts == nil {
+		hosts = DefaultShortenerHosts
+	}
+
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	return slices.ContainsFunc(hosts, func(h string) bool {
+		return strings.EqualFold(h, host)
+	})
+}
+
+// expandPayload replaces the URL of a bookmark about to be created with its
+// expanded form, if the client expands links. Links that cannot be expanded
+// are kept, so a shortener being down does not prevent bookmarking.
+func (c *Client) expandPayload(ctx context.Context, payload *CreateBookmarkRequest) {
+	if c.expand == nil {
+		return
+	}
+
+	expanded, err := ExpandURL(ctx, payload.URL, *c.expand)
+	if err != nil || expanded == payload.URL {
+		return
+	}
+
+	if c.expand.KeepOriginal {
+		original := "Original URL: " + payload.URL
+		if payload.Notes != "" {
+			payload.Notes += "\n\n" + original
+		} else {
+			payload.Notes = original
+		}
+	}
+	payload.URL = expanded
+}
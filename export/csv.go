@@ -0,0 +1,165 @@
+// Package export writes Linkding bookmarks to file formats understood by other
+// tools.
+//
+// Exporters consume an iterator of bookmarks, such as the one returned by
+// linkding.AllBookmarks, and stream their output to an io.Writer, so large
+// accounts can be exported without holding every bookmark in memory.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// Column identifies a bookmark field in a CSV file. The names match the
+// fields of the Linkding API.
+type Column string
+
+const (
+	ColumnID                 Column = "id"
+	ColumnURL                Column = "url"
+	ColumnTitle              Column = "title"
+	ColumnDescription        Column = "description"
+	ColumnNotes              Column = "notes"
+	ColumnWebsiteTitle       Column = "website_title"
+	ColumnWebsiteDescription Column = "website_description"
+	ColumnTags               Column = "tags"
+	ColumnUnread             Column = "unread"
+	ColumnShared             Column = "shared"
+	ColumnArchived           Column = "is_archived"
+	ColumnDateAdded          Column = "date_added"
+	ColumnDateModified       Column = "date_modified"
+)
+
+// DefaultCSVColumns are the columns written when no columns are configured.
+var DefaultCSVColumns = []Column{
+	ColumnURL,
+	ColumnTitle,
+	ColumnDescription,
+	ColumnNotes,
+	ColumnTags,
+	ColumnUnread,
+	ColumnShared,
+	ColumnArchived,
+	ColumnDateAdded,
+	ColumnDateModified,
+}
+
+// DefaultTagSeparator joins tag names when no separator is configured.
+const DefaultTagSeparator = " "
+
+// CSVOptions configures WriteCSV.
+type CSVOptions struct {
+	// The columns to write, in order. Defaults to DefaultCSVColumns.
+	Columns []Column
+	// The separator used to join tag names. Defaults to DefaultTagSeparator.
+	TagSeparator string
+	// Skip writing the header row with the column names.
+	OmitHeader bool
+}
+
+// WriteCSV writes the bookmarks to w as CSV and returns the number of
+// bookmarks written. Dates are formatted as RFC 3339 and left empty when not
+// set.
+//
+// Writing stops at the first error yielded by the iterator.
+func WriteCSV(w io.Writer, bookmarks iter.Seq2[linkding.Bookmark, error], opts CSVOptions) (int, error) {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+
+	separator := opts.TagSeparator
+	if separator == "" {
+		separator = DefaultTagSeparator
+	}
+
+	for _, column := range columns {
+		if _, err := csvValue(linkding.Bookmark{}, column, separator); err != nil {
+			return 0, err
+		}
+	}
+
+	writer := csv.NewWriter(w)
+
+	if !opts.OmitHeader {
+		header := make([]string, len(columns))
+		for i, column := range columns {
+			header[i] = string(column)
+		}
+
+		if err := writer.Write(header); err != nil {
+			return 0, err
+		}
+	}
+
+	count := 0
+	record := make([]string, len(columns))
+	for bookmark, err := range bookmarks {
+		if err != nil {
+			writer.Flush()
+			return count, err
+		}
+
+		for i, column := range columns {
+			record[i], _ = csvValue(bookmark, column, separator)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	writer.Flush()
+
+	return count, writer.Error()
+}
+
+func csvValue(bookmark linkding.Bookmark, column Column, tagSeparator string) (string, error) {
+	switch column {
+	case ColumnID:
+		return strconv.Itoa(bookmark.ID), nil
+	case ColumnURL:
+		return bookmark.URL, nil
+	case ColumnTitle:
+		return bookmark.Title, nil
+	case ColumnDescription:
+		return bookmark.Description, nil
+	case ColumnNotes:
+		return bookmark.Notes, nil
+	case ColumnWebsiteTitle:
+		return bookmark.WebsiteTitle, nil
+	case ColumnWebsiteDescription:
+		return bookmark.WebsiteDescription, nil
+	case ColumnTags:
+		return strings.Join(bookmark.TagNames, tagSeparator), nil
+	case ColumnUnread:
+		return strconv.FormatBool(bookmark.Unread), nil
+	case ColumnShared:
+		return strconv.FormatBool(bookmark.Shared), nil
+	case ColumnArchived:
+		return strconv.FormatBool(bookmark.IsArchived), nil
+	case ColumnDateAdded:
+		return formatDate(bookmark.DateAdded), nil
+	case ColumnDateModified:
+		return formatDate(bookmark.DateModified), nil
+	default:
+		return "", fmt.Errorf("export: unknown column %q", column)
+	}
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/cursor"
+)
+
+// ChangedBookmarks returns an iterator over the bookmarks, active and
+// archived, that were modified after the watermark. The returned function
+// reports the new watermark once iteration has finished, which is the latest
+// modification date seen, or the given watermark if nothing changed.
+//
+// The watermark is compared to the modification dates of the server, so the
+// clocks of the client and server do not need to agree. Deleted bookmarks
+// cannot be detected this way.
+func ChangedBookmarks(ctx context.Context, c linkding.BookmarkClient, watermark time.Time) (iter.Seq2[linkding.Bookmark, error], func() time.Time) {
+	latest := watermark
+	params := linkding.ListBookmarksParams{ModifiedSince: watermark}
+
+	bookmarks := func(yield func(linkding.Bookmark, error) bool) {
+		for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, params) {
+			// The API compares dates with a precision of seconds and includes
+			// the watermark itself, so bookmarks that were already exported are
+			// filtered out here.
+			if err == nil && !bookmark.DateModified.After(watermark) {
+				continue
+			}
+			if err == nil && bookmark.DateModified.After(latest) {
+				latest = bookmark.DateModified
+			}
+
+			if !yield(bookmark, err) || err != nil {
+				return
+			}
+		}
+	}
+
+	return bookmarks, func() time.Time { return latest }
+}
+
+// ExportChanged runs an incremental export. It loads the watermark from the
+// cursor, e.g. cursor.File("last-export"), passes the bookmarks modified since
+// then to write, e.g. a closure calling WriteCSV, and saves the new watermark
+// if write succeeds. It returns what write returned. Without a stored
+// watermark, every bookmark is exported.
+//
+// As the watermark is only advanced after a successful export, a failed run
+// is repeated in full by the next one.
+func ExportChanged(ctx context.Context, c linkding.BookmarkClient, watermarks cursor.Cursor, write func(iter.Seq2[linkding.Bookmark, error]) (int, error)) (int, error) {
+	watermark, err := watermarks.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	bookmarks, latest := ChangedBookmarks(ctx, c, watermark)
+	count, err := write(bookmarks)
+	if err != nil {
+		return count, err
+	}
+
+	if next := latest(); next.After(watermark) {
+		if err := watermarks.Save(next); err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
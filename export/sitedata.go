@@ -0,0 +1,249 @@
+package export
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// StaticSite is a static site generator that reads data files.
+type StaticSite string
+
+const (
+	// Hugo reads data files from the data directory and exposes them as
+	// .Site.Data.
+	Hugo StaticSite = "hugo"
+	// Jekyll reads data files from the _data directory and exposes them as
+	// site.data. It does not support TOML.
+	Jekyll StaticSite = "jekyll"
+)
+
+// DataFormat is the file format of data files.
+type DataFormat string
+
+const (
+	DataJSON DataFormat = "json"
+	DataYAML DataFormat = "yaml"
+	DataTOML DataFormat = "toml"
+)
+
+// SiteDataOptions configures WriteSiteData.
+type SiteDataOptions struct {
+	// The site generator the files are written for. Defaults to Hugo.
+	Site StaticSite
+	// The format of the files. Defaults to DataYAML.
+	Format DataFormat
+	// The name of the data file, or of the directory of data files when
+	// grouping by tag. Defaults to "bookmarks".
+	Name string
+	// Write a file for each tag, named after the tag, instead of a single
+	// file. Bookmarks with several tags are written to each of their files,
+	// untagged bookmarks to "untagged".
+	GroupByTag bool
+}
+
+// siteEntry is a bookmark as written to data files.
+type siteEntry struct {
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Tags        []string  `json:"tags"`
+	Date        time.Time `json:"date"`
+}
+
+// WriteSiteData writes the bookmarks as data files of a static site generator
+// into the site directory root, so the site can render a page of bookmarks,
+// and returns the number of bookmarks written. For example, with the default
+// options a Hugo template can list the bookmarks with:
+//
+//	{{ range .Site.Data.bookmarks }}<a href="{{ .url }}">{{ .title }}</a>{{ end }}
+//
+// Each file holds a list of bookmarks with the fields title, url,
+// description, tags and date, newest first. As TOML files cannot hold a list
+// at the top level, the list of TOML files is held by the key "bookmarks".
+// Reading stops at the first error yielded by the iterator, before any file is
+// written.
+func WriteSiteData(root string, bookmarks iter.Seq2[linkding.Bookmark, error], opts SiteDataOptions) (int, error) {
+	site := cmp.Or(opts.Site, Hugo)
+	format := cmp.Or(opts.Format, DataYAML)
+	name := cmp.Or(opts.Name, "bookmarks")
+
+	dir := "data"
+	switch site {
+	case Hugo:
+	case Jekyll:
+		dir = "_data"
+		if format == DataTOML {
+			return 0, errors.New("export: jekyll does not support TOML data files")
+		}
+	default:
+		return 0, fmt.Errorf("export: unknown static site generator %q", site)
+	}
+
+	var write func(io.Writer, []siteEntry) error
+	switch format {
+	case DataJSON:
+		write = writeDataJSON
+	case DataYAML:
+		write = writeDataYAML
+	case DataTOML:
+		write = writeDataTOML
+	default:
+		return 0, fmt.Errorf("export: unknown data format %q", format)
+	}
+
+	files := map[string][]siteEntry{}
+	count := 0
+	for bookmark, err := range bookmarks {
+		if err != nil {
+			return count, err
+		}
+		count++
+
+		entry := siteEntry{
+			Title:       bookmark.EffectiveTitle(),
+			URL:         bookmark.URL,
+			Description: cmp.Or(bookmark.Description, bookmark.WebsiteDescription),
+			Tags:        slices.Clone(bookmark.TagNames),
+			Date:        bookmark.DateAdded.UTC().Truncate(time.Second),
+		}
+		if entry.Tags == nil {
+			entry.Tags = []string{}
+		}
+
+		if !opts.GroupByTag {
+			files[name] = append(files[name], entry)
+			continue
+		}
+		if len(bookmark.TagNames) == 0 {
+			files[filepath.Join(name, "untagged")] = append(files[filepath.Join(name, "untagged")], entry)
+		}
+		for _, tag := range bookmark.TagNames {
+			file := filepath.Join(name, dataFileName(tag))
+			files[file] = append(files[file], entry)
+		}
+	}
+
+	ext := "." + string(format)
+	if site == Jekyll && format == DataYAML {
+		ext = ".yml"
+	}
+
+	for file, entries := range files {
+		slices.SortStableFunc(entries, func(a, b siteEntry) int {
+			return b.Date.Compare(a.Date)
+		})
+
+		path := filepath.Join(root, dir, file+ext)
+		if err := writeDataFile(path, entries, write); err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+func writeDataFile(path string, entries []siteEntry, write func(io.Writer, []siteEntry) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	if err := write(writer, entries); err != nil {
+		file.Close()
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// dataFileName turns a tag into a file name that site generators can address
+// as a key, keeping letters, digits, dashes and underscores.
+func dataFileName(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(tag))
+}
+
+func writeDataJSON(w io.Writer, entries []siteEntry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(entries)
+}
+
+// writeDataYAML writes the entries as YAML. Strings are written as JSON
+// strings, which are valid double-quoted YAML scalars.
+func writeDataYAML(w io.Writer, entries []siteEntry) error {
+	if len(entries) == 0 {
+		_, err := io.WriteString(w, "[]\n")
+		return err
+	}
+
+	for _, entry := range entries {
+		_, err := fmt.Fprintf(w, "- title: %s\n  url: %s\n  description: %s\n  tags: %s\n  date: %s\n",
+			quote(entry.Title), quote(entry.URL), quote(entry.Description), quoteList(entry.Tags), entry.Date.Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeDataTOML writes the entries as an array of tables. Strings are written
+// as JSON strings, which are valid TOML basic strings.
+func writeDataTOML(w io.Writer, entries []siteEntry) error {
+	for i, entry := range entries {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+
+		_, err := fmt.Fprintf(w, "[[bookmarks]]\ntitle = %s\nurl = %s\ndescription = %s\ntags = %s\ndate = %s\n",
+			quote(entry.Title), quote(entry.URL), quote(entry.Description), quoteList(entry.Tags), entry.Date.Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = quote(item)
+	}
+
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
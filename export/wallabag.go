@@ -0,0 +1,183 @@
+package export
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"iter"
+	"net/url"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+)
+
+// WallabagOptions configures WriteWallabag.
+type WallabagOptions struct {
+	// The client used to download HTML snapshots. Required when
+	// IncludeSnapshots is set.
+	Client linkding.BookmarkClient
+	// Use the latest complete HTML snapshot of each bookmark as the content of
+	// its entry. Bookmarks without a snapshot fall back to their description.
+	IncludeSnapshots bool
+	// Bookmarks with this tag are starred in Wallabag. Nothing is starred
+	// when empty.
+	StarredTag string
+}
+
+// wallabagEntry is an entry in Wallabag's JSON import format, which is the
+// same format Wallabag exports.
+type wallabagEntry struct {
+	URL            string   `json:"url"`
+	Title          string   `json:"title"`
+	Content        string   `json:"content"`
+	IsArchived     int      `json:"is_archived"`
+	IsStarred      int      `json:"is_starred"`
+	Tags           []string `json:"tags"`
+	CreatedAt      string   `json:"created_at,omitempty"`
+	UpdatedAt      string   `json:"updated_at,omitempty"`
+	MimeType       string   `json:"mimetype"`
+	DomainName     string   `json:"domain_name,omitempty"`
+	PreviewPicture string   `json:"preview_picture,omitempty"`
+	Annotations    []any    `json:"annotations"`
+}
+
+// WriteWallabag writes the bookmarks to w as a JSON array in the format of
+// Wallabag's "wallabag v2" importer, and returns the number of bookmarks
+// written.
+//
+// Bookmarks that are archived or read in Linkding are archived in Wallabag.
+// Without snapshots, the content of an entry is the bookmark's description and
+// Wallabag fetches the page again when importing it.
+//
+// Writing stops at the first error yielded by the iterator or returned while
+// downloading a snapshot.
+func WriteWallabag(w io.Writer, bookmarks iter.Seq2[linkding.Bookmark, error], opts WallabagOptions) (int, error) {
+	if opts.IncludeSnapshots && opts.Client == nil {
+		return 0, errors.New("export: a client is required to include snapshots")
+	}
+
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+
+	fmt.Fprint(writer, "[\n")
+
+	count := 0
+	var err error
+	for bookmark, iterErr := range bookmarks {
+		if iterErr != nil {
+			err = iterErr
+			break
+		}
+
+		var entry wallabagEntry
+		if entry, err = newWallabagEntry(bookmark, opts); err != nil {
+			break
+		}
+
+		if count > 0 {
+			fmt.Fprint(writer, ",")
+		}
+		if err = encoder.Encode(entry); err != nil {
+			break
+		}
+		count++
+	}
+
+	fmt.Fprint(writer, "]\n")
+
+	if flushErr := writer.Flush(); err == nil {
+		err = flushErr
+	}
+
+	return count, err
+}
+
+func newWallabagEntry(bookmark linkding.Bookmark, opts WallabagOptions) (wallabagEntry, error) {
+	entry := wallabagEntry{
+		URL:            bookmark.URL,
+		Title:          bookmark.EffectiveTitle(),
+		Tags:           bookmark.TagNames,
+		CreatedAt:      formatDate(bookmark.DateAdded),
+		UpdatedAt:      formatDate(bookmark.DateModified),
+		MimeType:       "text/html",
+		PreviewPicture: bookmark.PreviewImageURL,
+		Annotations:    []any{},
+	}
+	if entry.Tags == nil {
+		entry.Tags = []string{}
+	}
+	if bookmark.IsArchived || !bookmark.Unread {
+		entry.IsArchived = 1
+	}
+	if opts.StarredTag != "" && containsTag(bookmark.TagNames, opts.StarredTag) {
+		entry.IsStarred = 1
+	}
+	if u, err := url.Parse(bookmark.URL); err == nil {
+		entry.DomainName = u.Hostname()
+	}
+
+	if opts.IncludeSnapshots {
+		content, err := latestSnapshot(opts.Client, bookmark.ID)
+		if err != nil {
+			return entry, fmt.Errorf("bookmark %d: %w", bookmark.ID, err)
+		}
+		entry.Content = content
+	}
+
+	if entry.Content == "" {
+		if description := cmp.Or(bookmark.Description, bookmark.WebsiteDescription); description != "" {
+			entry.Content = "<p>" + html.EscapeString(description) + "</p>"
+		}
+	}
+
+	return entry, nil
+}
+
+// latestSnapshot returns the content of the most recent complete HTML snapshot
+// of a bookmark, or an empty string if it has none.
+func latestSnapshot(c linkding.BookmarkClient, bookmarkID int) (string, error) {
+	var latest *linkding.BookmarkAsset
+	for asset, err := range linkding.AllBookmarkAssets(context.Background(), c, bookmarkID, linkding.ListBookmarkAssetsParams{
+		AssetType: linkding.AssetTypeSnapshot,
+		Status:    linkding.AssetStatusComplete,
+	}) {
+		if err != nil {
+			return "", err
+		}
+		if latest == nil || asset.DateCreated.After(latest.DateCreated) {
+			latest = &asset
+		}
+	}
+	if latest == nil {
+		return "", nil
+	}
+
+	body, err := c.DownloadBookmarkAsset(bookmarkID, latest.ID)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, name := range tags {
+		if strings.EqualFold(name, tag) {
+			return true
+		}
+	}
+
+	return false
+}
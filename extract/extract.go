@@ -0,0 +1,313 @@
+// Package extract reads the article text of bookmarked pages from their HTML
+// snapshots, for full-text search, summaries and reading time estimates.
+//
+// The article is found like browsers' reader modes do: navigation, sidebars,
+// comments and other clutter are removed, the paragraphs of the page are
+// scored by their length and punctuation, and the element holding the best
+// scoring paragraphs is taken as the article.
+package extract
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrNoSnapshot is returned when a bookmark has no complete HTML snapshot.
+var ErrNoSnapshot = errors.New("extract: bookmark has no snapshot")
+
+// Article is the text of a page.
+type Article struct {
+	Title string
+	// The text of the article, with paragraphs separated by blank lines.
+	Text string
+}
+
+// Words returns the number of words of the article text.
+func (a *Article) Words() int {
+	return len(strings.Fields(a.Text))
+}
+
+// Snapshot downloads the latest complete HTML snapshot of a bookmark and
+// extracts its article. It returns ErrNoSnapshot if the bookmark has none.
+func Snapshot(ctx context.Context, c linkding.BookmarkClient, bookmarkID int) (*Article, error) {
+	snapshot, err := LatestSnapshot(ctx, c, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.DownloadBookmarkAsset(bookmarkID, snapshot.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return Parse(body)
+}
+
+// LatestSnapshot returns the latest complete HTML snapshot asset of a
+// bookmark, or ErrNoSnapshot if it has none.
+func LatestSnapshot(ctx context.Context, c linkding.BookmarkClient, bookmarkID int) (*linkding.BookmarkAsset, error) {
+	var snapshot *linkding.BookmarkAsset
+	for asset, err := range linkding.AllBookmarkAssets(ctx, c, bookmarkID, linkding.ListBookmarkAssetsParams{
+		AssetType: linkding.AssetTypeSnapshot,
+		Status:    linkding.AssetStatusComplete,
+	}) {
+		if err != nil {
+			return nil, err
+		}
+		if snapshot == nil || asset.DateCreated.After(snapshot.DateCreated) {
+			snapshot = &asset
+		}
+	}
+	if snapshot == nil {
+		return nil, ErrNoSnapshot
+	}
+
+	return snapshot, nil
+}
+
+// Parse extracts the article of an HTML document. The document is expected to
+// be UTF-8, as snapshots are.
+func Parse(r io.Reader) (*Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	article := &Article{}
+	if title := find(doc, atom.Title); title != nil {
+		article.Title = strings.Join(strings.Fields(textContent(title)), " ")
+	}
+
+	body := find(doc, atom.Body)
+	if body == nil {
+		return article, nil
+	}
+
+	removeClutter(body)
+	root := bestCandidate(body)
+	article.Text = strings.Join(paragraphs(root), "\n\n")
+
+	return article, nil
+}
+
+var (
+	// unlikely matches the class and ID of elements that are not part of
+	// the article.
+	unlikely = regexp.MustCompile(`(?i)banner|breadcrumb|comment|community|cookie|disqus|footer|header|menu|modal|nav|popup|promo|related|remark|share|sidebar|social|sponsor|subscribe|widget|\bads?\b`)
+	// likely matches the class and ID of elements that are likely part of
+	// the article, overriding unlikely.
+	likely = regexp.MustCompile(`(?i)article|body|content|entry|main|post|story|text`)
+)
+
+// clutter are the elements that are never part of the article.
+var clutter = map[atom.Atom]bool{
+	atom.Aside:    true,
+	atom.Button:   true,
+	atom.Footer:   true,
+	atom.Form:     true,
+	atom.Header:   true,
+	atom.Iframe:   true,
+	atom.Nav:      true,
+	atom.Noscript: true,
+	atom.Script:   true,
+	atom.Select:   true,
+	atom.Style:    true,
+	atom.Svg:      true,
+	atom.Template: true,
+}
+
+func removeClutter(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.CommentNode || child.Type == html.ElementNode && isClutter(child) {
+			n.RemoveChild(child)
+		} else {
+			removeClutter(child)
+		}
+		child = next
+	}
+}
+
+func isClutter(n *html.Node) bool {
+	if clutter[n.DataAtom] || attr(n, "hidden") != "" || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+
+	names := attr(n, "class") + " " + attr(n, "id")
+	return unlikely.MatchString(names) && !likely.MatchString(names)
+}
+
+// bestCandidate returns the element holding the article: each paragraph adds
+// its score to its parent and half of it to its grandparent, and the element
+// with the highest score, discounted by the share of its text in links, wins.
+// The body is returned if no element scores.
+func bestCandidate(body *html.Node) *html.Node {
+	scores := map[*html.Node]float64{}
+	for n := range body.Descendants() {
+		if n.DataAtom != atom.P && n.DataAtom != atom.Pre && n.DataAtom != atom.Td && n.DataAtom != atom.Blockquote {
+			continue
+		}
+
+		text := strings.TrimSpace(textContent(n))
+		if len(text) < 25 {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+
+		if parent := n.Parent; parent != nil {
+			if _, ok := scores[parent]; !ok {
+				scores[parent] = baseScore(parent)
+			}
+			scores[parent] += score
+
+			if grandparent := parent.Parent; grandparent != nil {
+				if _, ok := scores[grandparent]; !ok {
+					scores[grandparent] = baseScore(grandparent)
+				}
+				scores[grandparent] += score / 2
+			}
+		}
+	}
+
+	best, bestScore := body, 0.0
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+
+	return best
+}
+
+func baseScore(n *html.Node) float64 {
+	score := 0.0
+	switch n.DataAtom {
+	case atom.Article, atom.Main:
+		score += 10
+	case atom.Div:
+		score += 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score += 3
+	case atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		score -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score -= 5
+	}
+
+	names := attr(n, "class") + " " + attr(n, "id")
+	if likely.MatchString(names) {
+		score += 25
+	}
+	if unlikely.MatchString(names) {
+		score -= 25
+	}
+
+	return score
+}
+
+// linkDensity returns the share of the text of n that is inside links.
+func linkDensity(n *html.Node) float64 {
+	length := len(textContent(n))
+	if length == 0 {
+		return 0
+	}
+
+	links := 0
+	for link := range n.Descendants() {
+		if link.DataAtom == atom.A {
+			links += len(textContent(link))
+		}
+	}
+
+	return float64(links) / float64(length)
+}
+
+// blocks are the elements that start a new paragraph.
+var blocks = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Blockquote: true, atom.Br: true,
+	atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Figcaption: true, atom.Figure: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Hr: true, atom.Li: true, atom.Main: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Tr: true, atom.Ul: true,
+}
+
+// paragraphs returns the text of n split at block elements, with whitespace
+// collapsed.
+func paragraphs(n *html.Node) []string {
+	var result []string
+	var current strings.Builder
+
+	flush := func() {
+		if text := strings.Join(strings.Fields(current.String()), " "); text != "" {
+			result = append(result, text)
+		}
+		current.Reset()
+	}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			current.WriteString(n.Data)
+		case n.Type == html.ElementNode && blocks[n.DataAtom]:
+			flush()
+			for child := range n.ChildNodes() {
+				walk(child)
+			}
+			flush()
+		default:
+			for child := range n.ChildNodes() {
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	flush()
+
+	return result
+}
+
+func textContent(n *html.Node) string {
+	var text strings.Builder
+	for d := range n.Descendants() {
+		if d.Type == html.TextNode {
+			text.WriteString(d.Data)
+		}
+	}
+
+	return text.String()
+}
+
+func find(n *html.Node, a atom.Atom) *html.Node {
+	for d := range n.Descendants() {
+		if d.Type == html.ElementNode && d.DataAtom == a {
+			return d
+		}
+	}
+
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,255 @@
+// Package favicon downloads the favicons of bookmarks into a cache on disk, so
+// terminal and desktop frontends can show icons without downloading them from
+// the server again each time.
+//
+// Linkding stores one favicon per host, so favicons are cached by the host of
+// the bookmarked URL: bookmarks of the same site share a single download, even
+// when they are requested concurrently.
+package favicon
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// DefaultTTL is how long downloaded favicons are used before they are
+// downloaded again, when no TTL is given.
+const DefaultTTL = 7 * 24 * time.Hour
+
+// DefaultConcurrency is the number of concurrent downloads of Prefetch when no
+// concurrency is given.
+const DefaultConcurrency = 4
+
+// maxSize is the size above which a favicon is considered broken.
+const maxSize = 1 << 20
+
+// ErrNoFavicon is returned for bookmarks without a favicon, either because the
+// server has favicons disabled or because it did not load the favicon yet.
+var ErrNoFavicon = errors.New("favicon: bookmark has no favicon")
+
+// Options configures a Cache.
+type Options struct {
+	// The directory the favicons are stored in. Defaults to
+	// "linkding/favicons" in the user cache directory.
+	Dir string
+	// How long downloaded favicons are used. Defaults to DefaultTTL.
+	TTL time.Duration
+	// The client used to download favicons. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Cache downloads favicons into a directory. It is safe for concurrent use.
+type Cache struct {
+	dir    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu       sync.Mutex
+	inflight map[string]*download
+	// The hosts whose favicon failed to download, which are not retried for
+	// the lifetime of the cache.
+	failed map[string]error
+}
+
+type download struct {
+	done chan struct{}
+	path string
+	err  error
+}
+
+// New creates a cache, creating its directory if needed.
+func New(opts Options) (*Cache, error) {
+	dir := opts.Dir
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(base, "linkding", "favicons")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		dir:      dir,
+		ttl:      cmp.Or(opts.TTL, DefaultTTL),
+		client:   cmp.Or(opts.HTTPClient, http.DefaultClient),
+		inflight: map[string]*download{},
+		failed:   map[string]error{},
+	}, nil
+}
+
+// Dir returns the directory the favicons are stored in.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Get returns the path of the favicon of a bookmark, downloading it unless a
+// favicon of the same host was downloaded within the TTL. It returns
+// ErrNoFavicon if the bookmark has no favicon.
+func (c *Cache) Get(ctx context.Context, bookmark linkding.Bookmark) (string, error) {
+	if bookmark.FaviconURL == "" {
+		return "", ErrNoFavicon
+	}
+	u, err := url.Parse(bookmark.URL)
+	if err != nil || u.Hostname() == "" {
+		return "", ErrNoFavicon
+	}
+
+	host := strings.ToLower(u.Hostname())
+	name := filepath.Join(c.dir, fileName(host)+extension(bookmark.FaviconURL))
+	if info, err := os.Stat(name); err == nil && time.Since(info.ModTime()) < c.ttl {
+		return name, nil
+	}
+
+	c.mu.Lock()
+	if err := c.failed[host]; err != nil {
+		c.mu.Unlock()
+		return "", err
+	}
+	if d := c.inflight[host]; d != nil {
+		c.mu.Unlock()
+		select {
+		case <-d.done:
+			return d.path, d.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	d := &download{done: make(chan struct{})}
+	c.inflight[host] = d
+	c.mu.Unlock()
+
+	d.err = c.download(ctx, bookmark.FaviconURL, name)
+	if d.err == nil {
+		d.path = name
+	}
+
+	c.mu.Lock()
+	delete(c.inflight, host)
+	// Cancellations are not failures of the favicon.
+	if d.err != nil && ctx.Err() == nil {
+		c.failed[host] = d.err
+	}
+	c.mu.Unlock()
+	close(d.done)
+
+	return d.path, d.err
+}
+
+// Prefetch downloads the favicons of bookmarks using at most concurrency
+// concurrent downloads, e.g. before showing a list, and returns the path of
+// the favicon of each bookmark by ID. Bookmarks without a favicon and
+// favicons that fail to download are left out.
+func (c *Cache) Prefetch(ctx context.Context, bookmarks []linkding.Bookmark, concurrency int) map[int]string {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	paths := make(map[int]string, len(bookmarks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, bookmark := range bookmarks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if name, err := c.Get(ctx, bookmark); err == nil {
+				mu.Lock()
+				paths[bookmark.ID] = name
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return paths
+}
+
+// download stores the favicon at source in name, replacing the file
+// atomically so readers never see a partial favicon.
+func (c *Cache) download(ctx context.Context, source, name string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("favicon: unexpected status %s", res.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxSize {
+		return fmt.Errorf("favicon: %s is larger than %d bytes", source, maxSize)
+	}
+
+	file, err := os.CreateTemp(c.dir, ".favicon-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), name)
+}
+
+// extension returns the file extension of the favicon at source, ignoring its
+// query, or ".png" if it has none.
+func extension(source string) string {
+	if u, err := url.Parse(source); err == nil {
+		source = u.Path
+	}
+
+	return cmp.Or(path.Ext(source), ".png")
+}
+
+// fileName turns a host into a file name, replacing everything but letters,
+// digits, dots and dashes.
+func fileName(host string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' {
+			return r
+		}
+		return '_'
+	}, host)
+}
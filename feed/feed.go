@@ -0,0 +1,288 @@
+// Package feed renders bookmarks as RSS 2.0 or Atom feeds, so shared links can
+// be published without exposing the Linkding token to feed readers.
+//
+// The feeds can be written once, e.g. to a static site, or served by Handler,
+// which queries the server on each request.
+package feed
+
+import (
+	"cmp"
+	"encoding/xml"
+	"io"
+	"iter"
+	"net/http"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// DefaultLimit is the number of entries of a feed when not configured.
+const DefaultLimit = 50
+
+// Options configures a feed.
+type Options struct {
+	// The title of the feed. Defaults to "Bookmarks".
+	Title string
+	// The URL of the website the feed belongs to, e.g. the shared bookmarks
+	// page of the Linkding instance.
+	Link        string
+	Description string
+	// The author of the feed, required by Atom. Defaults to the title.
+	Author string
+	// The URL the feed is served at, used as the ID of Atom feeds. Defaults
+	// to Link.
+	ID string
+	// The number of entries at most. Defaults to DefaultLimit.
+	Limit int
+	// Only include shared bookmarks, which is recommended for feeds that are
+	// published.
+	SharedOnly bool
+}
+
+// Format is the format of a feed.
+type Format string
+
+const (
+	RSS  Format = "rss"
+	Atom Format = "atom"
+)
+
+// Write writes the bookmarks as a feed of the given format and returns the
+// number of entries written. Bookmarks are expected newest first, as listed
+// by default. Nothing is written if the iterator yields an error.
+func Write(w io.Writer, format Format, bookmarks iter.Seq2[linkding.Bookmark, error], opts Options) (int, error) {
+	entries, err := collect(bookmarks, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	var doc any
+	if format == Atom {
+		doc = newAtomFeed(entries, opts)
+	} else {
+		doc = newRSSFeed(entries, opts)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return 0, err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return 0, err
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return 0, err
+	}
+
+	return len(entries), nil
+}
+
+// WriteRSS writes the bookmarks as an RSS 2.0 feed. See Write.
+func WriteRSS(w io.Writer, bookmarks iter.Seq2[linkding.Bookmark, error], opts Options) (int, error) {
+	return Write(w, RSS, bookmarks, opts)
+}
+
+// WriteAtom writes the bookmarks as an Atom feed. See Write.
+func WriteAtom(w io.Writer, bookmarks iter.Seq2[linkding.Bookmark, error], opts Options) (int, error) {
+	return Write(w, Atom, bookmarks, opts)
+}
+
+// Handler returns a handler serving the active bookmarks matching params,
+// e.g. a tag query such as "#blog", as a feed of the given format. Each
+// request lists the bookmarks from the server.
+func Handler(c linkding.BookmarkClient, params linkding.ListBookmarksParams, format Format, opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bookmarks := linkding.AllBookmarks(r.Context(), c, params)
+
+		// The feed is rendered before anything is written, so a failure can
+		// still be reported with an error status.
+		entries, err := collect(bookmarks, opts)
+		if err != nil {
+			http.Error(w, "failed to list bookmarks", http.StatusBadGateway)
+			return
+		}
+
+		if format == Atom {
+			w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		}
+		Write(w, format, entriesSeq(entries), opts)
+	})
+}
+
+// collect reads the bookmarks of a feed, stopping once the limit is reached so
+// that no further pages are requested.
+func collect(bookmarks iter.Seq2[linkding.Bookmark, error], opts Options) ([]linkding.Bookmark, error) {
+	limit := cmp.Or(opts.Limit, DefaultLimit)
+
+	entries := []linkding.Bookmark{}
+	for bookmark, err := range bookmarks {
+		if err != nil {
+			return nil, err
+		}
+		if opts.SharedOnly && !bookmark.Shared {
+			continue
+		}
+
+		entries = append(entries, bookmark)
+		if len(entries) >= limit {
+			break
+		}
+	}
+
+	return entries, nil
+}
+
+func entriesSeq(entries []linkding.Bookmark) iter.Seq2[linkding.Bookmark, error] {
+	return func(yield func(linkding.Bookmark, error) bool) {
+		for _, entry := range entries {
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description,omitempty"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func newRSSFeed(entries []linkding.Bookmark, opts Options) rssFeed {
+	channel := rssChannel{
+		Title:       cmp.Or(opts.Title, "Bookmarks"),
+		Link:        opts.Link,
+		Description: cmp.Or(opts.Description, opts.Title, "Bookmarks"),
+		Items:       make([]rssItem, len(entries)),
+	}
+	if updated := latest(entries); !updated.IsZero() {
+		channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+
+	for i, bookmark := range entries {
+		item := rssItem{
+			Title:       bookmark.EffectiveTitle(),
+			Link:        bookmark.URL,
+			Description: cmp.Or(bookmark.Description, bookmark.WebsiteDescription),
+			GUID:        rssGUID{IsPermaLink: true, Value: bookmark.URL},
+			Categories:  bookmark.TagNames,
+		}
+		if !bookmark.DateAdded.IsZero() {
+			item.PubDate = bookmark.DateAdded.Format(time.RFC1123Z)
+		}
+		channel.Items[i] = item
+	}
+
+	return rssFeed{Version: "2.0", Channel: channel}
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+func newAtomFeed(entries []linkding.Bookmark, opts Options) atomFeed {
+	feedTitle := cmp.Or(opts.Title, "Bookmarks")
+	updated := latest(entries)
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+
+	feed := atomFeed{
+		Title:   feedTitle,
+		ID:      cmp.Or(opts.ID, opts.Link),
+		Updated: updated.Format(time.RFC3339),
+		Author:  atomAuthor{Name: cmp.Or(opts.Author, feedTitle)},
+		Entries: make([]atomEntry, len(entries)),
+	}
+	if opts.Link != "" {
+		feed.Links = append(feed.Links, atomLink{Href: opts.Link})
+	}
+	if opts.ID != "" {
+		feed.Links = append(feed.Links, atomLink{Href: opts.ID, Rel: "self"})
+	}
+
+	for i, bookmark := range entries {
+		entry := atomEntry{
+			Title:   bookmark.EffectiveTitle(),
+			ID:      bookmark.URL,
+			Links:   []atomLink{{Href: bookmark.URL}},
+			Updated: cmp.Or(bookmark.DateModified, bookmark.DateAdded, updated).Format(time.RFC3339),
+			Summary: cmp.Or(bookmark.Description, bookmark.WebsiteDescription),
+		}
+		if !bookmark.DateAdded.IsZero() {
+			entry.Published = bookmark.DateAdded.Format(time.RFC3339)
+		}
+		for _, tag := range bookmark.TagNames {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries[i] = entry
+	}
+
+	return feed
+}
+
+// latest returns the latest modification date of the bookmarks.
+func latest(bookmarks []linkding.Bookmark) time.Time {
+	var t time.Time
+	for _, bookmark := range bookmarks {
+		if modified := cmp.Or(bookmark.DateModified, bookmark.DateAdded); modified.After(t) {
+			t = modified
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,284 @@
+// Package fulltext keeps a local full-text index of bookmarks, for searching
+// the content of bookmarked pages, which the Linkding API does not offer.
+//
+// The index is backed by Bleve and holds the title, description, notes and
+// tags of each bookmark along with the article text of its latest HTML
+// snapshot, see the extract package. It is built once with Build and kept up
+// to date with the events of a watcher, see Follow.
+package fulltext
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/simple"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/extract"
+	"github.com/larcher/go-linkding/watch"
+)
+
+// DefaultLimit is the number of hits returned by Search when not configured.
+const DefaultLimit = 20
+
+// Index is a full-text index of bookmarks. It is safe for concurrent use.
+type Index struct {
+	index bleve.Index
+}
+
+// document is a bookmark as stored in the index.
+type document struct {
+	URL         string   `json:"url"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Notes       string   `json:"notes"`
+	Tags        []string `json:"tags"`
+	Content     string   `json:"content"`
+	Archived    bool     `json:"archived"`
+	Unread      bool     `json:"unread"`
+}
+
+// Open opens the index at path, creating it if it does not exist.
+func Open(path string) (*Index, error) {
+	index, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		index, err = bleve.New(path, newMapping())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Index{index: index}, nil
+}
+
+// NewMemory returns an index that is only kept in memory.
+func NewMemory() (*Index, error) {
+	index, err := bleve.NewMemOnly(newMapping())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Index{index: index}, nil
+}
+
+func newMapping() mapping.IndexMapping {
+	text := bleve.NewTextFieldMapping()
+	stored := bleve.NewTextFieldMapping()
+	stored.Store = true
+	stored.IncludeTermVectors = true
+
+	keyword := bleve.NewKeywordFieldMapping()
+	flag := bleve.NewBooleanFieldMapping()
+
+	url := bleve.NewTextFieldMapping()
+	url.Store = true
+	url.Analyzer = simple.Name
+
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("url", url)
+	doc.AddFieldMappingsAt("title", stored)
+	doc.AddFieldMappingsAt("description", text)
+	doc.AddFieldMappingsAt("notes", text)
+	doc.AddFieldMappingsAt("tags", keyword)
+	doc.AddFieldMappingsAt("content", stored)
+	doc.AddFieldMappingsAt("archived", flag)
+	doc.AddFieldMappingsAt("unread", flag)
+
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	return m
+}
+
+// Close closes the index.
+func (i *Index) Close() error {
+	return i.index.Close()
+}
+
+// Count returns the number of bookmarks in the index.
+func (i *Index) Count() (int, error) {
+	count, err := i.index.DocCount()
+	return int(count), err
+}
+
+// Add adds a bookmark to the index, or replaces it, along with the text of
+// the page, which may be empty.
+func (i *Index) Add(bookmark linkding.Bookmark, text string) error {
+	return i.index.Index(strconv.Itoa(bookmark.ID), newDocument(bookmark, text))
+}
+
+// Delete removes a bookmark from the index.
+func (i *Index) Delete(id int) error {
+	return i.index.Delete(strconv.Itoa(id))
+}
+
+// SearchOptions configures a search.
+type SearchOptions struct {
+	// The number of hits at most. Defaults to DefaultLimit.
+	Limit int
+	// The number of hits skipped, for paging.
+	Offset int
+}
+
+// Hit is a bookmark matching a search.
+type Hit struct {
+	ID    int
+	URL   string
+	Title string
+	Score float64
+	// Excerpts of the title and page text containing matches, highlighted
+	// with mark elements.
+	Fragments []string
+}
+
+// Search returns the bookmarks matching a query, best matches first, and the
+// total number of matches. The query uses Bleve's query string syntax: words
+// match any field, "+word" and "-word" require or exclude a word, "tags:go"
+// matches a tag and "+archived:false" leaves out archived bookmarks.
+func (i *Index) Search(query string, opts SearchOptions) ([]Hit, int, error) {
+	req := bleve.NewSearchRequestOptions(bleve.NewQueryStringQuery(query), cmp.Or(opts.Limit, DefaultLimit), opts.Offset, false)
+	req.Fields = []string{"url", "title"}
+	req.Highlight = bleve.NewHighlightWithStyle("html")
+	req.Highlight.Fields = []string{"title", "content"}
+
+	result, err := i.index.Search(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]Hit, 0, len(result.Hits))
+	for _, match := range result.Hits {
+		id, err := strconv.Atoi(match.ID)
+		if err != nil {
+			continue
+		}
+
+		hit := Hit{ID: id, Score: match.Score}
+		hit.URL, _ = match.Fields["url"].(string)
+		hit.Title, _ = match.Fields["title"].(string)
+		for _, field := range req.Highlight.Fields {
+			for _, fragment := range match.Fragments[field] {
+				if strings.Contains(fragment, "<mark>") {
+					hit.Fragments = append(hit.Fragments, fragment)
+				}
+			}
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, int(result.Total), nil
+}
+
+// BuildOptions configures Build.
+type BuildOptions struct {
+	// Do not download snapshots, indexing only the fields of the bookmarks.
+	SkipSnapshots bool
+	// Called after each bookmark is indexed, if set.
+	Progress func(done int)
+}
+
+// Build indexes every bookmark of the account, active and archived, and
+// returns the number indexed. Bookmarks whose snapshot cannot be read are
+// indexed without page text.
+func (i *Index) Build(ctx context.Context, c linkding.BookmarkClient, opts BuildOptions) (int, error) {
+	done := 0
+	batch := i.index.NewBatch()
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return done, err
+		}
+
+		text := ""
+		if !opts.SkipSnapshots {
+			text, err = snapshotText(ctx, c, bookmark.ID)
+			if err != nil && ctx.Err() != nil {
+				return done, ctx.Err()
+			}
+		}
+
+		if err := batch.Index(strconv.Itoa(bookmark.ID), newDocument(bookmark, text)); err != nil {
+			return done, err
+		}
+		done++
+		if opts.Progress != nil {
+			opts.Progress(done)
+		}
+
+		if batch.Size() >= 100 {
+			if err := i.index.Batch(batch); err != nil {
+				return done, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := i.index.Batch(batch); err != nil {
+		return done, err
+	}
+
+	return done, nil
+}
+
+// Apply updates the index with a change reported by a watcher. The snapshot
+// of created and updated bookmarks is downloaded again, as a changed bookmark
+// may have a new one.
+func (i *Index) Apply(ctx context.Context, c linkding.BookmarkClient, event watch.Event) error {
+	switch event.Type {
+	case watch.BookmarkCreated, watch.BookmarkUpdated:
+		text, err := snapshotText(ctx, c, event.Bookmark.ID)
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return i.Add(event.Bookmark, text)
+	case watch.BookmarkDeleted:
+		return i.Delete(event.Bookmark.ID)
+	case watch.Error:
+		return event.Err
+	}
+
+	return nil
+}
+
+// Follow runs the watcher and applies its changes to the index until ctx is
+// done. Failed polls and updates are passed to onError, if set, and do not
+// stop following. The watcher should use a cursor or start from a freshly
+// built index, as changes made before its first poll are not reported
+// otherwise.
+func (i *Index) Follow(ctx context.Context, c linkding.BookmarkClient, w *watch.Watcher, onError func(error)) error {
+	for event := range w.Run(ctx) {
+		if err := i.Apply(ctx, c, event); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+	}
+
+	return ctx.Err()
+}
+
+func newDocument(bookmark linkding.Bookmark, text string) document {
+	return document{
+		URL:         bookmark.URL,
+		Title:       cmp.Or(bookmark.Title, bookmark.WebsiteTitle),
+		Description: cmp.Or(bookmark.Description, bookmark.WebsiteDescription),
+		Notes:       bookmark.Notes,
+		Tags:        bookmark.TagNames,
+		Content:     text,
+		Archived:    bookmark.IsArchived,
+		Unread:      bookmark.Unread,
+	}
+}
+
+// snapshotText returns the article text of the latest snapshot of a bookmark,
+// or an empty string if it has none.
+func snapshotText(ctx context.Context, c linkding.BookmarkClient, id int) (string, error) {
+	article, err := extract.Snapshot(ctx, c, id)
+	if errors.Is(err, extract.ErrNoSnapshot) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return article.Text, nil
+}
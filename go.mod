@@ -1,3 +1,11 @@
 module github.com/larcher/go-linkding
 
 go 1.23.0
+
+require (
+	go.etcd.io/bbolt v1.4.3
+	golang.org/x/net v0.43.0
+	golang.org/x/text v0.28.0
+)
+
+require golang.org/x/sys v0.35.0 // indirect
@@ -0,0 +1,209 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field identifies a record field a CSV column can be mapped to.
+type Field string
+
+const (
+	FieldURL         Field = "url"
+	FieldTitle       Field = "title"
+	FieldDescription Field = "description"
+	FieldNotes       Field = "notes"
+	FieldTags        Field = "tags"
+	FieldUnread      Field = "unread"
+	FieldShared      Field = "shared"
+	FieldArchived    Field = "archived"
+	FieldDateAdded   Field = "date_added"
+)
+
+// DefaultCSVMapping maps the column names written by the export package, and
+// a few common alternatives, to record fields.
+var DefaultCSVMapping = map[string]Field{
+	"url":         FieldURL,
+	"title":       FieldTitle,
+	"description": FieldDescription,
+	"notes":       FieldNotes,
+	"tags":        FieldTags,
+	"tag_names":   FieldTags,
+	"unread":      FieldUnread,
+	"shared":      FieldShared,
+	"is_archived": FieldArchived,
+	"archived":    FieldArchived,
+	"date_added":  FieldDateAdded,
+}
+
+// CSVOptions configures ReadCSV.
+type CSVOptions struct {
+	// Maps column names, compared case-insensitively, to record fields.
+	// Columns that are not mapped are ignored. Defaults to DefaultCSVMapping.
+	Mapping map[string]Field
+	// The characters separating tag names. Defaults to splitting on commas
+	// and whitespace.
+	TagSeparators string
+	// The field delimiter. Defaults to a comma.
+	Comma rune
+}
+
+// ReadCSV reads records from a CSV file whose first row names the columns.
+// A column must be mapped to the URL field.
+//
+// Boolean columns accept the values understood by strconv.ParseBool as well as
+// "yes" and "no". Dates can be given in RFC 3339 format, as "2006-01-02", or
+// as Unix timestamps in seconds.
+func ReadCSV(r io.Reader, opts CSVOptions) ([]Record, error) {
+	mapping := opts.Mapping
+	if mapping == nil {
+		mapping = DefaultCSVMapping
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return []Record{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]Field, len(header))
+	hasURL := false
+	for i, name := range header {
+		name = normalizeHeader(name)
+		for column, field := range mapping {
+			if strings.EqualFold(column, name) {
+				fields[i] = field
+			}
+		}
+		hasURL = hasURL || fields[i] == FieldURL
+	}
+	if !hasURL {
+		return nil, errors.New("importer: no column is mapped to the url field")
+	}
+
+	records := []Record{}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record := Record{}
+		for i, value := range row {
+			if i >= len(fields) || fields[i] == "" {
+				continue
+			}
+
+			if err := setField(&record, fields[i], value, opts.TagSeparators); err != nil {
+				line, _ := reader.FieldPos(i)
+				return nil, fmt.Errorf("importer: line %d, column %q: %w", line, header[i], err)
+			}
+		}
+
+		if record.URL != "" {
+			records = append(records, record)
+		}
+	}
+}
+
+func setField(record *Record, field Field, value, tagSeparators string) error {
+	value = strings.TrimSpace(value)
+
+	var err error
+	switch field {
+	case FieldURL:
+		record.URL = value
+	case FieldTitle:
+		record.Title = value
+	case FieldDescription:
+		record.Description = value
+	case FieldNotes:
+		record.Notes = value
+	case FieldTags:
+		record.Tags = splitTags(value, tagSeparators)
+	case FieldUnread:
+		record.Unread, err = parseBool(value)
+	case FieldShared:
+		record.Shared, err = parseBool(value)
+	case FieldArchived:
+		record.Archived, err = parseBool(value)
+	case FieldDateAdded:
+		record.DateAdded, err = parseDate(value)
+	default:
+		err = fmt.Errorf("unknown field %q", field)
+	}
+
+	return err
+}
+
+// normalizeHeader lowercases a column name, removing surrounding whitespace
+// and the byte order mark some tools write at the start of a file.
+func normalizeHeader(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+}
+
+// splitTags splits a list of tags on any of the separators, or on commas and
+// whitespace if no separators are given.
+func splitTags(value, separators string) []string {
+	tags := strings.FieldsFunc(value, func(r rune) bool {
+		if separators == "" {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n'
+		}
+
+		return strings.ContainsRune(separators, r)
+	})
+
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return false, nil
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+
+	return strconv.ParseBool(value)
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.DateOnly, value)
+}
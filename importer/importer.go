@@ -0,0 +1,302 @@
+// Package importer creates Linkding bookmarks from files exported by other
+// tools.
+//
+// Readers turn a source format into records, which Import then creates in
+// Linkding, deduplicating them against the bookmarks that already exist.
+package importer
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// Record is a bookmark read from an import source.
+type Record struct {
+	URL         string
+	Title       string
+	Description string
+	Notes       string
+	Tags        []string
+	Unread      bool
+	Shared      bool
+	Archived    bool
+	// When the bookmark was originally added. The Linkding API does not allow
+	// setting the date of a bookmark, so this is informational only.
+	DateAdded time.Time
+	// Files uploaded as assets of the bookmark when it is created.
+	Assets []Asset
+}
+
+// Asset is a file belonging to a record, such as an archived copy of the page.
+type Asset struct {
+	// The file name shown in Linkding.
+	Name string
+	// Opens the content of the file. It is only called when the asset is
+	// uploaded.
+	Open func() (io.ReadCloser, error)
+}
+
+// DuplicatePolicy decides what happens to records whose URL is already
+// bookmarked.
+type DuplicatePolicy int
+
+const (
+	// SkipDuplicates leaves existing bookmarks untouched.
+	SkipDuplicates DuplicatePolicy = iota
+	// UpdateDuplicates replaces the fields of existing bookmarks with the
+	// fields of the record.
+	UpdateDuplicates
+	// MergeDuplicates adds the tags of the record to existing bookmarks and
+	// fills their empty fields, keeping everything else.
+	MergeDuplicates
+)
+
+// Action is what Import did, or would do in a dry run, with a record.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionSkip   Action = "skip"
+)
+
+// Options configures Import.
+type Options struct {
+	// Report what would be done without changing anything.
+	DryRun bool
+	// What to do with records whose URL is already bookmarked, including
+	// records repeating the URL of an earlier record.
+	Duplicates DuplicatePolicy
+	// Called after each record has been handled.
+	Progress func(Progress)
+}
+
+// Progress reports how far an import has come.
+type Progress struct {
+	// The number of records handled so far.
+	Done int
+	// The total number of records.
+	Total int
+	// The outcome of the record just handled.
+	Item ItemResult
+}
+
+// ItemResult is the outcome of importing a single record.
+type ItemResult struct {
+	// The position of the record in the input.
+	Index  int
+	Record Record
+	Action Action
+	// The bookmark as stored by the server. It is nil in a dry run and when
+	// the record was skipped or could not be stored.
+	Bookmark *linkding.Bookmark
+	// The assets uploaded for the record.
+	Assets []linkding.BookmarkAsset
+	// The reason the record failed, nil if it succeeded. A record also fails
+	// if its bookmark was stored but one of its assets could not be uploaded.
+	Err error
+}
+
+// Report is the outcome of an import.
+type Report struct {
+	Items   []ItemResult
+	Created int
+	Updated int
+	Skipped int
+	Failed  int
+}
+
+// Import creates a bookmark for each record, in order. Records are matched to
+// existing bookmarks, active or archived, by their exact URL and handled
+// according to the duplicate policy. The assets of a record are only uploaded
+// when its bookmark is created, so importing the same records again does not
+// duplicate them.
+//
+// Failing records are reported in the returned report. An error is only
+// returned if the existing bookmarks could not be listed or ctx is done, in
+// which case the report covers the records handled so far.
+func Import(ctx context.Context, c linkding.BookmarkClient, records []Record, opts Options) (*Report, error) {
+	existing, err := existingBookmarks(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Items: make([]ItemResult, 0, len(records))}
+	for i, record := range records {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		item := importRecord(c, existing, record, opts)
+		item.Index = i
+		report.add(item)
+
+		if opts.Progress != nil {
+			opts.Progress(Progress{Done: i + 1, Total: len(records), Item: item})
+		}
+	}
+
+	return report, nil
+}
+
+func (r *Report) add(item ItemResult) {
+	r.Items = append(r.Items, item)
+
+	switch {
+	case item.Err != nil:
+		r.Failed++
+	case item.Action == ActionCreate:
+		r.Created++
+	case item.Action == ActionUpdate:
+		r.Updated++
+	default:
+		r.Skipped++
+	}
+}
+
+func importRecord(c linkding.BookmarkClient, existing map[string]linkding.Bookmark, record Record, opts Options) ItemResult {
+	item := ItemResult{Record: record}
+
+	current, found := existing[record.URL]
+	switch {
+	case !found:
+		item.Action = ActionCreate
+	case opts.Duplicates == SkipDuplicates:
+		item.Action = ActionSkip
+		return item
+	default:
+		item.Action = ActionUpdate
+	}
+
+	payload := createRequest(record)
+	if found && opts.Duplicates == MergeDuplicates {
+		payload = mergeRequest(current, record)
+	}
+
+	if opts.DryRun {
+		existing[record.URL] = bookmarkFromRequest(current.ID, payload)
+		return item
+	}
+
+	var bookmark *linkding.Bookmark
+	var err error
+	if found {
+		bookmark, err = c.UpdateBookmark(current.ID, payload)
+	} else {
+		bookmark, err = c.CreateBookmark(payload)
+	}
+	if err != nil {
+		item.Err = err
+		return item
+	}
+
+	existing[record.URL] = *bookmark
+	item.Bookmark = bookmark
+
+	if !found {
+		item.Assets, item.Err = uploadAssets(c, bookmark.ID, record.Assets)
+	}
+
+	return item
+}
+
+func uploadAssets(c linkding.BookmarkClient, bookmarkID int, assets []Asset) ([]linkding.BookmarkAsset, error) {
+	uploaded := make([]linkding.BookmarkAsset, 0, len(assets))
+
+	for _, asset := range assets {
+		content, err := asset.Open()
+		if err != nil {
+			return uploaded, fmt.Errorf("asset %q: %w", asset.Name, err)
+		}
+
+		stored, err := c.UploadBookmarkAsset(bookmarkID, asset.Name, content)
+		content.Close()
+		if err != nil {
+			return uploaded, fmt.Errorf("asset %q: %w", asset.Name, err)
+		}
+
+		uploaded = append(uploaded, *stored)
+	}
+
+	return uploaded, nil
+}
+
+func existingBookmarks(ctx context.Context, c linkding.BookmarkClient) (map[string]linkding.Bookmark, error) {
+	existing := map[string]linkding.Bookmark{}
+
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return nil, err
+		}
+		existing[bookmark.URL] = bookmark
+	}
+
+	return existing, nil
+}
+
+func createRequest(record Record) linkding.CreateBookmarkRequest {
+	tags := splitWords(record.Tags)
+
+	return linkding.CreateBookmarkRequest{
+		URL:         record.URL,
+		Title:       record.Title,
+		Description: record.Description,
+		Notes:       record.Notes,
+		IsArchived:  record.Archived,
+		Unread:      record.Unread,
+		Shared:      record.Shared,
+		TagNames:    tags,
+	}
+}
+
+// mergeRequest keeps the fields of an existing bookmark, filling the empty
+// ones from the record and adding the tags of the record.
+func mergeRequest(bookmark linkding.Bookmark, record Record) linkding.CreateBookmarkRequest {
+	payload := linkding.CreateBookmarkRequest{
+		URL:         bookmark.URL,
+		Title:       cmp.Or(bookmark.Title, record.Title),
+		Description: cmp.Or(bookmark.Description, record.Description),
+		Notes:       cmp.Or(bookmark.Notes, record.Notes),
+		IsArchived:  bookmark.IsArchived,
+		Unread:      bookmark.Unread,
+		Shared:      bookmark.Shared,
+		TagNames:    appendTags(slices.Clone(bookmark.TagNames), splitWords(record.Tags)),
+	}
+	if payload.TagNames == nil {
+		payload.TagNames = []string{}
+	}
+
+	return payload
+}
+
+// splitWords splits tags of several words into a tag per word, as the server
+// would, since requests with such tags are rejected as invalid.
+func splitWords(tags []string) []string {
+	words := []string{}
+	for _, tag := range tags {
+		words = appendTags(words, strings.Fields(tag))
+	}
+
+	return words
+}
+
+func bookmarkFromRequest(id int, payload linkding.CreateBookmarkRequest) linkding.Bookmark {
+	return linkding.Bookmark{
+		ID:          id,
+		URL:         payload.URL,
+		Title:       payload.Title,
+		Description: payload.Description,
+		Notes:       payload.Notes,
+		IsArchived:  payload.IsArchived,
+		Unread:      payload.Unread,
+		Shared:      payload.Shared,
+		TagNames:    payload.TagNames,
+	}
+}
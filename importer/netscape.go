@@ -0,0 +1,209 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// NetscapeOptions configures ReadNetscapeHTML.
+type NetscapeOptions struct {
+	// Maps the folder path of a bookmark, outermost folder first, to the tags
+	// added to it. Defaults to DefaultFolderTags.
+	FolderTags func(folders []string) []string
+}
+
+// DefaultFolderTags turns every folder of the path into a tag, replacing
+// whitespace with dashes as Linkding tags cannot contain spaces.
+func DefaultFolderTags(folders []string) []string {
+	tags := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		if tag := strings.Join(strings.Fields(folder), "-"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// ReadNetscapeHTML reads records from a file in the Netscape bookmark file
+// format, as exported by browsers and most bookmark managers.
+//
+// Each bookmark is tagged with the tags of its TAGS attribute and the tags
+// derived from the folders it is nested in. The ADD_DATE attribute is read
+// into DateAdded, TOREAD into Unread, and bookmarks are shared unless marked
+// PRIVATE. Notes written by Linkding's exporter are split from the
+// description.
+func ReadNetscapeHTML(r io.Reader, opts NetscapeOptions) ([]Record, error) {
+	folderTags := opts.FolderTags
+	if folderTags == nil {
+		folderTags = DefaultFolderTags
+	}
+
+	tokenizer := html.NewTokenizer(r)
+	records := []Record{}
+
+	// The stack holds the folder of every open list, with the outermost list
+	// of the document having no folder.
+	folders := []string{}
+	depth := 0
+	pendingFolder := ""
+
+	var current *Record
+	var text *strings.Builder
+	inDescription := false
+
+	finishDescription := func() {
+		if inDescription && current != nil {
+			setDescription(current, text.String())
+		}
+		inDescription = false
+	}
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			finishDescription()
+			if current != nil {
+				records = append(records, *current)
+			}
+
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			return records, nil
+		case html.TextToken:
+			if text != nil {
+				text.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+
+			if tag != "dd" && tag != "br" && tag != "p" {
+				finishDescription()
+			}
+
+			switch {
+			case tokenType == html.StartTagToken && tag == "dl":
+				if depth > 0 {
+					folders = append(folders, pendingFolder)
+				}
+				depth++
+				pendingFolder = ""
+			case tokenType == html.EndTagToken && tag == "dl":
+				if depth > 1 {
+					folders = folders[:len(folders)-1]
+				}
+				depth = max(depth-1, 0)
+			case tokenType == html.StartTagToken && tag == "h3":
+				// A description following the folder describes the folder,
+				// not the bookmark before it.
+				if current != nil {
+					records = append(records, *current)
+					current = nil
+				}
+				text = &strings.Builder{}
+			case tokenType == html.EndTagToken && tag == "h3":
+				if text != nil {
+					pendingFolder = strings.TrimSpace(text.String())
+				}
+				text = nil
+			case tokenType == html.StartTagToken && tag == "a":
+				if current != nil {
+					records = append(records, *current)
+				}
+
+				current = readAnchor(tokenizer)
+				current.Tags = appendTags(current.Tags, folderTags(nonEmpty(folders)))
+				text = &strings.Builder{}
+			case tokenType == html.EndTagToken && tag == "a":
+				if current != nil && text != nil {
+					current.Title = strings.TrimSpace(text.String())
+				}
+				text = nil
+			case tokenType == html.StartTagToken && tag == "dd":
+				inDescription = current != nil
+				text = &strings.Builder{}
+			}
+		}
+	}
+}
+
+func readAnchor(tokenizer *html.Tokenizer) *Record {
+	record := &Record{Shared: true}
+
+	for {
+		key, value, more := tokenizer.TagAttr()
+		switch string(key) {
+		case "href":
+			record.URL = strings.TrimSpace(string(value))
+		case "add_date":
+			if seconds, err := strconv.ParseInt(string(value), 10, 64); err == nil && seconds > 0 {
+				record.DateAdded = time.Unix(seconds, 0).UTC()
+			}
+		case "tags":
+			record.Tags = appendTags(record.Tags, splitTags(string(value), ","))
+		case "toread":
+			record.Unread = string(value) == "1"
+		case "private":
+			record.Shared = string(value) != "1"
+		}
+
+		if !more {
+			return record
+		}
+	}
+}
+
+// setDescription stores a description, splitting off the notes that
+// Linkding's exporter appends to it.
+func setDescription(record *Record, description string) {
+	description = strings.TrimSpace(description)
+
+	if start := strings.Index(description, "[linkding-notes]"); start >= 0 {
+		notes := description[start+len("[linkding-notes]"):]
+		notes, _, _ = strings.Cut(notes, "[/linkding-notes]")
+
+		record.Notes = strings.TrimSpace(notes)
+		description = strings.TrimSpace(description[:start])
+	}
+
+	record.Description = description
+}
+
+func appendTags(tags []string, more []string) []string {
+	for _, tag := range more {
+		if !containsTag(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, name := range tags {
+		if strings.EqualFold(name, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func nonEmpty(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,200 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+	"golang.org/x/net/html"
+)
+
+// PocketOptions configures how Pocket exports are read.
+type PocketOptions struct {
+	// The tag added to items marked as favorite. Favorites are not tagged
+	// when empty. Favorites are only known when the export has a favorite
+	// column.
+	FavoriteTag string
+	// Archive the items that were read in Pocket, in addition to marking them
+	// as read.
+	ArchiveRead bool
+	// Tags added to every item, e.g. to mark where it was imported from.
+	Tags []string
+}
+
+// ImportPocket reads a Pocket export, in either its CSV or HTML format, and
+// imports it into Linkding. It is a shorthand for ReadPocket and Import.
+func ImportPocket(ctx context.Context, c linkding.BookmarkClient, r io.Reader, pocketOpts PocketOptions, opts Options) (*Report, error) {
+	records, err := ReadPocket(r, pocketOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return Import(ctx, c, records, opts)
+}
+
+// ReadPocket reads a Pocket export, detecting whether it is in the CSV format
+// or the older HTML format.
+func ReadPocket(r io.Reader, opts PocketOptions) ([]Record, error) {
+	reader := bufio.NewReader(r)
+
+	start, err := reader.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(start)), "<") {
+		return ReadPocketHTML(reader, opts)
+	}
+
+	return ReadPocketCSV(reader, opts)
+}
+
+// ReadPocketCSV reads the CSV export of Pocket, with the columns title, url,
+// time_added, tags and status, and optionally favorite. Items with the status
+// "unread" are marked as unread.
+func ReadPocketCSV(r io.Reader, opts PocketOptions) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return []Record{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[normalizeHeader(name)] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, errors.New("importer: pocket export has no url column")
+	}
+
+	value := func(row []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(row) {
+			return ""
+		}
+
+		return strings.TrimSpace(row[i])
+	}
+
+	records := []Record{}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record := Record{
+			URL:   value(row, "url"),
+			Title: value(row, "title"),
+			Tags:  splitTags(value(row, "tags"), "|"),
+		}
+		if record.URL == "" {
+			continue
+		}
+
+		if record.DateAdded, err = parseDate(value(row, "time_added")); err != nil {
+			line, _ := reader.FieldPos(columns["time_added"])
+			return nil, fmt.Errorf("importer: line %d: %w", line, err)
+		}
+
+		favorite, _ := parseBool(value(row, "favorite"))
+		unread := strings.EqualFold(value(row, "status"), "unread")
+		records = append(records, pocketRecord(record, unread, favorite, opts))
+	}
+}
+
+// ReadPocketHTML reads the HTML export of Pocket, where items are listed
+// under an "Unread" and a "Read Archive" heading.
+func ReadPocketHTML(r io.Reader, opts PocketOptions) ([]Record, error) {
+	tokenizer := html.NewTokenizer(r)
+	records := []Record{}
+
+	var heading *strings.Builder
+	var title *strings.Builder
+	unread := true
+	record := Record{}
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			return records, nil
+		case html.TextToken:
+			if heading != nil {
+				heading.Write(tokenizer.Text())
+			}
+			if title != nil {
+				title.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.EndTagToken:
+			name, _ := tokenizer.TagName()
+
+			switch {
+			case tokenType == html.StartTagToken && string(name) == "h1":
+				heading = &strings.Builder{}
+			case tokenType == html.EndTagToken && string(name) == "h1":
+				if heading != nil {
+					unread = !strings.Contains(strings.ToLower(heading.String()), "read archive")
+				}
+				heading = nil
+			case tokenType == html.StartTagToken && string(name) == "a":
+				record = readPocketAnchor(tokenizer)
+				title = &strings.Builder{}
+			case tokenType == html.EndTagToken && string(name) == "a":
+				if title != nil && record.URL != "" {
+					record.Title = strings.TrimSpace(title.String())
+					records = append(records, pocketRecord(record, unread, false, opts))
+				}
+				title = nil
+			}
+		}
+	}
+}
+
+func readPocketAnchor(tokenizer *html.Tokenizer) Record {
+	record := Record{}
+
+	for {
+		key, value, more := tokenizer.TagAttr()
+		switch string(key) {
+		case "href":
+			record.URL = strings.TrimSpace(string(value))
+		case "time_added":
+			record.DateAdded, _ = parseDate(string(value))
+		case "tags":
+			record.Tags = splitTags(string(value), ",")
+		}
+
+		if !more {
+			return record
+		}
+	}
+}
+
+func pocketRecord(record Record, unread, favorite bool, opts PocketOptions) Record {
+	record.Unread = unread
+	record.Archived = !unread && opts.ArchiveRead
+
+	if favorite && opts.FavoriteTag != "" {
+		record.Tags = appendTags(record.Tags, []string{opts.FavoriteTag})
+	}
+	record.Tags = appendTags(record.Tags, opts.Tags)
+
+	return record
+}
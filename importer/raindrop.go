@@ -0,0 +1,136 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+)
+
+// RaindropOptions configures how Raindrop.io exports are read.
+type RaindropOptions struct {
+	// The prefix of the tags derived from collections, e.g. "raindrop/".
+	CollectionTagPrefix string
+	// Do not derive tags from collections.
+	SkipCollections bool
+	// The tag added to items marked as favorite. Favorites are not tagged
+	// when empty.
+	FavoriteTag string
+	// Tags added to every item, e.g. to mark where it was imported from.
+	Tags []string
+}
+
+// ImportRaindrop reads a Raindrop.io CSV export and imports it into Linkding.
+// It is a shorthand for ReadRaindropCSV and Import.
+func ImportRaindrop(ctx context.Context, c linkding.BookmarkClient, r io.Reader, raindropOpts RaindropOptions, opts Options) (*Report, error) {
+	records, err := ReadRaindropCSV(r, raindropOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return Import(ctx, c, records, opts)
+}
+
+// ReadRaindropCSV reads the CSV export of Raindrop.io.
+//
+// The collection of each item becomes a tag, with nested collections joined by
+// slashes, e.g. "Dev/Go". Items in the "Unsorted" collection get no collection
+// tag. The excerpt becomes the description, while the note and highlights are
+// kept in the notes. The created date is read into DateAdded.
+//
+// The HTML backup of Raindrop.io uses the Netscape bookmark format and can be
+// read with ReadNetscapeHTML.
+func ReadRaindropCSV(r io.Reader, opts RaindropOptions) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return []Record{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[normalizeHeader(name)] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, errors.New("importer: raindrop export has no url column")
+	}
+
+	value := func(row []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(row) {
+			return ""
+		}
+
+		return strings.TrimSpace(row[i])
+	}
+
+	records := []Record{}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record := Record{
+			URL:         value(row, "url"),
+			Title:       value(row, "title"),
+			Description: value(row, "excerpt"),
+			Notes:       raindropNotes(value(row, "note"), value(row, "highlights")),
+			Tags:        splitTags(value(row, "tags"), ","),
+		}
+		if record.URL == "" {
+			continue
+		}
+
+		if record.DateAdded, err = parseDate(value(row, "created")); err != nil {
+			line, _ := reader.FieldPos(columns["created"])
+			return nil, fmt.Errorf("importer: line %d: %w", line, err)
+		}
+
+		if tag := collectionTag(value(row, "folder"), opts); tag != "" {
+			record.Tags = appendTags(record.Tags, []string{tag})
+		}
+		if favorite, _ := parseBool(value(row, "favorite")); favorite && opts.FavoriteTag != "" {
+			record.Tags = appendTags(record.Tags, []string{opts.FavoriteTag})
+		}
+		record.Tags = appendTags(record.Tags, opts.Tags)
+
+		records = append(records, record)
+	}
+}
+
+func collectionTag(collection string, opts RaindropOptions) string {
+	if opts.SkipCollections || collection == "" || strings.EqualFold(collection, "unsorted") {
+		return ""
+	}
+
+	parts := strings.Split(collection, "/")
+	for i, part := range parts {
+		parts[i] = strings.Join(strings.Fields(part), "-")
+	}
+
+	return opts.CollectionTagPrefix + strings.Join(nonEmpty(parts), "/")
+}
+
+func raindropNotes(note, highlights string) string {
+	if highlights == "" {
+		return note
+	}
+	if note == "" {
+		return highlights
+	}
+
+	return note + "\n\n" + highlights
+}
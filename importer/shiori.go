@@ -0,0 +1,165 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// ShioriOptions configures how Shiori exports are read.
+type ShioriOptions struct {
+	// The data directory of Shiori, containing its "archive" and "ebook"
+	// directories, e.g. os.DirFS("/var/lib/shiori"). When set, the offline
+	// archive and the ebook of each bookmark are uploaded as assets.
+	DataDir fs.FS
+	// Tags added to every item, e.g. to mark where it was imported from.
+	Tags []string
+}
+
+// shioriBookmark is a bookmark as printed by "shiori print --json" and
+// returned by the Shiori API. Older versions of Shiori only have the modified
+// date.
+type shioriBookmark struct {
+	ID         int    `json:"id"`
+	URL        string `json:"url"`
+	Title      string `json:"title"`
+	Excerpt    string `json:"excerpt"`
+	Public     int    `json:"public"`
+	CreatedAt  string `json:"createdAt"`
+	ModifiedAt string `json:"modifiedAt"`
+	Modified   string `json:"modified"`
+	Tags       []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+}
+
+// ImportShiori reads a Shiori JSON export and imports it into Linkding. It is
+// a shorthand for ReadShiori and Import.
+func ImportShiori(ctx context.Context, c linkding.BookmarkClient, r io.Reader, shioriOpts ShioriOptions, opts Options) (*Report, error) {
+	records, err := ReadShiori(r, shioriOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return Import(ctx, c, records, opts)
+}
+
+// ReadShiori reads the bookmarks of Shiori from the JSON printed by
+// "shiori print --json", or from a response of its bookmarks API.
+//
+// The excerpt becomes the description and public bookmarks are shared. The
+// creation date is read into DateAdded, falling back to the modification date
+// for versions of Shiori that do not record it.
+//
+// The HTML file written by "shiori export" uses the Netscape bookmark format
+// and can be read with ReadNetscapeHTML, but it does not include archives.
+func ReadShiori(r io.Reader, opts ShioriOptions) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return []Record{}, nil
+	}
+
+	// The API wraps the bookmarks in an object with paging information.
+	var bookmarks []shioriBookmark
+	if data[0] == '{' {
+		var page struct {
+			Bookmarks []shioriBookmark `json:"bookmarks"`
+		}
+		err = json.Unmarshal(data, &page)
+		bookmarks = page.Bookmarks
+	} else {
+		err = json.Unmarshal(data, &bookmarks)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("importer: invalid shiori export: %w", err)
+	}
+
+	records := make([]Record, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		if bookmark.URL == "" {
+			continue
+		}
+
+		record := Record{
+			URL:         strings.TrimSpace(bookmark.URL),
+			Title:       strings.TrimSpace(bookmark.Title),
+			Description: strings.TrimSpace(bookmark.Excerpt),
+			Shared:      bookmark.Public == 1,
+			DateAdded:   parseShioriDate(bookmark.CreatedAt, bookmark.ModifiedAt, bookmark.Modified),
+		}
+		for _, tag := range bookmark.Tags {
+			record.Tags = appendTags(record.Tags, splitTags(tag.Name, " "))
+		}
+		record.Tags = appendTags(record.Tags, opts.Tags)
+
+		if opts.DataDir != nil {
+			record.Assets, err = shioriAssets(opts.DataDir, bookmark)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// shioriAssets returns the archive and ebook of a bookmark that exist in the
+// data directory. Archives are stored in Shiori's own format, so they are
+// uploaded for safekeeping rather than for viewing in Linkding.
+func shioriAssets(dataDir fs.FS, bookmark shioriBookmark) ([]Asset, error) {
+	files := []struct{ path, name string }{
+		{fmt.Sprintf("archive/%d", bookmark.ID), fmt.Sprintf("shiori-archive-%d", bookmark.ID)},
+		{fmt.Sprintf("ebook/%d.epub", bookmark.ID), fmt.Sprintf("shiori-ebook-%d.epub", bookmark.ID)},
+	}
+
+	assets := []Asset{}
+	for _, file := range files {
+		_, err := fs.Stat(dataDir, file.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		assets = append(assets, Asset{
+			Name: file.name,
+			Open: func() (io.ReadCloser, error) {
+				return dataDir.Open(file.path)
+			},
+		})
+	}
+
+	return assets, nil
+}
+
+func parseShioriDate(values ...string) time.Time {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		if t, err := time.Parse(time.DateTime, value); err == nil {
+			return t
+		}
+		if t, err := parseDate(value); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,117 @@
+// Package launcher renders bookmarks for application launchers, so searching
+// bookmarks from a launcher takes a thin script on top of the linkding command
+// line tool.
+package launcher
+
+import (
+	"encoding/json"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+)
+
+// AlfredItem is an item of the script filter JSON format of Alfred, which
+// Raycast's script commands understand as well.
+type AlfredItem struct {
+	UID          string `json:"uid,omitempty"`
+	Title        string `json:"title"`
+	Subtitle     string `json:"subtitle,omitempty"`
+	Arg          string `json:"arg,omitempty"`
+	Autocomplete string `json:"autocomplete,omitempty"`
+	Match        string `json:"match,omitempty"`
+	// Whether the item can be actioned. Defaults to true when nil.
+	Valid        *bool             `json:"valid,omitempty"`
+	QuicklookURL string            `json:"quicklookurl,omitempty"`
+	Icon         *AlfredIcon       `json:"icon,omitempty"`
+	Text         *AlfredText       `json:"text,omitempty"`
+	Variables    map[string]string `json:"variables,omitempty"`
+}
+
+// AlfredIcon is the icon of an item.
+type AlfredIcon struct {
+	Path string `json:"path"`
+}
+
+// AlfredText is the text of an item copied with ⌘C or shown in large type
+// with ⌘L.
+type AlfredText struct {
+	Copy      string `json:"copy,omitempty"`
+	LargeType string `json:"largetype,omitempty"`
+}
+
+// AlfredOptions configures WriteAlfred.
+type AlfredOptions struct {
+	// Returns the icon of a bookmark, e.g. the path of a favicon downloaded
+	// to a local cache, as Alfred only shows icons from files. Defaults to
+	// the favicon URL of the bookmark, which Raycast shows.
+	Icon func(bookmark linkding.Bookmark) string
+	// The title of the item written when there are no bookmarks, so the
+	// launcher does not fall back to its default results. No item is written
+	// when empty.
+	Empty string
+}
+
+// WriteAlfred writes the bookmarks to w as an Alfred script filter result and
+// returns the number of bookmarks written. Each item opens the URL of its
+// bookmark, and carries its ID in the variable "id".
+//
+// Unlike other writers, nothing is written if the iterator yields an error,
+// as a partial result is not valid JSON.
+func WriteAlfred(w io.Writer, bookmarks iter.Seq2[linkding.Bookmark, error], opts AlfredOptions) (int, error) {
+	icon := opts.Icon
+	if icon == nil {
+		icon = func(bookmark linkding.Bookmark) string { return bookmark.FaviconURL }
+	}
+
+	items := []AlfredItem{}
+	for bookmark, err := range bookmarks {
+		if err != nil {
+			return 0, err
+		}
+		items = append(items, newAlfredItem(bookmark, icon(bookmark)))
+	}
+
+	count := len(items)
+	if count == 0 && opts.Empty != "" {
+		valid := false
+		items = append(items, AlfredItem{Title: opts.Empty, Valid: &valid})
+	}
+
+	err := json.NewEncoder(w).Encode(struct {
+		Items []AlfredItem `json:"items"`
+	}{items})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func newAlfredItem(bookmark linkding.Bookmark, icon string) AlfredItem {
+	title := bookmark.EffectiveTitle()
+
+	subtitle := bookmark.URL
+	if len(bookmark.TagNames) > 0 {
+		subtitle += " · #" + strings.Join(bookmark.TagNames, " #")
+	}
+
+	item := AlfredItem{
+		UID:          bookmark.URL,
+		Title:        title,
+		Subtitle:     subtitle,
+		Arg:          bookmark.URL,
+		Autocomplete: title,
+		Match:        strings.Join(append([]string{title, bookmark.URL}, bookmark.TagNames...), " "),
+		QuicklookURL: bookmark.URL,
+		Text:         &AlfredText{Copy: bookmark.URL, LargeType: title},
+		Variables:    map[string]string{"id": strconv.Itoa(bookmark.ID)},
+	}
+	if icon != "" {
+		item.Icon = &AlfredIcon{Path: icon}
+	}
+
+	return item
+}
@@ -0,0 +1,95 @@
+package launcher
+
+import (
+	"cmp"
+	"errors"
+	"io"
+	"iter"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+)
+
+// DefaultDelimiter separates the fields of a line when no delimiter is
+// configured. It is unlikely to be part of a title.
+const DefaultDelimiter = " ⁞ "
+
+// ErrNoURL is returned by ResolveSelection for a selection not written by
+// WriteLines.
+var ErrNoURL = errors.New("launcher: no URL in selection")
+
+// LinesOptions configures WriteLines and ResolveSelection.
+type LinesOptions struct {
+	// The delimiter between the fields of a line. Defaults to
+	// DefaultDelimiter.
+	Delimiter string
+	// End lines with a NUL byte instead of a newline, e.g. for rofi's
+	// -sep '\0'.
+	NullSeparated bool
+}
+
+// WriteLines writes a line per bookmark to w, for pickers such as rofi, dmenu
+// or fzf, and returns the number of bookmarks written. A line holds the title,
+// URL and tags of a bookmark:
+//
+//	The Go Programming Language ⁞ https://go.dev ⁞ #go #programming
+//
+// Line breaks and delimiters in titles are replaced by spaces, so each line
+// can be resolved to its bookmark with ResolveSelection.
+//
+// Writing stops at the first error yielded by the iterator.
+func WriteLines(w io.Writer, bookmarks iter.Seq2[linkding.Bookmark, error], opts LinesOptions) (int, error) {
+	delimiter := cmp.Or(opts.Delimiter, DefaultDelimiter)
+	end := "\n"
+	if opts.NullSeparated {
+		end = "\x00"
+	}
+	clean := strings.NewReplacer(separator(delimiter), " ", "\n", " ", "\r", " ", "\x00", "")
+
+	count := 0
+	for bookmark, err := range bookmarks {
+		if err != nil {
+			return count, err
+		}
+
+		tags := make([]string, len(bookmark.TagNames))
+		for i, tag := range bookmark.TagNames {
+			tags[i] = "#" + tag
+		}
+		title := bookmark.EffectiveTitle()
+
+		line := clean.Replace(title) + delimiter + bookmark.URL + delimiter + strings.Join(tags, " ") + end
+		if _, err := io.WriteString(w, line); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// ResolveSelection returns the URL of a line written by WriteLines with the
+// same options, as picked by the user. A trailing line break or NUL byte is
+// ignored.
+func ResolveSelection(selection string, opts LinesOptions) (string, error) {
+	delimiter := cmp.Or(opts.Delimiter, DefaultDelimiter)
+	selection = strings.TrimRight(selection, "\r\n\x00")
+
+	// The URL is the second to last field, as the tags may be empty.
+	fields := strings.Split(selection, separator(delimiter))
+	if len(fields) < 3 {
+		return "", ErrNoURL
+	}
+	url := strings.TrimSpace(fields[len(fields)-2])
+	if url == "" {
+		return "", ErrNoURL
+	}
+
+	return url, nil
+}
+
+// separator returns the delimiter without surrounding spaces, which pickers
+// may trim from a selection without tags.
+func separator(delimiter string) string {
+	return cmp.Or(strings.TrimSpace(delimiter), delimiter)
+}
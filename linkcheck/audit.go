@@ -0,0 +1,155 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/wayback"
+)
+
+// AuditOptions configures Audit.
+type AuditOptions struct {
+	// Configures the checks.
+	Check Options
+	// Looks up the latest capture of dead pages in the Wayback Machine. Only
+	// the snapshots of the bookmarks are considered when nil.
+	Wayback *wayback.Client
+	// Also audit archived bookmarks.
+	IncludeArchived bool
+	// The tags added to the bookmarks of each category, if set. Tags of
+	// previous audits are not removed.
+	Tags AuditTags
+}
+
+// AuditTags are the tags Audit adds to the bookmarks of each category.
+type AuditTags struct {
+	DeadArchived   string
+	DeadUnarchived string
+	Redirected     string
+}
+
+// Finding is a bookmark that needs attention.
+type Finding struct {
+	Bookmark linkding.Bookmark
+	Result   Result
+	// The URL of an archived copy of a dead page, if one was found.
+	Archive string
+}
+
+// AuditReport is the outcome of Audit.
+type AuditReport struct {
+	// The number of bookmarks checked.
+	Checked int
+	// Dead pages with an archived copy, which can replace the page.
+	DeadArchived []Finding
+	// Dead pages without an archived copy, which are lost.
+	DeadUnarchived []Finding
+	// Pages that moved permanently, whose bookmarks can be updated to the
+	// new location.
+	Redirected []Finding
+	// Pages that could not be checked, worth checking again later.
+	Failed []Finding
+	// The error of each bookmark that could not be tagged, by ID.
+	TagErrors map[int]error
+}
+
+// Audit checks the links of the bookmarks of an account and reports the dead
+// ones, split by whether an archived copy exists, and the ones that moved
+// permanently. An archived copy is the snapshot of the bookmark made by
+// Linkding's web archive integration or wayback.SaveBookmarks, or else the
+// latest capture in the Wayback Machine.
+func Audit(ctx context.Context, c linkding.BookmarkClient, opts AuditOptions) (*AuditReport, error) {
+	all := linkding.AllBookmarks
+	if opts.IncludeArchived {
+		all = linkding.AllBookmarksIncludingArchived
+	}
+
+	bookmarks := []linkding.Bookmark{}
+	for bookmark, err := range all(ctx, c, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	urls := make([]string, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		urls[i] = bookmark.URL
+	}
+	results := New(opts.Check).CheckAll(ctx, urls)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &AuditReport{Checked: len(bookmarks), TagErrors: map[int]error{}}
+	for i, bookmark := range bookmarks {
+		finding := Finding{Bookmark: bookmark, Result: results[i]}
+
+		var tag string
+		switch results[i].Status {
+		case Dead:
+			archive, err := archivedCopy(ctx, bookmark, opts.Wayback)
+			if err != nil {
+				return nil, err
+			}
+			finding.Archive = archive
+			if archive != "" {
+				report.DeadArchived = append(report.DeadArchived, finding)
+				tag = opts.Tags.DeadArchived
+			} else {
+				report.DeadUnarchived = append(report.DeadUnarchived, finding)
+				tag = opts.Tags.DeadUnarchived
+			}
+		case Redirected:
+			report.Redirected = append(report.Redirected, finding)
+			tag = opts.Tags.Redirected
+		case Failed:
+			report.Failed = append(report.Failed, finding)
+		}
+
+		if tag != "" {
+			if err := addTag(c, bookmark, tag); err != nil {
+				report.TagErrors[bookmark.ID] = err
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// archivedCopy returns the URL of an archived copy of a bookmarked page, or
+// an empty string. Failed lookups in the Wayback Machine count as no copy,
+// unless ctx is done.
+func archivedCopy(ctx context.Context, bookmark linkding.Bookmark, wb *wayback.Client) (string, error) {
+	if bookmark.WebArchiveSnapshotURL != "" {
+		return bookmark.WebArchiveSnapshotURL, nil
+	}
+	if snapshot := wayback.SnapshotFromNotes(bookmark.Notes); snapshot != "" {
+		return snapshot, nil
+	}
+	if wb == nil {
+		return "", nil
+	}
+
+	latest, err := wb.Latest(ctx, bookmark.URL)
+	if err != nil && !errors.Is(err, wayback.ErrNoCapture) && ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	return latest, nil
+}
+
+func addTag(c linkding.BookmarkClient, bookmark linkding.Bookmark, tag string) error {
+	if slices.ContainsFunc(bookmark.TagNames, func(name string) bool {
+		return strings.EqualFold(name, tag)
+	}) {
+		return nil
+	}
+
+	tags := append(slices.Clone(bookmark.TagNames), tag)
+	_, err := c.PatchBookmark(bookmark.ID, linkding.PatchBookmarkRequest{TagNames: &tags})
+	return err
+}
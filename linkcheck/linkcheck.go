@@ -0,0 +1,244 @@
+// Package linkcheck finds bookmarks whose pages moved or disappeared.
+//
+// A Checker requests each URL without following redirects itself, so it can
+// tell permanent redirects, whose targets should replace the bookmarked URL,
+// from temporary ones, which should not. Pages that are gone are told apart
+// from pages that merely failed to load, as servers that are down or block
+// automated requests do not mean that a link rotted. Audit combines the
+// checks with the archived copies of pages into a report of the bookmarks
+// that need attention, and ResolveRedirects moves bookmarks to the new
+// locations of their pages.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency is the number of URLs checked at once when not
+// configured.
+const DefaultConcurrency = 8
+
+// DefaultTimeout is how long a URL is checked at most when not configured.
+const DefaultTimeout = 15 * time.Second
+
+// DefaultMaxHops is the number of redirects followed at most when not
+// configured.
+const DefaultMaxHops = 10
+
+// DefaultUserAgent is the user agent sent when not configured.
+const DefaultUserAgent = "go-linkding-linkcheck"
+
+// Status is the outcome of checking a URL.
+type Status string
+
+const (
+	// OK is a page that loads at its URL, possibly after temporary
+	// redirects.
+	OK Status = "ok"
+	// Redirected is a page that moved permanently. The new location is in
+	// Result.FinalURL.
+	Redirected Status = "redirected"
+	// Dead is a page that is gone: the server answered with 404 Not Found
+	// or 410 Gone, or the domain no longer exists.
+	Dead Status = "dead"
+	// Failed is a page that could not be checked, e.g. because the server
+	// timed out, returned a server error or refused the request. It may
+	// load when checked again.
+	Failed Status = "failed"
+)
+
+// Result is the outcome of checking a URL.
+type Result struct {
+	URL    string
+	Status Status
+	// The status code of the last response, or 0 if there was none.
+	StatusCode int
+	// The URL reached by following the permanent redirects from URL. It
+	// equals URL if the first response was not a permanent redirect.
+	FinalURL string
+	// The reason a check failed or a page is dead, if any.
+	Err error
+}
+
+// Options configures a Checker.
+type Options struct {
+	// The client used for requests. Its redirect policy is replaced, as the
+	// checker follows redirects itself. Defaults to a client using
+	// http.DefaultTransport.
+	HTTPClient *http.Client
+	// The number of URLs checked at once by CheckAll. Defaults to
+	// DefaultConcurrency.
+	Concurrency int
+	// How long a URL is checked at most, including redirects. Defaults to
+	// DefaultTimeout.
+	Timeout time.Duration
+	// The number of redirects followed at most. Defaults to DefaultMaxHops.
+	MaxHops int
+	// The User-Agent header sent. Some sites reject requests without a
+	// browser-like user agent. Defaults to DefaultUserAgent.
+	UserAgent string
+}
+
+// Checker checks URLs. It is safe for concurrent use.
+type Checker struct {
+	opts Options
+}
+
+// New returns a checker.
+func New(opts Options) *Checker {
+	client := http.Client{}
+	if opts.HTTPClient != nil {
+		client = *opts.HTTPClient
+	}
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	opts.HTTPClient = &client
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxHops <= 0 {
+		opts.MaxHops = DefaultMaxHops
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+
+	return &Checker{opts: opts}
+}
+
+// Check checks a URL, following its redirects.
+func (c *Checker) Check(ctx context.Context, rawURL string) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	result := Result{URL: rawURL, FinalURL: rawURL}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		result.Status, result.Err = Failed, err
+		return result
+	}
+
+	permanent := true
+	for hop := 0; ; hop++ {
+		res, err := c.request(ctx, u)
+		if err != nil {
+			result.Status, result.Err = Failed, err
+			if isGone(err) {
+				result.Status = Dead
+			}
+			return result
+		}
+		result.StatusCode = res.StatusCode
+
+		switch code := res.StatusCode; {
+		case code >= 300 && code < 400:
+			location, err := res.Location()
+			if err != nil {
+				result.Status, result.Err = Failed, fmt.Errorf("linkcheck: redirect without location: %w", err)
+				return result
+			}
+			if hop >= c.opts.MaxHops {
+				result.Status, result.Err = Failed, errors.New("linkcheck: too many redirects")
+				return result
+			}
+
+			// Only an unbroken chain of permanent redirects from the
+			// bookmarked URL moves it.
+			if code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+				permanent = false
+			}
+			if permanent {
+				result.FinalURL = location.String()
+			}
+			u = location
+			continue
+		case code == http.StatusNotFound || code == http.StatusGone:
+			result.Status, result.Err = Dead, fmt.Errorf("linkcheck: %s", res.Status)
+		case code >= 400:
+			result.Status, result.Err = Failed, fmt.Errorf("linkcheck: %s", res.Status)
+		case result.FinalURL != result.URL:
+			result.Status = Redirected
+		default:
+			result.Status = OK
+		}
+
+		return result
+	}
+}
+
+// CheckAll checks URLs concurrently and returns their results in the same
+// order.
+func (c *Checker) CheckAll(ctx context.Context, urls []string) []Result {
+	results := make([]Result, len(urls))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(c.opts.Concurrency, len(urls)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = c.Check(ctx, urls[i])
+			}
+		}()
+	}
+
+	for i := range urls {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			results[i] = Result{URL: urls[i], FinalURL: urls[i], Status: Failed, Err: ctx.Err()}
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// request requests a URL with HEAD, falling back to GET on errors, as some
+// servers do not support HEAD or answer it differently.
+func (c *Checker) request(ctx context.Context, u *url.URL) (*http.Response, error) {
+	var res *http.Response
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+
+		res, err = c.opts.HTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		res.Body.Close()
+
+		if res.StatusCode < 400 {
+			break
+		}
+	}
+
+	return res, nil
+}
+
+// isGone reports whether a request failed because the domain of the URL no
+// longer exists.
+func isGone(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
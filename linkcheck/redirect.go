@@ -0,0 +1,144 @@
+package linkcheck
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+)
+
+// ResolveOptions configures ResolveRedirects.
+type ResolveOptions struct {
+	// Configures the checks.
+	Check Options
+	// Also resolve the redirects of archived bookmarks.
+	IncludeArchived bool
+	// When the new location of a bookmark is already bookmarked, merge the
+	// tags, description and notes of the bookmark into the existing one and
+	// delete it. Such bookmarks are left unchanged otherwise.
+	MergeDuplicates bool
+	// Report the changes without making them.
+	DryRun bool
+}
+
+// Move is a bookmark whose page moved permanently.
+type Move struct {
+	Bookmark linkding.Bookmark
+	// The new location of the page.
+	To string
+	// The existing bookmark of the new location, if any.
+	Duplicate *linkding.Bookmark
+}
+
+// ResolveReport is the outcome of ResolveRedirects.
+type ResolveReport struct {
+	// The bookmarks updated to the new location.
+	Updated []Move
+	// The bookmarks merged into the existing bookmark of the new location.
+	Merged []Move
+	// The bookmarks left unchanged because the new location is already
+	// bookmarked and MergeDuplicates is not set.
+	Duplicates []Move
+	// The error of each bookmark that could not be changed, by ID.
+	Failed map[int]error
+}
+
+// ResolveRedirects follows the permanent redirects of the bookmarked pages and
+// updates the bookmarks to the final locations, cleaning up links that moved
+// to HTTPS or to another domain. Temporary redirects, e.g. to login pages, are
+// not followed. Bookmarks whose new location is already bookmarked are
+// reported as duplicates or merged, see ResolveOptions.MergeDuplicates.
+func ResolveRedirects(ctx context.Context, c linkding.BookmarkClient, opts ResolveOptions) (*ResolveReport, error) {
+	all := []linkding.Bookmark{}
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, bookmark)
+	}
+
+	// Duplicates are looked up among every bookmark, as Linkding does not
+	// allow two bookmarks of the same URL, archived or not.
+	byURL := map[string]linkding.Bookmark{}
+	for _, bookmark := range all {
+		byURL[linkding.NormalizeURL(bookmark.URL)] = bookmark
+	}
+
+	bookmarks := all
+	if !opts.IncludeArchived {
+		bookmarks = slices.DeleteFunc(slices.Clone(all), func(bookmark linkding.Bookmark) bool {
+			return bookmark.IsArchived
+		})
+	}
+	urls := make([]string, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		urls[i] = bookmark.URL
+	}
+	results := New(opts.Check).CheckAll(ctx, urls)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &ResolveReport{Failed: map[int]error{}}
+	for i, bookmark := range bookmarks {
+		if results[i].Status != Redirected {
+			continue
+		}
+
+		move := Move{Bookmark: bookmark, To: results[i].FinalURL}
+		key := linkding.NormalizeURL(move.To)
+		if existing, ok := byURL[key]; ok && existing.ID != bookmark.ID {
+			move.Duplicate = &existing
+		}
+
+		switch {
+		case move.Duplicate == nil:
+			if !opts.DryRun {
+				if _, err := c.PatchBookmark(bookmark.ID, linkding.PatchBookmarkRequest{URL: &move.To}); err != nil {
+					report.Failed[bookmark.ID] = err
+					continue
+				}
+			}
+			byURL[key] = bookmark
+			report.Updated = append(report.Updated, move)
+		case opts.MergeDuplicates:
+			if !opts.DryRun {
+				if err := mergeInto(c, *move.Duplicate, bookmark); err != nil {
+					report.Failed[bookmark.ID] = err
+					continue
+				}
+			}
+			report.Merged = append(report.Merged, move)
+		default:
+			report.Duplicates = append(report.Duplicates, move)
+		}
+	}
+
+	return report, nil
+}
+
+// mergeInto adds the tags of a bookmark to an existing bookmark, fills in its
+// empty description and notes, and deletes the bookmark.
+func mergeInto(c linkding.BookmarkClient, existing, bookmark linkding.Bookmark) error {
+	tags := slices.Clone(existing.TagNames)
+	for _, tag := range bookmark.TagNames {
+		if !slices.ContainsFunc(tags, func(name string) bool { return strings.EqualFold(name, tag) }) {
+			tags = append(tags, tag)
+		}
+	}
+	description := cmp.Or(existing.Description, bookmark.Description)
+	notes := cmp.Or(existing.Notes, bookmark.Notes)
+
+	_, err := c.PatchBookmark(existing.ID, linkding.PatchBookmarkRequest{
+		TagNames:    &tags,
+		Description: &description,
+		Notes:       &notes,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.DeleteBookmark(bookmark.ID)
+}
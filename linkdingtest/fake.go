@@ -0,0 +1,622 @@
+// Package linkdingtest provides an in-memory implementation of the Linkding
+// API for use in tests.
+package linkdingtest
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// DefaultLimit is the page size used by the fake when no limit is given,
+// mirroring the default of the Linkding API.
+const DefaultLimit = 100
+
+// Fake is an in-memory implementation of linkding.BookmarkClient. The zero
+// value is not usable, use NewFake instead.
+//
+// Fake is safe for concurrent use.
+type Fake struct {
+	// Now returns the current time. It is used to set the date fields of
+	// bookmarks and tags.
+	Now func() time.Time
+	// Capabilities is returned by DetectCapabilities.
+	Capabilities linkding.Capabilities
+	// Preferences is returned by GetUserPreferences.
+	Preferences linkding.UserPreferences
+
+	mu          sync.Mutex
+	bookmarks   map[int]*linkding.Bookmark
+	tags        map[int]*linkding.Tag
+	assets      map[int][]linkding.BookmarkAsset
+	contents    map[int][]byte
+	nextID      int
+	nextTagID   int
+	nextAssetID int
+}
+
+var _ linkding.BookmarkClient = (*Fake)(nil)
+
+// NewFake creates an empty fake Linkding instance supporting every feature.
+func NewFake() *Fake {
+	return &Fake{
+		Now: time.Now,
+		Capabilities: linkding.Capabilities{
+			Assets:  true,
+			Bundles: true,
+			Notes:   true,
+		},
+		bookmarks:   map[int]*linkding.Bookmark{},
+		tags:        map[int]*linkding.Tag{},
+		assets:      map[int][]linkding.BookmarkAsset{},
+		contents:    map[int][]byte{},
+		nextID:      1,
+		nextTagID:   1,
+		nextAssetID: 1,
+	}
+}
+
+// AddBookmark stores a copy of the given bookmark as is, assigning it an ID
+// if it has none, and returns the stored bookmark. It is meant for seeding the
+// fake with fields that cannot be set through the API, such as dates.
+func (f *Fake) AddBookmark(bookmark linkding.Bookmark) linkding.Bookmark {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if bookmark.ID == 0 {
+		bookmark.ID = f.nextID
+	}
+	if bookmark.ID >= f.nextID {
+		f.nextID = bookmark.ID + 1
+	}
+	if bookmark.TagNames == nil {
+		bookmark.TagNames = []string{}
+	}
+	f.ensureTags(bookmark.TagNames)
+
+	stored := bookmark
+	stored.TagNames = slices.Clone(bookmark.TagNames)
+	f.bookmarks[bookmark.ID] = &stored
+
+	return copyBookmark(&stored)
+}
+
+// AddBookmarkAsset attaches a copy of the given asset with the given content to
+// a bookmark, assigning it an ID if it has none, and returns the stored asset.
+func (f *Fake) AddBookmarkAsset(bookmarkID int, asset linkding.BookmarkAsset, content []byte) linkding.BookmarkAsset {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if asset.ID == 0 {
+		asset.ID = f.nextAssetID
+	}
+	if asset.ID >= f.nextAssetID {
+		f.nextAssetID = asset.ID + 1
+	}
+	asset.Bookmark = bookmarkID
+	f.assets[bookmarkID] = append(f.assets[bookmarkID], asset)
+	f.contents[asset.ID] = slices.Clone(content)
+
+	return asset
+}
+
+// ListBookmarks lists the bookmarks that are not archived. Queries are
+// matched like the server does for the syntax supported by the fake, see
+// ErrUnsupportedQuery.
+func (f *Fake) ListBookmarks(params linkding.ListBookmarksParams) (*linkding.ListBookmarksResponse, error) {
+	return f.listBookmarks("/api/bookmarks/", false, params)
+}
+
+// ListArchivedBookmarks lists the archived bookmarks.
+func (f *Fake) ListArchivedBookmarks(params linkding.ListBookmarksParams) (*linkding.ListBookmarksResponse, error) {
+	return f.listBookmarks("/api/bookmarks/archived/", true, params)
+}
+
+// GetBookmark returns a single bookmark.
+func (f *Fake) GetBookmark(id int) (*linkding.Bookmark, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bookmark, ok := f.bookmarks[id]
+	if !ok {
+		return nil, linkding.ErrNotFound
+	}
+
+	result := copyBookmark(bookmark)
+	return &result, nil
+}
+
+// CheckBookmark looks up a bookmark by its exact URL. The metadata returned
+// only contains the URL, as the fake does not scrape websites.
+func (f *Fake) CheckBookmark(bookmarkUrl string) (*linkding.CheckBookmarkResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := &linkding.CheckBookmarkResponse{
+		Metadata: linkding.Metadata{URL: bookmarkUrl},
+		AutoTags: []string{},
+	}
+	if bookmark := f.findByURL(bookmarkUrl); bookmark != nil {
+		found := copyBookmark(bookmark)
+		result.Bookmark = &found
+	}
+
+	return result, nil
+}
+
+// CreateBookmark creates a bookmark. Like Linkding, creating a bookmark for a
+// URL that is already bookmarked updates the existing bookmark instead.
+func (f *Fake) CreateBookmark(payload linkding.CreateBookmarkRequest) (*linkding.Bookmark, error) {
+	if err := validate(payload); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bookmark := f.findByURL(payload.URL)
+	if bookmark == nil {
+		now := f.Now()
+		bookmark = &linkding.Bookmark{ID: f.nextID, DateAdded: now}
+		f.bookmarks[bookmark.ID] = bookmark
+		f.nextID++
+	}
+	f.apply(bookmark, payload)
+
+	result := copyBookmark(bookmark)
+	return &result, nil
+}
+
+// UpdateBookmark replaces the editable fields of a bookmark.
+func (f *Fake) UpdateBookmark(id int, payload linkding.CreateBookmarkRequest) (*linkding.Bookmark, error) {
+	if err := validate(payload); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bookmark, ok := f.bookmarks[id]
+	if !ok {
+		return nil, linkding.ErrNotFound
+	}
+	f.apply(bookmark, payload)
+
+	result := copyBookmark(bookmark)
+	return &result, nil
+}
+
+// PatchBookmark changes the fields of a bookmark that are set in the payload.
+func (f *Fake) PatchBookmark(id int, payload linkding.PatchBookmarkRequest) (*linkding.Bookmark, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bookmark, ok := f.bookmarks[id]
+	if !ok {
+		return nil, linkding.ErrNotFound
+	}
+
+	update := linkding.CreateBookmarkRequest{
+		URL:         bookmark.URL,
+		Title:       bookmark.Title,
+		Description: bookmark.Description,
+		Notes:       bookmark.Notes,
+		IsArchived:  bookmark.IsArchived,
+		Unread:      bookmark.Unread,
+		Shared:      bookmark.Shared,
+		TagNames:    bookmark.TagNames,
+	}
+	if payload.URL != nil {
+		update.URL = *payload.URL
+	}
+	if payload.Title != nil {
+		update.Title = *payload.Title
+	}
+	if payload.Description != nil {
+		update.Description = *payload.Description
+	}
+	if payload.Notes != nil {
+		update.Notes = *payload.Notes
+	}
+	if payload.IsArchived != nil {
+		update.IsArchived = *payload.IsArchived
+	}
+	if payload.Unread != nil {
+		update.Unread = *payload.Unread
+	}
+	if payload.Shared != nil {
+		update.Shared = *payload.Shared
+	}
+	if payload.TagNames != nil {
+		update.TagNames = *payload.TagNames
+	}
+	f.apply(bookmark, update)
+
+	result := copyBookmark(bookmark)
+	return &result, nil
+}
+
+// ArchiveBookmark archives a bookmark.
+func (f *Fake) ArchiveBookmark(id int) error {
+	return f.setArchived(id, true)
+}
+
+// UnarchiveBookmark unarchives a bookmark.
+func (f *Fake) UnarchiveBookmark(id int) error {
+	return f.setArchived(id, false)
+}
+
+// DeleteBookmark deletes a bookmark along with its assets.
+func (f *Fake) DeleteBookmark(id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.bookmarks[id]; !ok {
+		return linkding.ErrNotFound
+	}
+	delete(f.bookmarks, id)
+	for _, asset := range f.assets[id] {
+		delete(f.contents, asset.ID)
+	}
+	delete(f.assets, id)
+
+	return nil
+}
+
+// ListTags lists tags ordered by ID.
+func (f *Fake) ListTags(params linkding.ListTagsParams) (*linkding.ListTagsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tags := make([]linkding.Tag, 0, len(f.tags))
+	for _, tag := range f.tags {
+		tags = append(tags, *tag)
+	}
+	slices.SortFunc(tags, func(a, b linkding.Tag) int { return a.ID - b.ID })
+
+	page, next, previous := paginate(tags, params.Limit, params.Offset)
+
+	return &linkding.ListTagsResponse{
+		Count:    len(tags),
+		Next:     pageURL("/api/tags/", params.Limit, next),
+		Previous: pageURL("/api/tags/", params.Limit, previous),
+		Results:  page,
+	}, nil
+}
+
+// GetTag returns a single tag.
+func (f *Fake) GetTag(id int) (*linkding.Tag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tag, ok := f.tags[id]
+	if !ok {
+		return nil, linkding.ErrNotFound
+	}
+
+	result := *tag
+	return &result, nil
+}
+
+// CreateTag creates a tag, or returns the existing tag with the same name.
+func (f *Fake) CreateTag(name string) (*linkding.Tag, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w (name is required)", linkding.ErrBadRequest)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := *f.ensureTag(name)
+	return &result, nil
+}
+
+// ListBookmarkAssets lists the assets of a bookmark. Only the first params
+// are used, like Client.ListBookmarkAssets.
+func (f *Fake) ListBookmarkAssets(bookmarkID int, optional ...linkding.ListBookmarkAssetsParams) (*linkding.ListBookmarkAssetsResponse, error) {
+	var params linkding.ListBookmarkAssetsParams
+	if len(optional) > 0 {
+		params = optional[0]
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.bookmarks[bookmarkID]; !ok {
+		return nil, linkding.ErrNotFound
+	}
+	assets := f.assets[bookmarkID]
+	page, next, previous := paginate(assets, params.Limit, params.Offset)
+	// Filters apply to the page, as in the client.
+	page = slices.DeleteFunc(page, func(asset linkding.BookmarkAsset) bool {
+		return !params.Matches(asset)
+	})
+	if page == nil {
+		page = []linkding.BookmarkAsset{}
+	}
+	path := fmt.Sprintf("/api/bookmarks/%d/assets/", bookmarkID)
+
+	return &linkding.ListBookmarkAssetsResponse{
+		Count:    len(assets),
+		Next:     pageURL(path, params.Limit, next),
+		Previous: pageURL(path, params.Limit, previous),
+		Results:  page,
+	}, nil
+}
+
+// GetBookmarkAsset returns a single asset of a bookmark.
+func (f *Fake) GetBookmarkAsset(bookmarkID int, id int) (*linkding.BookmarkAsset, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, asset := range f.assets[bookmarkID] {
+		if asset.ID == id {
+			return &asset, nil
+		}
+	}
+
+	return nil, linkding.ErrNotFound
+}
+
+// DownloadBookmarkAsset returns the content of a single asset of a bookmark.
+func (f *Fake) DownloadBookmarkAsset(bookmarkID int, id int) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, asset := range f.assets[bookmarkID] {
+		if asset.ID == id {
+			return io.NopCloser(bytes.NewReader(f.contents[id])), nil
+		}
+	}
+
+	return nil, linkding.ErrNotFound
+}
+
+// UploadBookmarkAsset stores the content read from r as a new asset of a
+// bookmark. The content type is derived from the extension of the name.
+func (f *Fake) UploadBookmarkAsset(bookmarkID int, name string, r io.Reader) (*linkding.BookmarkAsset, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.bookmarks[bookmarkID]; !ok {
+		return nil, linkding.ErrNotFound
+	}
+
+	asset := linkding.BookmarkAsset{
+		ID:          f.nextAssetID,
+		Bookmark:    bookmarkID,
+		AssetType:   linkding.AssetTypeUpload,
+		DateCreated: f.Now(),
+		ContentType: cmp.Or(mime.TypeByExtension(path.Ext(name)), "application/octet-stream"),
+		DisplayName: name,
+		Status:      linkding.AssetStatusComplete,
+	}
+	f.nextAssetID++
+	f.assets[bookmarkID] = append(f.assets[bookmarkID], asset)
+	f.contents[asset.ID] = content
+
+	return &asset, nil
+}
+
+// DeleteBookmarkAsset deletes a single asset of a bookmark.
+func (f *Fake) DeleteBookmarkAsset(bookmarkID int, id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	assets := f.assets[bookmarkID]
+	for i, asset := range assets {
+		if asset.ID == id {
+			f.assets[bookmarkID] = slices.Delete(assets, i, i+1)
+			delete(f.contents, id)
+			return nil
+		}
+	}
+
+	return linkding.ErrNotFound
+}
+
+// GetUserPreferences returns the configured preferences.
+func (f *Fake) GetUserPreferences() (*linkding.UserPreferences, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := f.Preferences
+	return &result, nil
+}
+
+// DetectCapabilities returns the configured capabilities.
+func (f *Fake) DetectCapabilities() (*linkding.Capabilities, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := f.Capabilities
+	return &result, nil
+}
+
+func (f *Fake) listBookmarks(path string, archived bool, params linkding.ListBookmarksParams) (*linkding.ListBookmarksResponse, error) {
+	query, err := parseQuery(params.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	matches := []linkding.Bookmark{}
+	for _, bookmark := range f.bookmarks {
+		if bookmark.IsArchived != archived {
+			continue
+		}
+		if params.Unread && !bookmark.Unread {
+			continue
+		}
+		if !params.AddedSince.IsZero() && bookmark.DateAdded.Before(params.AddedSince) {
+			continue
+		}
+		// Like the server, only bookmarks modified strictly after the time
+		// match.
+		if !params.ModifiedSince.IsZero() && !bookmark.DateModified.After(params.ModifiedSince) {
+			continue
+		}
+		if !query.matches(bookmark) {
+			continue
+		}
+
+		matches = append(matches, copyBookmark(bookmark))
+	}
+	sortBookmarks(matches, params.Sort)
+
+	page, next, previous := paginate(matches, params.Limit, params.Offset)
+
+	return &linkding.ListBookmarksResponse{
+		Count:    len(matches),
+		Next:     pageURL(path, params.Limit, next),
+		Previous: pageURL(path, params.Limit, previous),
+		Results:  page,
+	}, nil
+}
+
+func (f *Fake) setArchived(id int, archived bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bookmark, ok := f.bookmarks[id]
+	if !ok {
+		return linkding.ErrNotFound
+	}
+	bookmark.IsArchived = archived
+	bookmark.DateModified = f.Now()
+
+	return nil
+}
+
+// validate rejects the payloads the server rejects. Unlike Client, which
+// sends nil TagNames as an empty list, the fake gets the payload as it is, and
+// a null tag_names is rejected like the server does.
+func validate(payload linkding.CreateBookmarkRequest) error {
+	if payload.URL == "" {
+		return fmt.Errorf("%w (url is required)", linkding.ErrBadRequest)
+	}
+	if payload.TagNames == nil {
+		return fmt.Errorf("%w (tag_names may not be null)", linkding.ErrBadRequest)
+	}
+
+	return nil
+}
+
+func (f *Fake) apply(bookmark *linkding.Bookmark, payload linkding.CreateBookmarkRequest) {
+	bookmark.URL = payload.URL
+	bookmark.Title = payload.Title
+	bookmark.Description = payload.Description
+	bookmark.Notes = payload.Notes
+	bookmark.IsArchived = payload.IsArchived
+	bookmark.Unread = payload.Unread
+	bookmark.Shared = payload.Shared
+	bookmark.TagNames = slices.Clone(payload.TagNames)
+	bookmark.DateModified = f.Now()
+
+	f.ensureTags(bookmark.TagNames)
+}
+
+func (f *Fake) findByURL(url string) *linkding.Bookmark {
+	for _, bookmark := range f.bookmarks {
+		if bookmark.URL == url {
+			return bookmark
+		}
+	}
+
+	return nil
+}
+
+func (f *Fake) ensureTags(names []string) {
+	for _, name := range names {
+		f.ensureTag(name)
+	}
+}
+
+func (f *Fake) ensureTag(name string) *linkding.Tag {
+	for _, tag := range f.tags {
+		if strings.EqualFold(tag.Name, name) {
+			return tag
+		}
+	}
+
+	tag := &linkding.Tag{ID: f.nextTagID, Name: name, DateAdded: f.Now()}
+	f.tags[tag.ID] = tag
+	f.nextTagID++
+
+	return tag
+}
+
+func copyBookmark(bookmark *linkding.Bookmark) linkding.Bookmark {
+	result := *bookmark
+	result.TagNames = slices.Clone(bookmark.TagNames)
+
+	return result
+}
+
+// sortBookmarks orders bookmarks like Linkding, using the ID to break ties so
+// that pagination is stable.
+func sortBookmarks(bookmarks []linkding.Bookmark, order string) {
+	slices.SortFunc(bookmarks, func(a, b linkding.Bookmark) int {
+		switch order {
+		case "added_asc":
+			return cmp.Or(a.DateAdded.Compare(b.DateAdded), a.ID-b.ID)
+		case "title_asc":
+			return cmp.Or(strings.Compare(a.Title, b.Title), a.ID-b.ID)
+		case "title_desc":
+			return cmp.Or(strings.Compare(b.Title, a.Title), b.ID-a.ID)
+		default:
+			return cmp.Or(b.DateAdded.Compare(a.DateAdded), b.ID-a.ID)
+		}
+	})
+}
+
+// paginate returns the requested page of items along with the offsets of the
+// next and previous pages, which are -1 when there is no such page.
+func paginate[T any](items []T, limit, offset int) ([]T, int, int) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	offset = max(offset, 0)
+
+	start := min(offset, len(items))
+	end := min(start+limit, len(items))
+
+	next, previous := -1, -1
+	if end < len(items) {
+		next = end
+	}
+	if start > 0 {
+		previous = max(start-limit, 0)
+	}
+
+	return slices.Clone(items[start:end]), next, previous
+}
+
+// pageURL returns the URL of the page at offset, keeping the page size like
+// the server does, or "" for an offset of -1.
+func pageURL(path string, limit, offset int) string {
+	if offset < 0 {
+		return ""
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	return fmt.Sprintf("http://linkding.test%s?limit=%d&offset=%d", path, limit, offset)
+}
@@ -0,0 +1,218 @@
+package linkdingtest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+)
+
+// ErrUnsupportedQuery is returned when listing bookmarks with a query the fake
+// cannot match like the server does. The fake supports the syntax built by
+// linkding.Query: terms, quoted phrases, #tags, !unread and !untagged,
+// combined with and, or, not and parentheses. Terms and phrases match the URL,
+// title, description, notes and tags ignoring case.
+var ErrUnsupportedQuery = errors.New("linkdingtest: unsupported search syntax")
+
+// query matches the bookmarks of a parsed search query.
+type query func(bookmark *linkding.Bookmark) bool
+
+func (q query) matches(bookmark *linkding.Bookmark) bool {
+	return q(bookmark)
+}
+
+// token is a part of a search query. Quoted phrases are kept apart from
+// words, so a quoted "not" or "#tag" is matched as text.
+type token struct {
+	text   string
+	quoted bool
+}
+
+func parseQuery(q string) (query, error) {
+	tokens, err := tokenize(q)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrUnsupportedQuery, q, err)
+	}
+	if len(tokens) == 0 {
+		return func(*linkding.Bookmark) bool { return true }, nil
+	}
+
+	p := &parser{tokens: tokens}
+	result, err := p.or()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrUnsupportedQuery, q, err)
+	}
+
+	return result, nil
+}
+
+func tokenize(q string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(q); {
+		switch c := q[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '"':
+			var phrase strings.Builder
+			i++
+			for ; i < len(q) && q[i] != '"'; i++ {
+				if q[i] == '\\' && i+1 < len(q) {
+					i++
+				}
+				phrase.WriteByte(q[i])
+			}
+			if i == len(q) {
+				return nil, errors.New("unterminated quote")
+			}
+			i++
+			tokens = append(tokens, token{text: phrase.String(), quoted: true})
+		default:
+			end := i
+			for end < len(q) && !strings.ContainsRune(" \t\n\r()", rune(q[end])) {
+				end++
+			}
+			tokens = append(tokens, token{text: q[i:end]})
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+// parser parses tokens by precedence: not binds tighter than and, which may be
+// left out between two parts, and and binds tighter than or.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek(operator string) bool {
+	if p.pos >= len(p.tokens) {
+		return false
+	}
+	t := p.tokens[p.pos]
+
+	return !t.quoted && strings.EqualFold(t.text, operator)
+}
+
+func (p *parser) or() (query, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("or") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orQuery(left, right)
+	}
+
+	return left, nil
+}
+
+func (p *parser) and() (query, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.tokens) && !p.peek(")") && !p.peek("or") {
+		if p.peek("and") {
+			p.pos++
+		}
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = andQuery(left, right)
+	}
+
+	return left, nil
+}
+
+func (p *parser) not() (query, error) {
+	if !p.peek("not") {
+		return p.primary()
+	}
+	p.pos++
+
+	operand, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(bookmark *linkding.Bookmark) bool { return !operand(bookmark) }, nil
+}
+
+func (p *parser) primary() (query, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("unexpected end")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+
+	if t.quoted {
+		return textQuery(t.text), nil
+	}
+
+	switch lower := strings.ToLower(t.text); {
+	case lower == "(":
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return inner, nil
+	case lower == ")", lower == "and", lower == "or":
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	case lower == "!unread":
+		return func(bookmark *linkding.Bookmark) bool { return bookmark.Unread }, nil
+	case lower == "!untagged":
+		return func(bookmark *linkding.Bookmark) bool { return len(bookmark.TagNames) == 0 }, nil
+	case strings.HasPrefix(lower, "!"), strings.HasPrefix(lower, "-"):
+		return nil, fmt.Errorf("unsupported %q", t.text)
+	case strings.HasPrefix(lower, "#") && len(lower) > 1:
+		tag := t.text[1:]
+		return func(bookmark *linkding.Bookmark) bool {
+			return slices.ContainsFunc(bookmark.TagNames, func(name string) bool {
+				return strings.EqualFold(name, tag)
+			})
+		}, nil
+	default:
+		return textQuery(t.text), nil
+	}
+}
+
+func textQuery(text string) query {
+	text = strings.ToLower(text)
+
+	return func(bookmark *linkding.Bookmark) bool {
+		return strings.Contains(strings.ToLower(strings.Join([]string{
+			bookmark.URL,
+			bookmark.Title,
+			bookmark.Description,
+			bookmark.Notes,
+			strings.Join(bookmark.TagNames, " "),
+		}, " ")), text)
+	}
+}
+
+func andQuery(left, right query) query {
+	return func(bookmark *linkding.Bookmark) bool { return left(bookmark) && right(bookmark) }
+}
+
+func orQuery(left, right query) query {
+	return func(bookmark *linkding.Bookmark) bool { return left(bookmark) || right(bookmark) }
+}
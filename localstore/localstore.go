@@ -0,0 +1,272 @@
+// Package localstore keeps a persistent local mirror of the bookmarks and tags
+// of a Linkding account in a bbolt database, so frontends can query them
+// without a round trip to the server.
+//
+// The store implements syncer.Store. Refresh pulls the changes of the server
+// incrementally, and local changes marked as dirty are pushed by the same
+// sync.
+package localstore
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/cursor"
+	"github.com/larcher/go-linkding/syncer"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bookmarksBucket = []byte("bookmarks")
+	tagsBucket      = []byte("tags")
+	metaBucket      = []byte("meta")
+
+	watermarkKey = []byte("watermark")
+)
+
+// Store is a local mirror of an account backed by a bbolt database. It is
+// safe for concurrent use, but the database file can only be opened by one
+// process at a time.
+type Store struct {
+	db *bolt.DB
+}
+
+var _ syncer.Store = (*Store)(nil)
+
+// Open opens the store at path, creating it if it does not exist.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bookmarksBucket, tagsBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Cursors returns a cursor store kept in the same database, e.g. for a
+// watcher running alongside the store.
+func (s *Store) Cursors() *cursor.Bolt {
+	return cursor.NewBolt(s.db)
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Refresh syncs the bookmarks with the server and replaces the tags with the
+// tags of the server. Only bookmarks modified since the previous refresh are
+// transferred, unless opts asks for a full scan.
+func (s *Store) Refresh(ctx context.Context, c linkding.BookmarkClient, opts syncer.Options) (*syncer.Summary, error) {
+	summary, err := syncer.Sync(ctx, c, s, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []linkding.Tag{}
+	for tag, err := range linkding.AllTags(ctx, c, linkding.ListTagsParams{}) {
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(tagsBucket); err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucket(tagsBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, tag := range tags {
+			if err := putJSON(bucket, []byte(tag.Name), tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// Get returns the item with the given URL, and whether it exists.
+func (s *Store) Get(url string) (syncer.Item, bool, error) {
+	var item syncer.Item
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bookmarksBucket).Get([]byte(url))
+		if data == nil {
+			return nil
+		}
+
+		found = true
+		return json.Unmarshal(data, &item)
+	})
+
+	return item, found, err
+}
+
+// Put creates or replaces the item with the URL of item.
+func (s *Store) Put(item syncer.Item) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(bookmarksBucket), []byte(item.URL), item)
+	})
+}
+
+// Delete removes the item with the given URL.
+func (s *Store) Delete(url string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bookmarksBucket).Delete([]byte(url))
+	})
+}
+
+// Items returns every item, including items deleted locally that have not
+// been synced yet, sorted by URL.
+func (s *Store) Items() ([]syncer.Item, error) {
+	return s.items(func(syncer.Item) bool { return true })
+}
+
+// Watermark returns the watermark of the last sync.
+func (s *Store) Watermark() (time.Time, error) {
+	var watermark time.Time
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(metaBucket).Get(watermarkKey)
+		if data == nil {
+			return nil
+		}
+		return watermark.UnmarshalText(data)
+	})
+
+	return watermark, err
+}
+
+// SetWatermark stores the watermark for the next sync.
+func (s *Store) SetWatermark(watermark time.Time) error {
+	data, err := watermark.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(watermarkKey, data)
+	})
+}
+
+// Bookmarks returns the bookmarks for which match returns true, skipping
+// bookmarks deleted locally, sorted by URL.
+func (s *Store) Bookmarks(match func(linkding.Bookmark) bool) ([]linkding.Bookmark, error) {
+	items, err := s.items(func(item syncer.Item) bool {
+		return !item.Deleted && match(item.Bookmark)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks := make([]linkding.Bookmark, len(items))
+	for i, item := range items {
+		bookmarks[i] = item.Bookmark
+	}
+
+	return bookmarks, nil
+}
+
+// Search returns the bookmarks matching a query. Words prefixed with "#" match
+// tags, other words must occur in the URL, title, description or notes. All
+// words must match, ignoring case.
+func (s *Store) Search(query string) ([]linkding.Bookmark, error) {
+	words := strings.Fields(strings.ToLower(query))
+
+	return s.Bookmarks(func(bookmark linkding.Bookmark) bool {
+		text := strings.ToLower(strings.Join([]string{
+			bookmark.URL,
+			bookmark.Title,
+			bookmark.Description,
+			bookmark.Notes,
+			bookmark.WebsiteTitle,
+			bookmark.WebsiteDescription,
+		}, "\n"))
+
+		for _, word := range words {
+			if tag, ok := strings.CutPrefix(word, "#"); ok {
+				if !slices.ContainsFunc(bookmark.TagNames, func(name string) bool { return strings.EqualFold(name, tag) }) {
+					return false
+				}
+			} else if !strings.Contains(text, word) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Tags returns the tags as of the last refresh, sorted by name.
+func (s *Store) Tags() ([]linkding.Tag, error) {
+	tags := []linkding.Tag{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(tagsBucket).ForEach(func(_, data []byte) error {
+			var tag linkding.Tag
+			if err := json.Unmarshal(data, &tag); err != nil {
+				return err
+			}
+
+			tags = append(tags, tag)
+			return nil
+		})
+	})
+
+	return tags, err
+}
+
+func (s *Store) items(match func(syncer.Item) bool) ([]syncer.Item, error) {
+	items := []syncer.Item{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bookmarksBucket).ForEach(func(_, data []byte) error {
+			var item syncer.Item
+			if err := json.Unmarshal(data, &item); err != nil {
+				return err
+			}
+
+			if match(item) {
+				items = append(items, item)
+			}
+			return nil
+		})
+	})
+
+	return items, err
+}
+
+func putJSON(bucket *bolt.Bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return bucket.Put(key, data)
+}
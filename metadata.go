@@ -0,0 +1,213 @@
+package linkding
+
+import (
+	"context"
+	"strings"
+)
+
+// RefreshOptions configures how the metadata of bookmarks is refreshed.
+type RefreshOptions struct {
+	// Replace titles and descriptions that differ from the ones of the page,
+	// instead of only filling empty ones.
+	Overwrite bool
+	// Fetches the metadata of a page when the server returns none, e.g.
+	// because its scraper cannot reach the page. The scrape package provides
+	// a scraper running on the client. Only the server is asked when nil.
+	Scrape func(ctx context.Context, url string) (*Metadata, error)
+}
+
+// RefreshMetadata fetches the current title and description of the page of a
+// bookmark and stores them in the bookmark.
+//
+// The metadata is scraped by the server through the check endpoint, falling
+// back to opts.Scrape. Only empty fields are filled unless opts.Overwrite is
+// set. The website title and description kept by the server cannot be set
+// through the API, so the refreshed values are stored in the title and
+// description of the bookmark, which take precedence over them. The returned
+// bookmark is the unchanged one if there was nothing to update.
+func (c *Client) RefreshMetadata(ctx context.Context, id int, opts RefreshOptions) (*Bookmark, error) {
+	bookmark, err := c.Bookmarks.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.refreshMetadata(ctx, *bookmark, opts)
+}
+
+// RefreshMetadataMatching refreshes the metadata of every bookmark, active or
+// archived, matching params like RefreshMetadata does.
+//
+// Unless opts.Overwrite is set, only bookmarks with an empty title or
+// description are visited. The result holds the bookmark of each visited item,
+// whether it was updated or not. An error is only returned if the matching
+// bookmarks could not be listed.
+func (c *Client) RefreshMetadataMatching(ctx context.Context, params ListBookmarksParams, opts RefreshOptions) (*BulkResult, error) {
+	bookmarks, err := c.collectMatching(ctx, params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.Overwrite {
+		bookmarks = filterBookmarks(bookmarks, func(bookmark Bookmark) bool {
+			return bookmark.Title == "" || bookmark.Description == ""
+		})
+	}
+
+	return c.updateEach(ctx, bookmarks, func(ctx context.Context, bookmark Bookmark) (*Bookmark, error) {
+		return c.refreshMetadata(ctx, bookmark, opts)
+	}), nil
+}
+
+// AutofillOptions configures AutofillDescriptions.
+type AutofillOptions struct {
+	// Also fill empty titles of the bookmarks visited.
+	Titles bool
+	// Only report the changes that would be made, without making them.
+	DryRun bool
+	// Fetches the metadata of a page when the server returns none. See
+	// RefreshOptions.
+	Scrape func(ctx context.Context, url string) (*Metadata, error)
+}
+
+// AutofillChange is a change to a bookmark made, or proposed in a dry run, by
+// AutofillDescriptions.
+type AutofillChange struct {
+	// The bookmark before the change.
+	Bookmark Bookmark
+	// The new title and description, empty if the field is not changed.
+	Title       string
+	Description string
+}
+
+// AutofillReport is the outcome of AutofillDescriptions.
+type AutofillReport struct {
+	// The bookmarks filled, or that would be filled in a dry run, in the order
+	// they were listed.
+	Changes []AutofillChange
+	// The number of bookmarks without a description for which no metadata
+	// was found.
+	Unchanged int
+	// The reasons fetching the metadata or updating a bookmark failed, by
+	// bookmark ID. Failed updates are not part of Changes.
+	Failed map[int]error
+}
+
+// AutofillDescriptions fills the empty descriptions of every bookmark, active
+// or archived, matching params with the description of its page, and its title
+// if it is empty too and opts.Titles is set. Bookmarks that have a description
+// are left alone.
+//
+// This is typically needed after importing bookmarks from a service that did
+// not keep descriptions. A dry run reports the changes without making them,
+// so they can be previewed first:
+//
+//	report, err := client.AutofillDescriptions(ctx, params, linkding.AutofillOptions{DryRun: true})
+//	for _, change := range report.Changes {
+//		fmt.Printf("%s: %q\n", change.Bookmark.URL, change.Description)
+//	}
+//
+// An error is only returned if the matching bookmarks could not be listed.
+func (c *Client) AutofillDescriptions(ctx context.Context, params ListBookmarksParams, opts AutofillOptions) (*AutofillReport, error) {
+	bookmarks, err := c.collectMatching(ctx, params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks = filterBookmarks(bookmarks, func(bookmark Bookmark) bool {
+		return bookmark.Description == ""
+	})
+
+	changes := make([]*AutofillChange, len(bookmarks))
+	errs := runPool(ctx, len(bookmarks), c.concurrency, func(ctx context.Context, i int) error {
+		bookmark := bookmarks[i]
+		metadata, err := c.fetchMetadata(ctx, bookmark.URL, opts.Scrape)
+		if err != nil {
+			return err
+		}
+
+		patch, changed := metadataPatch(bookmark, metadata, false)
+		if !opts.Titles {
+			patch.Title = nil
+			changed = patch.Description != nil
+		}
+		if !changed {
+			return nil
+		}
+
+		if !opts.DryRun {
+			if _, err := c.Bookmarks.Patch(ctx, bookmark.ID, patch); err != nil {
+				return err
+			}
+		}
+
+		change := &AutofillChange{Bookmark: bookmark}
+		if patch.Title != nil {
+			change.Title = *patch.Title
+		}
+		if patch.Description != nil {
+			change.Description = *patch.Description
+		}
+		changes[i] = change
+
+		return nil
+	})
+
+	report := &AutofillReport{Changes: []AutofillChange{}, Failed: map[int]error{}}
+	for i, err := range errs {
+		switch {
+		case err != nil:
+			report.Failed[bookmarks[i].ID] = err
+		case changes[i] != nil:
+			report.Changes = append(report.Changes, *changes[i])
+		default:
+			report.Unchanged++
+		}
+	}
+
+	return report, nil
+}
+
+func (c *Client) refreshMetadata(ctx context.Context, bookmark Bookmark, opts RefreshOptions) (*Bookmark, error) {
+	metadata, err := c.fetchMetadata(ctx, bookmark.URL, opts.Scrape)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, changed := metadataPatch(bookmark, metadata, opts.Overwrite)
+	if !changed {
+		return &bookmark, nil
+	}
+
+	return c.Bookmarks.Patch(ctx, bookmark.ID, patch)
+}
+
+// metadataPatch returns the changes filling the title and description of a
+// bookmark from the metadata of its page, and whether there are any. Fields
+// that are not empty are only replaced if overwrite is set.
+func metadataPatch(bookmark Bookmark, metadata *Metadata, overwrite bool) (PatchBookmarkRequest, bool) {
+	patch := PatchBookmarkRequest{}
+	if title := strings.TrimSpace(metadata.Title); title != "" && title != bookmark.Title && (bookmark.Title == "" || overwrite) {
+		patch.Title = &title
+	}
+	if description := strings.TrimSpace(metadata.Description); description != "" && description != bookmark.Description && (bookmark.Description == "" || overwrite) {
+		patch.Description = &description
+	}
+
+	return patch, patch.Title != nil || patch.Description != nil
+}
+
+// fetchMetadata returns the metadata of the page at url as scraped by the
+// server, or by scrape if the server found neither a title nor a description
+// and scrape is not nil.
+func (c *Client) fetchMetadata(ctx context.Context, url string, scrape func(ctx context.Context, url string) (*Metadata, error)) (*Metadata, error) {
+	check, err := c.Bookmarks.Check(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if check.Metadata.Title != "" || check.Metadata.Description != "" || scrape == nil {
+		return &check.Metadata, nil
+	}
+
+	return scrape(ctx, url)
+}
@@ -0,0 +1,156 @@
+// Package mirror copies the bookmarks of one Linkding instance to another, for
+// example to keep a public read-only copy of a private instance.
+package mirror
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/importer"
+)
+
+// Options configures Mirror. The import options decide what happens to
+// bookmarks that already exist in the destination. Use
+// importer.UpdateDuplicates to keep mirrored bookmarks up to date on every
+// run.
+type Options struct {
+	importer.Options
+	// Copy the complete assets of each bookmark when it is created in the
+	// destination.
+	Assets bool
+	// Selects the bookmarks to copy, e.g. only shared ones. All bookmarks are
+	// copied when nil.
+	Filter func(linkding.Bookmark) bool
+	// Delete bookmarks from the destination whose URL is not copied from the
+	// source.
+	Prune bool
+}
+
+// Report is the outcome of mirroring. The embedded import report covers the
+// copied bookmarks.
+type Report struct {
+	importer.Report
+	// The number of bookmarks deleted, or that would be deleted in a dry run,
+	// from the destination.
+	Deleted int
+	// The bookmarks that could not be deleted, by URL, with the reason.
+	DeleteErrors map[string]error
+}
+
+// Mirror copies every bookmark of src, active and archived, to dst, keeping
+// its tags, notes and flags. Bookmarks are matched by URL, so running it
+// again only copies what is new.
+//
+// An error is only returned if the bookmarks of either instance could not be
+// listed or ctx is done. Bookmarks that fail to copy or delete are reported in
+// the returned report.
+func Mirror(ctx context.Context, src, dst linkding.BookmarkClient, opts Options) (*Report, error) {
+	records := []importer.Record{}
+	urls := map[string]bool{}
+
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, src, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return nil, err
+		}
+		if opts.Filter != nil && !opts.Filter(bookmark) {
+			continue
+		}
+
+		record, err := newRecord(ctx, src, bookmark, opts.Assets)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+		urls[bookmark.URL] = true
+	}
+
+	imported, err := importer.Import(ctx, dst, records, opts.Options)
+	report := &Report{DeleteErrors: map[string]error{}}
+	if imported != nil {
+		report.Report = *imported
+	}
+	if err != nil || !opts.Prune {
+		return report, err
+	}
+
+	return report, prune(ctx, dst, urls, opts.DryRun, report)
+}
+
+func newRecord(ctx context.Context, src linkding.BookmarkClient, bookmark linkding.Bookmark, withAssets bool) (importer.Record, error) {
+	record := importer.Record{
+		URL:         bookmark.URL,
+		Title:       bookmark.Title,
+		Description: bookmark.Description,
+		Notes:       bookmark.Notes,
+		Tags:        bookmark.TagNames,
+		Unread:      bookmark.Unread,
+		Shared:      bookmark.Shared,
+		Archived:    bookmark.IsArchived,
+		DateAdded:   bookmark.DateAdded,
+	}
+	if !withAssets {
+		return record, nil
+	}
+
+	for asset, err := range linkding.AllBookmarkAssets(ctx, src, bookmark.ID, linkding.ListBookmarkAssetsParams{}) {
+		if err != nil {
+			return record, fmt.Errorf("bookmark %d: %w", bookmark.ID, err)
+		}
+		if asset.Status != linkding.AssetStatusComplete {
+			continue
+		}
+
+		record.Assets = append(record.Assets, importer.Asset{
+			Name: assetName(asset),
+			Open: func() (io.ReadCloser, error) {
+				return src.DownloadBookmarkAsset(asset.Bookmark, asset.ID)
+			},
+		})
+	}
+
+	return record, nil
+}
+
+// assetName returns the file name an asset is uploaded with. Snapshots have no
+// file name, so they are named after their ID with an extension the server
+// recognizes as HTML.
+func assetName(asset linkding.BookmarkAsset) string {
+	name := cmp.Or(asset.DisplayName, fmt.Sprint(asset.ID))
+	if asset.AssetType == linkding.AssetTypeSnapshot && path.Ext(name) == "" {
+		name += ".html"
+	}
+
+	return name
+}
+
+func prune(ctx context.Context, dst linkding.BookmarkClient, urls map[string]bool, dryRun bool, report *Report) error {
+	stale := []linkding.Bookmark{}
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, dst, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return err
+		}
+		if !urls[bookmark.URL] {
+			stale = append(stale, bookmark)
+		}
+	}
+
+	for _, bookmark := range stale {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if !dryRun {
+			if err := dst.DeleteBookmark(bookmark.ID); err != nil {
+				report.DeleteErrors[bookmark.URL] = err
+				continue
+			}
+		}
+		report.Deleted++
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package linkding
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// DefaultTrackingParams are the query parameters removed by
+// DefaultURLNormalizer. They are added by ad networks and newsletters to track
+// where a visitor came from, and do not change the page.
+var DefaultTrackingParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"mc_cid",
+	"mc_eid",
+	"igshid",
+	"_hsenc",
+	"_hsmi",
+}
+
+// DefaultURLNormalizer removes DefaultTrackingParams and fragments.
+var DefaultURLNormalizer = URLNormalizer{StripParams: DefaultTrackingParams}
+
+// URLNormalizer rewrites URLs so that variants of the same address collapse
+// into one bookmark. Hosts are always lowercased, and default ports are
+// removed.
+type URLNormalizer struct {
+	// The query parameters removed. A name ending with "*" removes every
+	// parameter starting with the rest of the name.
+	StripParams []string
+	// Keep the fragment of URLs, which is removed by default. Some single-page
+	// applications use fragments for routing.
+	KeepFragment bool
+}
+
+// Normalize returns the normalized form of rawURL. URLs that cannot be parsed
+// or are not absolute are returned unchanged, and left for the server to
+// reject.
+func (n URLNormalizer) Normalize(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return rawURL
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	u.Host = host
+
+	if !n.KeepFragment {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+
+	// The query is filtered in place rather than decoded and encoded again,
+	// so the order and escaping of the remaining parameters are kept.
+	if u.RawQuery != "" && len(n.StripParams) > 0 {
+		params := strings.Split(u.RawQuery, "&")
+		kept := params[:0]
+		for _, param := range params {
+			name, _, _ := strings.Cut(param, "=")
+			if unescaped, err := url.QueryUnescape(name); err == nil {
+				name = unescaped
+			}
+			if param != "" && !n.strips(name) {
+				kept = append(kept, param)
+			}
+		}
+		u.RawQuery = strings.Join(kept, "&")
+	}
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+func (n URLNormalizer) strips(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range n.StripParams {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NormalizeURL returns the normalized form of rawURL using
+// DefaultURLNormalizer.
+func NormalizeURL(rawURL string) string {
+	return DefaultURLNormalizer.Normalize(rawURL)
+}
+
+// normalizeURL applies the normalizer of the client, if any.
+func (c *Client) normalizeURL(rawURL string) string {
+	if c.urlNormalizer == nil {
+		return rawURL
+	}
+
+	return c.urlNormalizer.Normalize(rawURL)
+}
@@ -0,0 +1,414 @@
+// Package offline queues changes to bookmarks while the Linkding server is
+// unreachable and replays them in order once it is back.
+//
+// Client wraps another client. Reads are passed through, while changes that
+// fail because the server cannot be reached are queued and reported with
+// ErrQueued. Replay applies the queued changes, skipping those that conflict
+// with changes made on the server in the meantime.
+package offline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// ErrQueued is returned when a change was queued, either because the server
+// could not be reached, in which case it wraps the error of the request, or
+// because earlier changes are still queued.
+var ErrQueued = errors.New("offline: change queued for replay")
+
+// Kind is the kind of change of a queued operation.
+type Kind string
+
+const (
+	KindCreate    Kind = "create"
+	KindUpdate    Kind = "update"
+	KindPatch     Kind = "patch"
+	KindArchive   Kind = "archive"
+	KindUnarchive Kind = "unarchive"
+	KindDelete    Kind = "delete"
+)
+
+// Op is a queued change.
+type Op struct {
+	// The position of the operation in the queue.
+	Seq  int64 `json:"seq"`
+	Kind Kind  `json:"kind"`
+	// The bookmark changed, zero for creations.
+	BookmarkID int                             `json:"bookmark_id,omitempty"`
+	Create     *linkding.CreateBookmarkRequest `json:"create,omitempty"`
+	Patch      *linkding.PatchBookmarkRequest  `json:"patch,omitempty"`
+	// The modification date of the bookmark last seen by the client when the
+	// change was made. It is zero if the bookmark was not seen, in which case
+	// conflicts cannot be detected.
+	Base     time.Time `json:"base"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// Conflict is a queued change to a bookmark that was modified on the server
+// after the change was made, or a queued creation of a URL that was
+// bookmarked on the server in the meantime.
+type Conflict struct {
+	Op Op
+	// The bookmark as currently stored by the server.
+	Current linkding.Bookmark
+}
+
+// Client is a linkding.BookmarkClient that queues changes while the server is
+// unreachable. It is safe for concurrent use if the wrapped client is.
+type Client struct {
+	linkding.BookmarkClient
+
+	queue   Queue
+	queueMu sync.Mutex
+
+	mu       sync.Mutex
+	modified map[int]time.Time
+}
+
+var _ linkding.BookmarkClient = (*Client)(nil)
+
+// NewClient returns a client that passes requests to c and queues failed
+// changes in queue.
+func NewClient(c linkding.BookmarkClient, queue Queue) *Client {
+	return &Client{BookmarkClient: c, queue: queue, modified: map[int]time.Time{}}
+}
+
+// ListBookmarks lists the bookmarks that are not archived, remembering their
+// modification dates to detect conflicts.
+func (c *Client) ListBookmarks(params linkding.ListBookmarksParams) (*linkding.ListBookmarksResponse, error) {
+	response, err := c.BookmarkClient.ListBookmarks(params)
+	if err == nil {
+		c.see(response.Results...)
+	}
+	return response, err
+}
+
+// ListArchivedBookmarks lists the archived bookmarks, remembering their
+// modification dates to detect conflicts.
+func (c *Client) ListArchivedBookmarks(params linkding.ListBookmarksParams) (*linkding.ListBookmarksResponse, error) {
+	response, err := c.BookmarkClient.ListArchivedBookmarks(params)
+	if err == nil {
+		c.see(response.Results...)
+	}
+	return response, err
+}
+
+// GetBookmark retrieves a bookmark, remembering its modification date to
+// detect conflicts.
+func (c *Client) GetBookmark(id int) (*linkding.Bookmark, error) {
+	bookmark, err := c.BookmarkClient.GetBookmark(id)
+	if err == nil {
+		c.see(*bookmark)
+	}
+	return bookmark, err
+}
+
+// CreateBookmark creates a bookmark, or queues its creation if the server
+// cannot be reached.
+func (c *Client) CreateBookmark(payload linkding.CreateBookmarkRequest) (*linkding.Bookmark, error) {
+	return c.change(Op{Kind: KindCreate, Create: &payload}, func() (*linkding.Bookmark, error) {
+		return c.BookmarkClient.CreateBookmark(payload)
+	})
+}
+
+// UpdateBookmark updates a bookmark, or queues the update if the server cannot
+// be reached.
+func (c *Client) UpdateBookmark(id int, payload linkding.CreateBookmarkRequest) (*linkding.Bookmark, error) {
+	return c.change(Op{Kind: KindUpdate, BookmarkID: id, Create: &payload}, func() (*linkding.Bookmark, error) {
+		return c.BookmarkClient.UpdateBookmark(id, payload)
+	})
+}
+
+// PatchBookmark partially updates a bookmark, or queues the update if the
+// server cannot be reached.
+func (c *Client) PatchBookmark(id int, payload linkding.PatchBookmarkRequest) (*linkding.Bookmark, error) {
+	return c.change(Op{Kind: KindPatch, BookmarkID: id, Patch: &payload}, func() (*linkding.Bookmark, error) {
+		return c.BookmarkClient.PatchBookmark(id, payload)
+	})
+}
+
+// ArchiveBookmark archives a bookmark, or queues archiving it if the server
+// cannot be reached.
+func (c *Client) ArchiveBookmark(id int) error {
+	_, err := c.change(Op{Kind: KindArchive, BookmarkID: id}, func() (*linkding.Bookmark, error) {
+		return nil, c.BookmarkClient.ArchiveBookmark(id)
+	})
+	return err
+}
+
+// UnarchiveBookmark unarchives a bookmark, or queues unarchiving it if the
+// server cannot be reached.
+func (c *Client) UnarchiveBookmark(id int) error {
+	_, err := c.change(Op{Kind: KindUnarchive, BookmarkID: id}, func() (*linkding.Bookmark, error) {
+		return nil, c.BookmarkClient.UnarchiveBookmark(id)
+	})
+	return err
+}
+
+// DeleteBookmark deletes a bookmark, or queues its deletion if the server
+// cannot be reached.
+func (c *Client) DeleteBookmark(id int) error {
+	_, err := c.change(Op{Kind: KindDelete, BookmarkID: id}, func() (*linkding.Bookmark, error) {
+		return nil, c.BookmarkClient.DeleteBookmark(id)
+	})
+	return err
+}
+
+// Pending returns the queued changes.
+func (c *Client) Pending() ([]Op, error) {
+	return c.queue.Ops()
+}
+
+// change sends a change to the server, queueing it if the server cannot be
+// reached. While earlier changes are queued, changes are queued without being
+// sent, so they are applied in the order they were made.
+func (c *Client) change(op Op, send func() (*linkding.Bookmark, error)) (*linkding.Bookmark, error) {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	ops, err := c.queue.Ops()
+	if err != nil {
+		return nil, err
+	}
+
+	var cause error
+	if len(ops) == 0 {
+		bookmark, err := send()
+		if err == nil {
+			if bookmark != nil {
+				c.see(*bookmark)
+			}
+			return bookmark, nil
+		}
+		if !unreachable(err) {
+			return nil, err
+		}
+		cause = err
+	}
+
+	op.Seq = 1
+	if len(ops) > 0 {
+		op.Seq = ops[len(ops)-1].Seq + 1
+	}
+	op.QueuedAt = time.Now()
+
+	c.mu.Lock()
+	op.Base = c.modified[op.BookmarkID]
+	c.mu.Unlock()
+
+	if err := c.queue.Push(op); err != nil {
+		return nil, errors.Join(cause, err)
+	}
+
+	if cause == nil {
+		return nil, ErrQueued
+	}
+	return nil, fmt.Errorf("%w: %w", ErrQueued, cause)
+}
+
+func (c *Client) see(bookmarks ...linkding.Bookmark) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, bookmark := range bookmarks {
+		c.modified[bookmark.ID] = bookmark.DateModified
+	}
+}
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// Decides whether a conflicting change is applied anyway. Conflicting
+	// changes are skipped when nil.
+	Overwrite func(Conflict) bool
+}
+
+// ReplayReport is the outcome of a replay.
+type ReplayReport struct {
+	// The number of changes applied.
+	Applied int
+	// The conflicting changes that were skipped.
+	Conflicts []Conflict
+	// The changes rejected by the server, with the reason.
+	Failed map[int64]error
+	// The number of changes left in the queue because the server became
+	// unreachable again.
+	Remaining int
+}
+
+// Replay applies the queued changes in order, removing each from the queue
+// once it has been applied, skipped or rejected by the server.
+//
+// Replay stops when the server cannot be reached, leaving the remaining
+// changes queued. Changes made while replaying wait for it to finish. An
+// error is only returned if the queue cannot be read or written or ctx is
+// done.
+func (c *Client) Replay(ctx context.Context, opts ReplayOptions) (*ReplayReport, error) {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	ops, err := c.queue.Ops()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReplayReport{Conflicts: []Conflict{}, Failed: map[int64]error{}}
+	for i, op := range ops {
+		if err := ctx.Err(); err != nil {
+			report.Remaining = len(ops) - i
+			return report, err
+		}
+
+		conflict, err := c.conflict(op)
+		if err == nil {
+			if conflict != nil && (opts.Overwrite == nil || !opts.Overwrite(*conflict)) {
+				report.Conflicts = append(report.Conflicts, *conflict)
+			} else {
+				var bookmark *linkding.Bookmark
+				bookmark, err = c.apply(op)
+				if err == nil {
+					report.Applied++
+					if err := c.rebase(ops[i+1:], op, bookmark); err != nil {
+						return report, err
+					}
+				}
+			}
+		}
+
+		if unreachable(err) {
+			report.Remaining = len(ops) - i
+			return report, nil
+		}
+		if err != nil {
+			report.Failed[op.Seq] = err
+		}
+
+		if err := c.queue.Remove(op.Seq); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// conflict returns the conflict of op with the current state of the server,
+// or nil if there is none.
+func (c *Client) conflict(op Op) (*Conflict, error) {
+	if op.Kind == KindCreate {
+		check, err := c.BookmarkClient.CheckBookmark(op.Create.URL)
+		if err != nil || check.Bookmark == nil {
+			return nil, err
+		}
+		return &Conflict{Op: op, Current: *check.Bookmark}, nil
+	}
+
+	if op.Base.IsZero() {
+		return nil, nil
+	}
+
+	current, err := c.BookmarkClient.GetBookmark(op.BookmarkID)
+	if errors.Is(err, linkding.ErrNotFound) && op.Kind == KindDelete {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if current.DateModified.After(op.Base) {
+		return &Conflict{Op: op, Current: *current}, nil
+	}
+
+	return nil, nil
+}
+
+// rebase sets the base of the later changes of the bookmark changed by op to
+// its modification date after op was applied, so they do not conflict with
+// op. bookmark is the bookmark returned by the server, if any.
+func (c *Client) rebase(later []Op, op Op, bookmark *linkding.Bookmark) error {
+	if op.BookmarkID == 0 || op.Kind == KindDelete {
+		return nil
+	}
+	affected := slices.ContainsFunc(later, func(other Op) bool {
+		return other.BookmarkID == op.BookmarkID && !other.Base.IsZero()
+	})
+	if !affected {
+		return nil
+	}
+
+	if bookmark == nil {
+		// Archiving returns no bookmark. Without its modification date the
+		// later changes keep their base, and are reported as conflicts.
+		current, err := c.BookmarkClient.GetBookmark(op.BookmarkID)
+		if err != nil {
+			return nil
+		}
+		c.see(*current)
+		bookmark = current
+	}
+
+	for i := range later {
+		if later[i].BookmarkID == op.BookmarkID && !later[i].Base.IsZero() {
+			later[i].Base = bookmark.DateModified
+			if err := c.queue.Update(later[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) apply(op Op) (*linkding.Bookmark, error) {
+	var bookmark *linkding.Bookmark
+	var err error
+
+	switch op.Kind {
+	case KindCreate:
+		bookmark, err = c.BookmarkClient.CreateBookmark(*op.Create)
+	case KindUpdate:
+		bookmark, err = c.BookmarkClient.UpdateBookmark(op.BookmarkID, *op.Create)
+	case KindPatch:
+		bookmark, err = c.BookmarkClient.PatchBookmark(op.BookmarkID, *op.Patch)
+	case KindArchive:
+		err = c.BookmarkClient.ArchiveBookmark(op.BookmarkID)
+	case KindUnarchive:
+		err = c.BookmarkClient.UnarchiveBookmark(op.BookmarkID)
+	case KindDelete:
+		err = c.BookmarkClient.DeleteBookmark(op.BookmarkID)
+		if errors.Is(err, linkding.ErrNotFound) {
+			err = nil
+		}
+	default:
+		err = fmt.Errorf("offline: unknown kind %q", op.Kind)
+	}
+
+	if bookmark != nil {
+		c.see(*bookmark)
+	}
+
+	return bookmark, err
+}
+
+// unreachable reports whether err means the request did not reach the server,
+// as opposed to being rejected by it. Requests failed fast by the circuit
+// breaker of a linkding.Client were not sent either.
+func unreachable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, linkding.ErrCircuitOpen) {
+		return true
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+
+	return errors.As(err, &urlErr) || errors.As(err, &netErr)
+}
@@ -0,0 +1,389 @@
+package offline_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/linkdingtest"
+	"github.com/larcher/go-linkding/offline"
+)
+
+// flaky is a fake server that can be taken offline. While it is down, every
+// request fails like a request to an unreachable server.
+type flaky struct {
+	*linkdingtest.Fake
+
+	down bool
+	// The number of requests answered before going down, unlimited if
+	// negative.
+	budget int
+}
+
+func newFlaky() *flaky {
+	fake := linkdingtest.NewFake()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake.Now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	return &flaky{Fake: fake, budget: -1}
+}
+
+func (f *flaky) err() error {
+	if f.budget == 0 {
+		f.down = true
+	}
+	if f.down {
+		return &url.Error{Op: "Get", URL: "http://linkding.test", Err: syscall.ECONNREFUSED}
+	}
+	if f.budget > 0 {
+		f.budget--
+	}
+	return nil
+}
+
+func (f *flaky) GetBookmark(id int) (*linkding.Bookmark, error) {
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	return f.Fake.GetBookmark(id)
+}
+
+func (f *flaky) CheckBookmark(bookmarkURL string) (*linkding.CheckBookmarkResponse, error) {
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	return f.Fake.CheckBookmark(bookmarkURL)
+}
+
+func (f *flaky) CreateBookmark(payload linkding.CreateBookmarkRequest) (*linkding.Bookmark, error) {
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	return f.Fake.CreateBookmark(payload)
+}
+
+func (f *flaky) UpdateBookmark(id int, payload linkding.CreateBookmarkRequest) (*linkding.Bookmark, error) {
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	return f.Fake.UpdateBookmark(id, payload)
+}
+
+func (f *flaky) PatchBookmark(id int, payload linkding.PatchBookmarkRequest) (*linkding.Bookmark, error) {
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	return f.Fake.PatchBookmark(id, payload)
+}
+
+func (f *flaky) ArchiveBookmark(id int) error {
+	if err := f.err(); err != nil {
+		return err
+	}
+	return f.Fake.ArchiveBookmark(id)
+}
+
+func (f *flaky) DeleteBookmark(id int) error {
+	if err := f.err(); err != nil {
+		return err
+	}
+	return f.Fake.DeleteBookmark(id)
+}
+
+func TestChangesPassThroughWhileOnline(t *testing.T) {
+	server := newFlaky()
+	c := offline.NewClient(server, &offline.MemoryQueue{})
+
+	bookmark, err := c.CreateBookmark(linkding.NewBookmark("https://go.dev").Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.Fake.GetBookmark(bookmark.ID); err != nil {
+		t.Errorf("bookmark not created on server: %v", err)
+	}
+	if ops := pending(t, c); len(ops) != 0 {
+		t.Errorf("queued %d changes, want none", len(ops))
+	}
+}
+
+func TestRejectedChangesAreNotQueued(t *testing.T) {
+	c := offline.NewClient(newFlaky(), &offline.MemoryQueue{})
+
+	if _, err := c.CreateBookmark(linkding.CreateBookmarkRequest{TagNames: []string{}}); !errors.Is(err, linkding.ErrBadRequest) {
+		t.Fatalf("error = %v, want ErrBadRequest", err)
+	}
+	if ops := pending(t, c); len(ops) != 0 {
+		t.Errorf("queued %d changes, want none", len(ops))
+	}
+}
+
+func TestQueueAndReplay(t *testing.T) {
+	server := newFlaky()
+	c := offline.NewClient(server, &offline.MemoryQueue{})
+	existing := seen(t, server, c, "https://go.dev")
+
+	server.down = true
+	if _, err := c.CreateBookmark(linkding.NewBookmark("https://pkg.go.dev").Build()); !errors.Is(err, offline.ErrQueued) {
+		t.Fatalf("create error = %v, want ErrQueued", err)
+	}
+	server.down = false
+
+	// Changes made while others are queued are queued behind them, even
+	// though the server is back.
+	if err := c.DeleteBookmark(existing.ID); !errors.Is(err, offline.ErrQueued) {
+		t.Fatalf("delete error = %v, want ErrQueued", err)
+	}
+	if _, err := server.Fake.GetBookmark(existing.ID); err != nil {
+		t.Fatalf("bookmark deleted before replay: %v", err)
+	}
+
+	ops := pending(t, c)
+	if len(ops) != 2 || ops[0].Kind != offline.KindCreate || ops[1].Kind != offline.KindDelete {
+		t.Fatalf("queued %+v, want a create and a delete", ops)
+	}
+
+	report := replay(t, c, offline.ReplayOptions{})
+	if report.Applied != 2 || report.Remaining != 0 {
+		t.Errorf("report = %+v, want 2 applied", report)
+	}
+	if check, _ := server.Fake.CheckBookmark("https://pkg.go.dev"); check.Bookmark == nil {
+		t.Error("queued bookmark not created")
+	}
+	if _, err := server.Fake.GetBookmark(existing.ID); !errors.Is(err, linkding.ErrNotFound) {
+		t.Errorf("GetBookmark error = %v, want ErrNotFound", err)
+	}
+	if ops := pending(t, c); len(ops) != 0 {
+		t.Errorf("%d changes left in queue", len(ops))
+	}
+}
+
+func TestReplayConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		overwrite bool
+		want      string
+	}{
+		{"skipped", false, "Server"},
+		{"overwritten", true, "Queued"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFlaky()
+			c := offline.NewClient(server, &offline.MemoryQueue{})
+			bookmark := seen(t, server, c, "https://go.dev")
+
+			server.down = true
+			patchTitle(t, c, bookmark.ID, "Queued")
+			server.down = false
+
+			title := "Server"
+			if _, err := server.Fake.PatchBookmark(bookmark.ID, linkding.PatchBookmarkRequest{Title: &title}); err != nil {
+				t.Fatal(err)
+			}
+
+			var conflicts []offline.Conflict
+			report := replay(t, c, offline.ReplayOptions{Overwrite: func(conflict offline.Conflict) bool {
+				conflicts = append(conflicts, conflict)
+				return tt.overwrite
+			}})
+			if len(conflicts) != 1 || conflicts[0].Current.Title != "Server" {
+				t.Errorf("conflicts = %+v, want the server change", conflicts)
+			}
+			// Only skipped conflicts are reported.
+			if skipped := len(report.Conflicts) == 1; skipped == tt.overwrite {
+				t.Errorf("reported conflicts = %+v", report.Conflicts)
+			}
+			if got := serverTitle(t, server, bookmark.ID); got != tt.want {
+				t.Errorf("title = %q, want %q", got, tt.want)
+			}
+			if ops := pending(t, c); len(ops) != 0 {
+				t.Errorf("%d changes left in queue", len(ops))
+			}
+		})
+	}
+}
+
+func TestReplayCreateConflict(t *testing.T) {
+	server := newFlaky()
+	c := offline.NewClient(server, &offline.MemoryQueue{})
+
+	server.down = true
+	if _, err := c.CreateBookmark(linkding.NewBookmark("https://go.dev").Title("Queued").Build()); !errors.Is(err, offline.ErrQueued) {
+		t.Fatalf("error = %v, want ErrQueued", err)
+	}
+	server.down = false
+	if _, err := server.Fake.CreateBookmark(linkding.NewBookmark("https://go.dev").Title("Server").Build()); err != nil {
+		t.Fatal(err)
+	}
+
+	report := replay(t, c, offline.ReplayOptions{})
+	if len(report.Conflicts) != 1 || report.Applied != 0 {
+		t.Errorf("report = %+v, want the creation skipped as a conflict", report)
+	}
+}
+
+func TestReplayRebasesLaterChanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		first func(t *testing.T, c *offline.Client, id int)
+	}{
+		{"patch", func(t *testing.T, c *offline.Client, id int) {
+			patchTitle(t, c, id, "First")
+		}},
+		{"archive", func(t *testing.T, c *offline.Client, id int) {
+			if err := c.ArchiveBookmark(id); !errors.Is(err, offline.ErrQueued) {
+				t.Fatalf("error = %v, want ErrQueued", err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFlaky()
+			c := offline.NewClient(server, &offline.MemoryQueue{})
+			bookmark := seen(t, server, c, "https://go.dev")
+
+			server.down = true
+			tt.first(t, c, bookmark.ID)
+			patchTitle(t, c, bookmark.ID, "Second")
+			server.down = false
+
+			report := replay(t, c, offline.ReplayOptions{})
+			if report.Applied != 2 || len(report.Conflicts) != 0 {
+				t.Errorf("report = %+v, want both changes applied without conflicts", report)
+			}
+			if got := serverTitle(t, server, bookmark.ID); got != "Second" {
+				t.Errorf("title = %q, want %q", got, "Second")
+			}
+		})
+	}
+}
+
+func TestReplayRecordsRejectedChanges(t *testing.T) {
+	server := newFlaky()
+	c := offline.NewClient(server, &offline.MemoryQueue{})
+
+	server.down = true
+	if _, err := c.UpdateBookmark(42, linkding.NewBookmark("https://go.dev").Build()); !errors.Is(err, offline.ErrQueued) {
+		t.Fatalf("error = %v, want ErrQueued", err)
+	}
+	server.down = false
+
+	report := replay(t, c, offline.ReplayOptions{})
+	if len(report.Failed) != 1 || !errors.Is(report.Failed[1], linkding.ErrNotFound) {
+		t.Errorf("failed = %v, want the update rejected with ErrNotFound", report.Failed)
+	}
+	if ops := pending(t, c); len(ops) != 0 {
+		t.Errorf("%d changes left in queue", len(ops))
+	}
+}
+
+func TestReplayStopsWhenUnreachable(t *testing.T) {
+	server := newFlaky()
+	c := offline.NewClient(server, &offline.MemoryQueue{})
+
+	server.down = true
+	for _, u := range []string{"https://go.dev", "https://pkg.go.dev", "https://go.dev/blog"} {
+		if _, err := c.CreateBookmark(linkding.NewBookmark(u).Build()); !errors.Is(err, offline.ErrQueued) {
+			t.Fatalf("error = %v, want ErrQueued", err)
+		}
+	}
+	// The first creation is checked and sent, then the server goes down.
+	server.down = false
+	server.budget = 2
+
+	report := replay(t, c, offline.ReplayOptions{})
+	if report.Applied != 1 || report.Remaining != 2 {
+		t.Errorf("report = %+v, want 1 applied and 2 remaining", report)
+	}
+	if ops := pending(t, c); len(ops) != 2 || ops[0].Create.URL != "https://pkg.go.dev" {
+		t.Errorf("queued %+v, want the last two creations", ops)
+	}
+}
+
+func TestFileQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	queue := offline.NewFileQueue(path)
+
+	for seq := int64(1); seq <= 3; seq++ {
+		if err := queue.Push(offline.Op{Seq: seq, Kind: offline.KindDelete, BookmarkID: int(seq)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := queue.Remove(2); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := queue.Update(offline.Op{Seq: 3, Kind: offline.KindDelete, BookmarkID: 3, Base: base}); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := offline.NewFileQueue(path).Ops()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 2 || ops[0].Seq != 1 || ops[1].Seq != 3 || !ops[1].Base.Equal(base) {
+		t.Errorf("ops = %+v, want 1 and the updated 3", ops)
+	}
+}
+
+// seen creates a bookmark on the server and reads it through c, so its
+// changes are checked for conflicts.
+func seen(t *testing.T, server *flaky, c *offline.Client, url string) *linkding.Bookmark {
+	t.Helper()
+
+	created, err := server.Fake.CreateBookmark(linkding.NewBookmark(url).Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bookmark, err := c.GetBookmark(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bookmark
+}
+
+func patchTitle(t *testing.T, c *offline.Client, id int, title string) {
+	t.Helper()
+
+	if _, err := c.PatchBookmark(id, linkding.PatchBookmarkRequest{Title: &title}); !errors.Is(err, offline.ErrQueued) {
+		t.Fatalf("error = %v, want ErrQueued", err)
+	}
+}
+
+func serverTitle(t *testing.T, server *flaky, id int) string {
+	t.Helper()
+
+	bookmark, err := server.Fake.GetBookmark(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bookmark.Title
+}
+
+func pending(t *testing.T, c *offline.Client) []offline.Op {
+	t.Helper()
+
+	ops, err := c.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ops
+}
+
+func replay(t *testing.T, c *offline.Client, opts offline.ReplayOptions) *offline.ReplayReport {
+	t.Helper()
+
+	report, err := c.Replay(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
@@ -0,0 +1,169 @@
+package offline
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+)
+
+// Queue holds the operations waiting to be replayed, in order.
+type Queue interface {
+	// Push appends an operation to the queue.
+	Push(op Op) error
+	// Ops returns the queued operations in the order they were pushed.
+	Ops() ([]Op, error)
+	// Remove removes the operation with the given sequence number.
+	Remove(seq int64) error
+	// Update replaces the operation with the same sequence number, keeping
+	// its position.
+	Update(op Op) error
+}
+
+// MemoryQueue is a Queue that keeps operations in memory, so they are lost
+// when the process exits. It is safe for concurrent use.
+type MemoryQueue struct {
+	mu  sync.Mutex
+	ops []Op
+}
+
+var _ Queue = (*MemoryQueue)(nil)
+
+// Push appends an operation to the queue.
+func (q *MemoryQueue) Push(op Op) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.ops = append(q.ops, op)
+	return nil
+}
+
+// Ops returns the queued operations.
+func (q *MemoryQueue) Ops() ([]Op, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return slices.Clone(q.ops), nil
+}
+
+// Remove removes the operation with the given sequence number.
+func (q *MemoryQueue) Remove(seq int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.ops = slices.DeleteFunc(q.ops, func(op Op) bool { return op.Seq == seq })
+	return nil
+}
+
+// Update replaces the operation with the same sequence number.
+func (q *MemoryQueue) Update(op Op) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	replace(q.ops, op)
+	return nil
+}
+
+// FileQueue is a Queue persisted as a JSON file, so queued operations survive
+// restarts. The file is replaced atomically on every change. It is safe for
+// concurrent use within one process.
+type FileQueue struct {
+	mu   sync.Mutex
+	path string
+}
+
+var _ Queue = (*FileQueue)(nil)
+
+// NewFileQueue returns a queue stored at path. The file is created when the
+// first operation is pushed.
+func NewFileQueue(path string) *FileQueue {
+	return &FileQueue{path: path}
+}
+
+// Push appends an operation to the queue.
+func (q *FileQueue) Push(op Op) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ops, err := q.read()
+	if err != nil {
+		return err
+	}
+
+	return q.write(append(ops, op))
+}
+
+// Ops returns the queued operations.
+func (q *FileQueue) Ops() ([]Op, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.read()
+}
+
+// Remove removes the operation with the given sequence number.
+func (q *FileQueue) Remove(seq int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ops, err := q.read()
+	if err != nil {
+		return err
+	}
+
+	return q.write(slices.DeleteFunc(ops, func(op Op) bool { return op.Seq == seq }))
+}
+
+// Update replaces the operation with the same sequence number.
+func (q *FileQueue) Update(op Op) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ops, err := q.read()
+	if err != nil {
+		return err
+	}
+	replace(ops, op)
+
+	return q.write(ops)
+}
+
+// replace replaces the operation of ops with the sequence number of op.
+func replace(ops []Op, op Op) {
+	if i := slices.IndexFunc(ops, func(queued Op) bool { return queued.Seq == op.Seq }); i >= 0 {
+		ops[i] = op
+	}
+}
+
+func (q *FileQueue) read() ([]Op, error) {
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Op{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ops := []Op{}
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, err
+	}
+
+	return ops, nil
+}
+
+func (q *FileQueue) write(ops []Op) error {
+	data, err := json.MarshalIndent(ops, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, q.path)
+}
@@ -0,0 +1,113 @@
+package linkding
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// DecodeMode controls how fields of API responses that are unknown to this
+// package are handled.
+type DecodeMode int
+
+const (
+	// DecodeDefault silently ignores unknown fields.
+	DecodeDefault DecodeMode = iota
+	// DecodeStrict fails decoding when a response contains unknown fields,
+	// which helps catching schema drift early, e.g. in integration tests.
+	DecodeStrict
+	// DecodeTolerant collects unknown fields into the Extra field of each
+	// decoded struct, so they can still be accessed by callers.
+	DecodeTolerant
+)
+
+// WithDecodeMode sets how unknown fields in API responses are handled. By
+// default they are ignored.
+func WithDecodeMode(mode DecodeMode) Option {
+	return func(c *Client) {
+		c.decodeMode = mode
+	}
+}
+
+// WithConcurrency sets the number of concurrent requests made by bulk
+// operations that do not take an explicit concurrency. It defaults to
+// DefaultConcurrency.
+func WithConcurrency(concurrency int) Option {
+	return func(c *Client) {
+		c.concurrency = concurrency
+	}
+}
+
+// WithURLNormalizer normalizes the URLs of bookmarks before they are created,
+// updated or checked, so that URLs differing only in tracking parameters or
+// letter case of the host are stored as the same bookmark. Use
+// DefaultURLNormalizer for the common case. URLs are sent unchanged by
+// default.
+func WithURLNormalizer(n URLNormalizer) Option {
+	return func(c *Client) {
+		c.urlNormalizer = &n
+	}
+}
+
+// WithURLExpansion expands short links, e.g. of t.co or bit.ly, before
+// bookmarks are created, so the bookmark points to the actual page. See
+// ExpandURL. Links that cannot be expanded are bookmarked as they are.
+func WithURLExpansion(opts ExpandOptions) Option {
+	return func(c *Client) {
+		c.expand = &opts
+	}
+}
+
+// WithTagNormalizer normalizes the tags of bookmarks before they are created
+// or updated, and of tags created directly. Use DefaultTagNormalizer for the
+// common case, and NormalizeTags to apply it to existing bookmarks. Tags are
+// sent unchanged by default.
+func WithTagNormalizer(n TagNormalizer) Option {
+	return func(c *Client) {
+		c.tagNormalizer = &n
+	}
+}
+
+// WithHeader sends an additional header with every request, e.g. the
+// CF-Access-Client-Id and CF-Access-Client-Secret headers of Cloudflare Access
+// for servers behind such a gateway. Headers given for a single call with
+// WithRequestHeader replace those of the client with the same key. The
+// headers set by the client itself, such as Authorization, cannot be
+// replaced.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		if c.header == nil {
+			c.header = http.Header{}
+		}
+		c.header.Add(key, value)
+	}
+}
+
+// WithRetries sends requests that fail because the server cannot be reached
+// or is unavailable, as reported by a proxy with the status 502, 503 or 504,
+// again up to retries times, waiting longer between each attempt. Only reads
+// are retried, as changes may have been applied even if the request failed.
+// Requests rejected with 429 Too Many Requests are retried whatever their
+// method, as they were not processed, except uploads of assets. The wait
+// asked for by a Retry-After header is honored, up to MaxRetryAfter.
+// Requests are not retried by default.
+func WithRetries(retries int) Option {
+	return func(c *Client) {
+		c.retries = retries
+	}
+}
+
+// RetryFunc is called before a failed request is sent again, with the number
+// of the retry, starting at 1, the time waited before sending it, and the
+// error the request failed with, e.g. ErrTooManyRequests.
+type RetryFunc func(retry int, delay time.Duration, reason error)
+
+// WithRetryNotify reports each retry of a request to fn, e.g. to show "rate
+// limited, retrying in 30s". See WithRetries.
+func WithRetryNotify(fn RetryFunc) Option {
+	return func(c *Client) {
+		c.onRetry = fn
+	}
+}
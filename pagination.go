@@ -0,0 +1,229 @@
+package linkding
+
+import (
+	"context"
+	"iter"
+)
+
+// DefaultPageSize is the number of items requested per page when following
+// pagination without an explicit limit.
+const DefaultPageSize = 100
+
+// AllBookmarks returns an iterator over every bookmark matching params,
+// requesting further pages as needed. The limit of params sets the page size
+// and its offset the position to start from.
+//
+// Iteration stops after yielding the first error. The context is checked
+// before requesting each page, and is also passed along to the requests when
+// c is a *Client.
+//
+// Pages are requested by offset, so bookmarks created or deleted while
+// iterating shift the later pages. Each bookmark is yielded at most once: a
+// bookmark moved onto the next page by a creation is not yielded again.
+// However, a bookmark moved onto an earlier page by a deletion is missed, as
+// are bookmarks created after their position was passed. Callers needing a
+// consistent view should list while the account is not being changed.
+func AllBookmarks(ctx context.Context, c BookmarkClient, params ListBookmarksParams) iter.Seq2[Bookmark, error] {
+	if client, ok := c.(*Client); ok {
+		return bookmarkPages(ctx, params, client.Bookmarks.List)
+	}
+
+	return bookmarkPages(ctx, params, func(_ context.Context, params ListBookmarksParams, _ ...RequestOption) (*ListBookmarksResponse, error) {
+		return c.ListBookmarks(params)
+	})
+}
+
+// AllArchivedBookmarks returns an iterator over every archived bookmark
+// matching params. It behaves like AllBookmarks.
+func AllArchivedBookmarks(ctx context.Context, c BookmarkClient, params ListBookmarksParams) iter.Seq2[Bookmark, error] {
+	if client, ok := c.(*Client); ok {
+		return bookmarkPages(ctx, params, client.Bookmarks.ListArchived)
+	}
+
+	return bookmarkPages(ctx, params, func(_ context.Context, params ListBookmarksParams, _ ...RequestOption) (*ListBookmarksResponse, error) {
+		return c.ListArchivedBookmarks(params)
+	})
+}
+
+// AllBookmarksIncludingArchived returns an iterator over every bookmark
+// matching params, the active ones followed by the archived ones. It behaves
+// like AllBookmarks, and stops after the first error of either list.
+func AllBookmarksIncludingArchived(ctx context.Context, c BookmarkClient, params ListBookmarksParams) iter.Seq2[Bookmark, error] {
+	return func(yield func(Bookmark, error) bool) {
+		for _, all := range []func(context.Context, BookmarkClient, ListBookmarksParams) iter.Seq2[Bookmark, error]{
+			AllBookmarks,
+			AllArchivedBookmarks,
+		} {
+			for bookmark, err := range all(ctx, c, params) {
+				if !yield(bookmark, err) || err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ListAllBookmarks retrieves every bookmark matching params, following
+// pagination. Bookmarks are listed once even if changes made meanwhile shift
+// the pages, see AllBookmarks.
+func (c *Client) ListAllBookmarks(params ListBookmarksParams) ([]Bookmark, error) {
+	return collect(AllBookmarks(context.Background(), c, params))
+}
+
+// ListAllArchivedBookmarks retrieves every archived bookmark matching params,
+// following pagination, like ListAllBookmarks.
+func (c *Client) ListAllArchivedBookmarks(params ListBookmarksParams) ([]Bookmark, error) {
+	return collect(AllArchivedBookmarks(context.Background(), c, params))
+}
+
+// AllBookmarkAssets returns an iterator over every asset of a bookmark
+// passing the filters of params, requesting further pages as needed. The
+// limit of params sets the page size and its offset the position to start
+// from. It otherwise behaves like AllBookmarks.
+func AllBookmarkAssets(ctx context.Context, c BookmarkClient, bookmarkID int, params ListBookmarkAssetsParams) iter.Seq2[BookmarkAsset, error] {
+	list := func(_ context.Context, params ListBookmarkAssetsParams) (*ListBookmarkAssetsResponse, error) {
+		return c.ListBookmarkAssets(bookmarkID, params)
+	}
+	if client, ok := c.(*Client); ok {
+		list = func(ctx context.Context, params ListBookmarkAssetsParams) (*ListBookmarkAssetsResponse, error) {
+			return client.Assets.List(ctx, bookmarkID, params)
+		}
+	}
+
+	// Pages are requested unfiltered, so the offsets follow the server.
+	filter := params
+	params.AssetType, params.Status = "", ""
+
+	assets := pages(ctx, params.Limit, params.Offset, func(ctx context.Context, limit, offset int) ([]BookmarkAsset, string, error) {
+		params := params
+		params.Limit, params.Offset = limit, offset
+		page, err := list(ctx, params)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Results, page.Next, nil
+	}, func(asset BookmarkAsset) int { return asset.ID })
+
+	return func(yield func(BookmarkAsset, error) bool) {
+		for asset, err := range assets {
+			if err == nil && !filter.Matches(asset) {
+				continue
+			}
+			if !yield(asset, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ListAllBookmarkAssets retrieves every asset of a bookmark passing the
+// filters of params, following pagination, e.g. the complete snapshots:
+//
+//	client.ListAllBookmarkAssets(id, linkding.ListBookmarkAssetsParams{
+//		AssetType: linkding.AssetTypeSnapshot,
+//		Status:    linkding.AssetStatusComplete,
+//	})
+func (c *Client) ListAllBookmarkAssets(bookmarkID int, params ListBookmarkAssetsParams) ([]BookmarkAsset, error) {
+	return collect(AllBookmarkAssets(context.Background(), c, bookmarkID, params))
+}
+
+// AllTags returns an iterator over every tag, requesting further pages as
+// needed. The limit of params sets the page size and its offset the position
+// to start from. It otherwise behaves like AllBookmarks.
+func AllTags(ctx context.Context, c BookmarkClient, params ListTagsParams) iter.Seq2[Tag, error] {
+	list := func(_ context.Context, params ListTagsParams) (*ListTagsResponse, error) {
+		return c.ListTags(params)
+	}
+	if client, ok := c.(*Client); ok {
+		list = func(ctx context.Context, params ListTagsParams) (*ListTagsResponse, error) {
+			return client.Tags.List(ctx, params)
+		}
+	}
+
+	return pages(ctx, params.Limit, params.Offset, func(ctx context.Context, limit, offset int) ([]Tag, string, error) {
+		params := params
+		params.Limit, params.Offset = limit, offset
+		page, err := list(ctx, params)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Results, page.Next, nil
+	}, func(tag Tag) int { return tag.ID })
+}
+
+// ListAllTags retrieves every tag, following pagination, like
+// ListAllBookmarks.
+func (c *Client) ListAllTags(params ListTagsParams) ([]Tag, error) {
+	return collect(AllTags(context.Background(), c, params))
+}
+
+type listBookmarksFunc func(ctx context.Context, params ListBookmarksParams, opts ...RequestOption) (*ListBookmarksResponse, error)
+
+func bookmarkPages(ctx context.Context, params ListBookmarksParams, list listBookmarksFunc) iter.Seq2[Bookmark, error] {
+	return pages(ctx, params.Limit, params.Offset, func(ctx context.Context, limit, offset int) ([]Bookmark, string, error) {
+		params := params
+		params.Limit, params.Offset = limit, offset
+		page, err := list(ctx, params)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Results, page.Next, nil
+	}, func(bookmark Bookmark) int { return bookmark.ID })
+}
+
+// pages returns an iterator over the items of the pages returned by list,
+// starting at offset and requesting limit items per page, or DefaultPageSize
+// if limit is not positive. Items already yielded, as identified by id, are
+// skipped when a change shifts them onto the next page.
+func pages[T any](ctx context.Context, limit, offset int, list func(ctx context.Context, limit, offset int) (results []T, next string, err error), id func(T) int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if limit <= 0 {
+			limit = DefaultPageSize
+		}
+		seen := map[int]bool{}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			results, next, err := list(ctx, limit, offset)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range results {
+				if seen[id(item)] {
+					continue
+				}
+				seen[id(item)] = true
+
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if next == "" || len(results) == 0 {
+				return
+			}
+			offset += len(results)
+		}
+	}
+}
+
+// collect gathers the values of an iterator, stopping at the first error.
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	items := []T{}
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,87 @@
+package linkding
+
+import (
+	"io"
+	"net/http"
+)
+
+// ProgressFunc is called as the content of an asset is uploaded or
+// downloaded, with the number of bytes transferred so far and the total
+// number of bytes, or -1 if the server did not tell the size of a download.
+// The bytes of uploads include the few hundred bytes of their encoding.
+type ProgressFunc func(bytesTransferred, total int64)
+
+// WithProgress reports the progress of asset uploads and downloads to fn,
+// e.g. to render progress bars for large snapshots. fn is called from the
+// goroutine reading or writing the content, after each chunk.
+func WithProgress(fn ProgressFunc) Option {
+	return func(c *Client) {
+		c.progress = fn
+	}
+}
+
+// trackUpload reports the progress of sending body, if enabled.
+func (c *Client) trackUpload(body requestBody) requestBody {
+	if c.progress == nil {
+		return body
+	}
+
+	return &progressBody{
+		requestBody: body,
+		progress:    progress{fn: c.progress, total: body.Len()},
+	}
+}
+
+// trackDownload reports the progress of reading the body of res, if enabled.
+// A body resuming a download at offset is reported as the rest of the
+// download.
+func (c *Client) trackDownload(res *http.Response, offset int64) io.ReadCloser {
+	if c.progress == nil {
+		return res.Body
+	}
+
+	total := res.ContentLength
+	if total >= 0 {
+		total += offset
+	}
+
+	return &progressReader{
+		ReadCloser: res.Body,
+		progress:   progress{fn: c.progress, transferred: offset, total: total},
+	}
+}
+
+type progress struct {
+	fn          ProgressFunc
+	transferred int64
+	total       int64
+}
+
+func (p *progress) add(n int) {
+	if n > 0 {
+		p.transferred += int64(n)
+		p.fn(p.transferred, p.total)
+	}
+}
+
+type progressBody struct {
+	requestBody
+	progress
+}
+
+func (b *progressBody) Read(p []byte) (int, error) {
+	n, err := b.requestBody.Read(p)
+	b.add(n)
+	return n, err
+}
+
+type progressReader struct {
+	io.ReadCloser
+	progress
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.add(n)
+	return n, err
+}
@@ -0,0 +1,341 @@
+// Package proxy implements a caching reverse proxy for the Linkding API, for
+// dashboards and scripts that poll more often than the server should answer.
+//
+// The proxy exposes the same REST API as the server it sits in front of.
+// Successful GET responses of the list, check, tag and user endpoints are
+// cached for a configurable time, separately for each API token. Any other
+// request, e.g. creating or updating a bookmark, is passed through and
+// invalidates the cached responses of its token, so clients see their own
+// writes. Changes made on the server by other clients, or in Linkding's UI, are
+// only seen once cached responses expire.
+//
+// Responses carry an X-Cache header, HIT or MISS.
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long responses are cached when not configured.
+const DefaultTTL = 30 * time.Second
+
+// DefaultMaxEntries is the number of cached responses when not configured.
+const DefaultMaxEntries = 1000
+
+// DefaultMaxBodySize is the size of the largest cached response body when not
+// configured.
+const DefaultMaxBodySize = 1 << 20
+
+// cacheable matches the paths of the endpoints whose responses are cached.
+var cacheable = regexp.MustCompile(`^/api/(bookmarks/|bookmarks/archived/|bookmarks/check/|bookmarks/\d+/|tags/|tags/\d+/|user/profile/)$`)
+
+// Options configures a Proxy.
+type Options struct {
+	// How long responses are cached. Defaults to DefaultTTL.
+	TTL time.Duration
+	// The number of cached responses. Once reached, responses are not cached
+	// until entries expire. Defaults to DefaultMaxEntries.
+	MaxEntries int
+	// The size of the largest cached response body, larger responses are
+	// passed through. Defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+	// The transport used to send requests to the server. Defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// The API token sent to the server for requests without an Authorization
+	// header, if set. Anyone able to reach the proxy can then use the
+	// server, so it should only listen on a trusted network.
+	Token string
+	// Headers sent with every request to the server, e.g. for a gateway in
+	// front of it. They replace the headers of the same key sent by clients.
+	Header http.Header
+}
+
+// Stats are the number of requests answered by a Proxy.
+type Stats struct {
+	Hits   int64
+	Misses int64
+	// The number of requests invalidating the cache.
+	Writes  int64
+	Entries int
+}
+
+// Proxy is an http.Handler forwarding requests to a Linkding server. It is
+// safe for concurrent use.
+type Proxy struct {
+	opts  Options
+	proxy *httputil.ReverseProxy
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// The cacheable reads forwarded to the server, by credential. A
+	// credential is only tracked while it has reads in flight, so writes
+	// with arbitrary credentials do not grow the map.
+	flights map[string]*flight
+	stats   Stats
+}
+
+type flight struct {
+	// The number of reads in flight.
+	reads int
+	// Incremented by each write, so responses to reads that started before
+	// a write are not cached after it.
+	generation int
+}
+
+type entry struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+type contextKey struct{}
+
+// pending is a cacheable request forwarded to the server.
+type pending struct {
+	key        string
+	flight     *flight
+	generation int
+}
+
+// New returns a proxy for the server at baseURL, e.g. "https://links.example.org".
+func New(baseURL string, opts Options) (*Proxy, error) {
+	target, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	if target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("proxy: invalid server URL %q", baseURL)
+	}
+
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultMaxEntries
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+
+	p := &Proxy{
+		opts:    opts,
+		entries: map[string]*entry{},
+		flights: map[string]*flight{},
+	}
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.Out.Host = target.Host
+			if r.Out.Header.Get("Authorization") == "" && opts.Token != "" {
+				r.Out.Header.Set("Authorization", "Token "+opts.Token)
+			}
+			for key, values := range opts.Header {
+				r.Out.Header[key] = values
+			}
+		},
+		Transport:      opts.Transport,
+		ModifyResponse: p.store,
+	}
+
+	return p, nil
+}
+
+// ServeHTTP answers a request from the cache or forwards it to the server.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	credential := r.Header.Get("Authorization")
+	if credential == "" && p.opts.Token != "" {
+		credential = "Token " + p.opts.Token
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		p.mu.Lock()
+		p.stats.Writes++
+		p.mu.Unlock()
+
+		p.invalidate(credential)
+		// The server may only have applied the write once it answers, so
+		// responses read meanwhile are discarded again afterwards.
+		defer p.invalidate(credential)
+		w.Header().Set("X-Cache", "MISS")
+		p.proxy.ServeHTTP(w, r)
+		return
+	}
+
+	if !cacheable.MatchString(r.URL.Path) {
+		w.Header().Set("X-Cache", "MISS")
+		p.proxy.ServeHTTP(w, r)
+		return
+	}
+
+	// Responses are compressed depending on what the client accepts.
+	key := credential + "\x00" + r.Header.Get("Accept-Encoding") + "\x00" + r.URL.Path + "?" + r.URL.RawQuery
+	p.mu.Lock()
+	e, ok := p.entries[key]
+	if ok && time.Now().After(e.expires) {
+		delete(p.entries, key)
+		ok = false
+	}
+	if ok {
+		p.stats.Hits++
+	} else {
+		p.stats.Misses++
+	}
+	p.mu.Unlock()
+
+	if ok {
+		for name, values := range e.header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("X-Cache", "HIT")
+		w.WriteHeader(e.status)
+		if r.Method == http.MethodGet {
+			w.Write(e.body)
+		}
+		return
+	}
+
+	w.Header().Set("X-Cache", "MISS")
+	if r.Method == http.MethodHead {
+		p.proxy.ServeHTTP(w, r)
+		return
+	}
+	f, generation := p.takeOff(credential)
+	defer p.land(credential, f)
+
+	ctx := context.WithValue(r.Context(), contextKey{}, pending{key, f, generation})
+	p.proxy.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// takeOff records a read of a credential in flight, returning its flight and
+// the current generation.
+func (p *Proxy) takeOff(credential string) (*flight, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f := p.flights[credential]
+	if f == nil {
+		f = &flight{}
+		p.flights[credential] = f
+	}
+	f.reads++
+
+	return f, f.generation
+}
+
+// land records the end of a read, forgetting the credential once it has no
+// reads in flight.
+func (p *Proxy) land(credential string, f *flight) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if f.reads--; f.reads == 0 {
+		delete(p.flights, credential)
+	}
+}
+
+// store caches a successful response to a cacheable request.
+func (p *Proxy) store(resp *http.Response) error {
+	req, ok := resp.Request.Context().Value(contextKey{}).(pending)
+	if !ok || resp.StatusCode != http.StatusOK || resp.ContentLength > p.opts.MaxBodySize {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, p.opts.MaxBodySize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(body)) > p.opts.MaxBodySize {
+		// Too large to cache, pass on what was read and the rest.
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	header.Del("X-Cache")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if req.flight.generation != req.generation {
+		return nil
+	}
+	if len(p.entries) >= p.opts.MaxEntries {
+		p.removeExpired()
+	}
+	if len(p.entries) < p.opts.MaxEntries {
+		p.entries[req.key] = &entry{
+			status:  resp.StatusCode,
+			header:  header,
+			body:    body,
+			expires: time.Now().Add(p.opts.TTL),
+		}
+	}
+
+	return nil
+}
+
+// invalidate removes the cached responses of a credential, and keeps the
+// responses of its reads in flight from being cached.
+func (p *Proxy) invalidate(credential string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if f := p.flights[credential]; f != nil {
+		f.generation++
+	}
+	prefix := credential + "\x00"
+	for key := range p.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(p.entries, key)
+		}
+	}
+}
+
+// Purge removes every cached response.
+func (p *Proxy) Purge() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	clear(p.entries)
+	for _, f := range p.flights {
+		f.generation++
+	}
+}
+
+// Stats returns the number of requests answered so far.
+func (p *Proxy) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.removeExpired()
+	stats := p.stats
+	stats.Entries = len(p.entries)
+
+	return stats
+}
+
+func (p *Proxy) removeExpired() {
+	now := time.Now()
+	for key, e := range p.entries {
+		if now.After(e.expires) {
+			delete(p.entries, key)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// upstream is a server counting the reads it answers. If set, reads are
+// signaled on started and block until release is closed.
+type upstream struct {
+	reads   atomic.Int64
+	started chan struct{}
+	release chan struct{}
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	n := u.reads.Add(1)
+	if u.started != nil {
+		u.started <- struct{}{}
+	}
+	if u.release != nil {
+		<-u.release
+	}
+	fmt.Fprintf(w, `{"read":%d,"auth":%q}`, n, r.Header.Get("Authorization"))
+}
+
+func newProxy(t *testing.T, u *upstream) *Proxy {
+	t.Helper()
+
+	server := httptest.NewServer(u)
+	t.Cleanup(server.Close)
+
+	p, err := New(server.URL, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func send(p *Proxy, method, path, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Token "+token)
+	}
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		name string
+		// The requests sent before the checked read, as method, path and
+		// token.
+		before [][3]string
+		path   string
+		token  string
+		want   string
+	}{
+		{
+			name:   "cached read",
+			before: [][3]string{{"GET", "/api/bookmarks/", "a"}},
+			path:   "/api/bookmarks/",
+			token:  "a",
+			want:   "HIT",
+		},
+		{
+			name:   "other query",
+			before: [][3]string{{"GET", "/api/bookmarks/?q=go", "a"}},
+			path:   "/api/bookmarks/",
+			token:  "a",
+			want:   "MISS",
+		},
+		{
+			name:   "other token",
+			before: [][3]string{{"GET", "/api/bookmarks/", "a"}},
+			path:   "/api/bookmarks/",
+			token:  "b",
+			want:   "MISS",
+		},
+		{
+			name:   "uncacheable endpoint",
+			before: [][3]string{{"GET", "/api/bookmarks/1/assets/", "a"}},
+			path:   "/api/bookmarks/1/assets/",
+			token:  "a",
+			want:   "MISS",
+		},
+		{
+			name:   "invalidated by write",
+			before: [][3]string{{"GET", "/api/bookmarks/", "a"}, {"POST", "/api/bookmarks/", "a"}},
+			path:   "/api/bookmarks/",
+			token:  "a",
+			want:   "MISS",
+		},
+		{
+			name:   "write of other token",
+			before: [][3]string{{"GET", "/api/bookmarks/", "a"}, {"POST", "/api/bookmarks/", "b"}},
+			path:   "/api/bookmarks/",
+			token:  "a",
+			want:   "HIT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newProxy(t, &upstream{})
+			for _, r := range tt.before {
+				send(p, r[0], r[1], r[2])
+			}
+
+			w := send(p, http.MethodGet, tt.path, tt.token)
+			if got := w.Header().Get("X-Cache"); got != tt.want {
+				t.Errorf("X-Cache = %s, want %s", got, tt.want)
+			}
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Token "+tt.token) {
+				t.Errorf("response = %d %s, want the response for token %s", w.Code, w.Body, tt.token)
+			}
+		})
+	}
+}
+
+func TestWriteDuringReadIsNotCached(t *testing.T) {
+	u := &upstream{started: make(chan struct{}, 2), release: make(chan struct{})}
+	p := newProxy(t, u)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		send(p, http.MethodGet, "/api/bookmarks/", "a")
+	}()
+
+	// The write is answered while the read is waiting for the server, so
+	// the response of the read may predate it.
+	<-u.started
+	send(p, http.MethodPost, "/api/bookmarks/", "a")
+	close(u.release)
+	<-done
+
+	if got := send(p, http.MethodGet, "/api/bookmarks/", "a").Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("X-Cache = %s, want MISS", got)
+	}
+}
+
+func TestWritesDoNotGrowState(t *testing.T) {
+	p := newProxy(t, &upstream{})
+
+	for i := range 100 {
+		send(p, http.MethodPost, "/api/bookmarks/", fmt.Sprint(i))
+	}
+	send(p, http.MethodGet, "/api/bookmarks/", "a")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.flights) != 0 {
+		t.Errorf("tracking %d credentials, want none", len(p.flights))
+	}
+}
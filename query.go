@@ -0,0 +1,135 @@
+package linkding
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Query builds a search query in Linkding's search syntax, for the Query of
+// ListBookmarksParams:
+//
+//	q := linkding.Search("grpc").Tag("go").Unread().Exclude(linkding.Search().Tag("archive"))
+//	params := linkding.ListBookmarksParams{Query: q.String()}
+//
+// All parts of a query must match. Terms are quoted when needed, so they are
+// matched as they are given. Queries are immutable, each method returns a new
+// query.
+type Query struct {
+	parts []string
+}
+
+// Search returns a query matching bookmarks containing all of the terms.
+func Search(terms ...string) Query {
+	return Query{}.Term(terms...)
+}
+
+// Term returns the query also matching bookmarks whose title, description,
+// notes or URL contain all of the terms. A term containing spaces matches as
+// a phrase.
+func (q Query) Term(terms ...string) Query {
+	for _, term := range terms {
+		if term = EscapeSearchTerm(term); term != "" {
+			q = q.with(term)
+		}
+	}
+	return q
+}
+
+// Tag returns the query also matching bookmarks with all of the tags. Tags
+// are matched ignoring case, see EscapeTag.
+func (q Query) Tag(tags ...string) Query {
+	for _, tag := range tags {
+		if tag = EscapeTag(tag); tag != "" {
+			q = q.with(tag)
+		}
+	}
+	return q
+}
+
+// Untagged returns the query also matching bookmarks without tags.
+func (q Query) Untagged() Query {
+	return q.with("!untagged")
+}
+
+// Unread returns the query also matching unread bookmarks.
+func (q Query) Unread() Query {
+	return q.with("!unread")
+}
+
+// Exclude returns the query not matching bookmarks matched by other. Excluding
+// needs the boolean operators of Linkding 1.42 or later, which older versions
+// search for as words.
+func (q Query) Exclude(other Query) Query {
+	switch len(other.parts) {
+	case 0:
+		return q
+	case 1:
+		return q.with("not " + other.parts[0])
+	default:
+		return q.with("not (" + other.String() + ")")
+	}
+}
+
+// String returns the query in Linkding's search syntax.
+func (q Query) String() string {
+	return strings.Join(q.parts, " ")
+}
+
+// IsZero reports whether the query matches every bookmark.
+func (q Query) IsZero() bool {
+	return len(q.parts) == 0
+}
+
+func (q Query) with(part string) Query {
+	// Copy the parts, so queries built from the same query do not share them.
+	return Query{parts: append(q.parts[:len(q.parts):len(q.parts)], part)}
+}
+
+// EscapeSearchTerm returns term as a search term matching the term as it is
+// given. The term is quoted if it would otherwise be read as something else:
+// several words, a tag, a filter such as !unread, or an operator. Quotes and
+// backslashes in quoted terms are escaped.
+//
+// The term is also normalized to the composed Unicode form, in which text is
+// almost always stored, so a decomposed "é" still matches. Surrounding
+// whitespace is removed, and an empty term is returned as "".
+func EscapeSearchTerm(term string) string {
+	term = norm.NFC.String(strings.TrimSpace(term))
+	if term == "" {
+		return ""
+	}
+
+	quote := strings.ContainsFunc(term, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '"' || r == '(' || r == ')'
+	})
+	switch {
+	case strings.HasPrefix(term, "#"), strings.HasPrefix(term, "!"), strings.HasPrefix(term, "-"):
+		quote = true
+	case strings.EqualFold(term, "and"), strings.EqualFold(term, "or"), strings.EqualFold(term, "not"):
+		quote = true
+	}
+	if !quote {
+		return term
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(term) + `"`
+}
+
+// EscapeTag returns the search term matching bookmarks with a tag, e.g.
+// "#go" for "go". A leading "#" of the tag is ignored.
+//
+// Linkding splits tags at whitespace when saving them, so a tag given as
+// "machine learning" is stored as the tags "machine" and "learning". Such a
+// tag is returned as the terms matching both, "#machine #learning". Tags are
+// normalized to the composed Unicode form like search terms, and an empty tag
+// is returned as "".
+func EscapeTag(tag string) string {
+	names := strings.Fields(norm.NFC.String(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+	for i, name := range names {
+		names[i] = "#" + name
+	}
+
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,63 @@
+package linkding
+
+import "testing"
+
+func TestEscapeSearchTerm(t *testing.T) {
+	tests := []struct {
+		name string
+		term string
+		want string
+	}{
+		{"word", "golang", "golang"},
+		{"empty", "", ""},
+		{"whitespace only", "  \t ", ""},
+		{"surrounding whitespace", "  golang ", "golang"},
+		{"phrase", "error handling", `"error handling"`},
+		{"hash", "#go", `"#go"`},
+		{"hash inside", "c#", "c#"},
+		{"filter", "!unread", `"!unread"`},
+		{"negation", "-draft", `"-draft"`},
+		{"operator", "NOT", `"NOT"`},
+		{"embedded quotes", `say "hi"`, `"say \"hi\""`},
+		{"quote and backslash", `a"b\c`, `"a\"b\\c"`},
+		{"parentheses", "f(x)", `"f(x)"`},
+		{"emoji", "🚀", "🚀"},
+		{"emoji phrase", "rocket 🚀", `"rocket 🚀"`},
+		{"combining characters", "cafe\u0301", "caf\u00e9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EscapeSearchTerm(tt.term); got != tt.want {
+				t.Errorf("EscapeSearchTerm(%q) = %q, want %q", tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeTag(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want string
+	}{
+		{"tag", "go", "#go"},
+		{"empty", "", ""},
+		{"hash only", "#", ""},
+		{"leading hash", "#go", "#go"},
+		{"spaces", "machine learning", "#machine #learning"},
+		{"surrounding and repeated spaces", "  machine   learning ", "#machine #learning"},
+		{"embedded quotes", `"quoted"`, `#"quoted"`},
+		{"emoji", "🚀", "#🚀"},
+		{"emoji with spaces", "🚀 launch", "#🚀 #launch"},
+		{"combining characters", "cafe\u0301", "#caf\u00e9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EscapeTag(tt.tag); got != tt.want {
+				t.Errorf("EscapeTag(%q) = %q, want %q", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package linkding
+
+import (
+	"context"
+	"math/rand/v2"
+)
+
+// NextUnread returns the next bookmark to read: the oldest active unread
+// bookmark matching params, or the newest if params.Sort is "added_desc". It
+// returns nil if no unread bookmark matches.
+//
+// Only the first bookmark is requested, so it is cheap to call for every
+// bookmark read, e.g. together with MarkRead.
+func (c *Client) NextUnread(ctx context.Context, params ListBookmarksParams) (*Bookmark, error) {
+	params.Unread = true
+	params.Limit = 1
+	params.Offset = 0
+	if params.Sort == "" {
+		params.Sort = "added_asc"
+	}
+
+	page, err := c.Bookmarks.List(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Results) == 0 {
+		return nil, nil
+	}
+
+	return &page.Results[0], nil
+}
+
+// MarkRead marks a bookmark as read and returns the updated bookmark.
+func (c *Client) MarkRead(ctx context.Context, id int) (*Bookmark, error) {
+	unread := false
+	return c.Bookmarks.Patch(ctx, id, PatchBookmarkRequest{Unread: &unread})
+}
+
+// MarkUnread marks a bookmark as unread and returns the updated bookmark.
+func (c *Client) MarkUnread(ctx context.Context, id int) (*Bookmark, error) {
+	unread := true
+	return c.Bookmarks.Patch(ctx, id, PatchBookmarkRequest{Unread: &unread})
+}
+
+// RandomUnread returns a random active unread bookmark matching params, e.g. a
+// tag query such as "#article", or nil if none matches. It requests the count
+// of matching bookmarks and then the bookmark at a random offset, so only two
+// requests are made regardless of the number of bookmarks.
+func (c *Client) RandomUnread(ctx context.Context, params ListBookmarksParams) (*Bookmark, error) {
+	params.Unread = true
+	params.Limit = 1
+	params.Offset = 0
+
+	page, err := c.Bookmarks.List(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if page.Count == 0 {
+		return nil, nil
+	}
+
+	params.Offset = rand.IntN(page.Count)
+	if params.Offset > 0 {
+		if page, err = c.Bookmarks.List(ctx, params); err != nil {
+			return nil, err
+		}
+	}
+	if len(page.Results) == 0 {
+		// The bookmark at the offset was removed or read in the meantime.
+		return c.NextUnread(ctx, params)
+	}
+
+	return &page.Results[0], nil
+}
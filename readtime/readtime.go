@@ -0,0 +1,176 @@
+// Package readtime estimates how long bookmarked pages take to read, for
+// reading lists that show the time next to each bookmark or filter by it.
+//
+// The text of a page is taken from the latest HTML snapshot of the bookmark,
+// if the server archived one, and from the live page otherwise. Estimates can
+// be stored on the server as tags such as "read-5min", see Tag.
+package readtime
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/extract"
+	"golang.org/x/net/html/charset"
+)
+
+// DefaultWordsPerMinute is the reading speed when not configured, a common
+// estimate for adults reading on a screen.
+const DefaultWordsPerMinute = 230
+
+// DefaultTagPrefix is the prefix of reading time tags when not configured.
+const DefaultTagPrefix = "read-"
+
+// Options configures an estimate.
+type Options struct {
+	// The reading speed. Defaults to DefaultWordsPerMinute.
+	WordsPerMinute int
+	// Always read the live page, even if the bookmark has a snapshot.
+	Live bool
+	// The client used to fetch live pages. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// The prefix of reading time tags. Defaults to DefaultTagPrefix.
+	TagPrefix string
+}
+
+// Estimate returns the reading time of a bookmark in minutes, at least 1.
+func Estimate(ctx context.Context, c linkding.BookmarkClient, bookmark linkding.Bookmark, opts Options) (int, error) {
+	page, err := open(ctx, c, bookmark, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer page.Close()
+
+	words, err := Words(page)
+	if err != nil {
+		return 0, err
+	}
+
+	return Minutes(words, opts.WordsPerMinute), nil
+}
+
+// TagBookmark estimates the reading time of a bookmark and stores it as a tag,
+// replacing any reading time tag of a previous estimate. It returns the
+// estimate in minutes.
+func TagBookmark(ctx context.Context, c linkding.BookmarkClient, bookmark linkding.Bookmark, opts Options) (int, error) {
+	minutes, err := Estimate(ctx, c, bookmark, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	prefix := cmp.Or(opts.TagPrefix, DefaultTagPrefix)
+	tags := slices.DeleteFunc(slices.Clone(bookmark.TagNames), func(tag string) bool {
+		_, ok := ParseTag(tag, prefix)
+		return ok
+	})
+	tags = append(tags, Tag(minutes, prefix))
+
+	if _, err := c.PatchBookmark(bookmark.ID, linkding.PatchBookmarkRequest{TagNames: &tags}); err != nil {
+		return 0, err
+	}
+
+	return minutes, nil
+}
+
+// Minutes returns the reading time of a number of words in minutes, rounded
+// up and at least 1. A speed of 0 or less uses DefaultWordsPerMinute.
+func Minutes(words, wordsPerMinute int) int {
+	if wordsPerMinute <= 0 {
+		wordsPerMinute = DefaultWordsPerMinute
+	}
+
+	return max(1, (words+wordsPerMinute-1)/wordsPerMinute)
+}
+
+// Tag returns the tag of a reading time, e.g. "read-5min". An empty prefix
+// uses DefaultTagPrefix.
+func Tag(minutes int, prefix string) string {
+	return cmp.Or(prefix, DefaultTagPrefix) + strconv.Itoa(minutes) + "min"
+}
+
+// ParseTag returns the reading time of a tag created by Tag with the same
+// prefix, and whether the tag is a reading time tag.
+func ParseTag(tag, prefix string) (int, bool) {
+	prefix = cmp.Or(prefix, DefaultTagPrefix)
+
+	match := tagPattern.FindStringSubmatch(strings.ToLower(tag))
+	if match == nil || match[1] != strings.ToLower(prefix) {
+		return 0, false
+	}
+
+	minutes, err := strconv.Atoi(match[2])
+	return minutes, err == nil
+}
+
+var tagPattern = regexp.MustCompile(`^(.*?)(\d+)min$`)
+
+// Words counts the words of the article of an HTML document, leaving out
+// navigation, scripts and other parts of the page that are not read.
+func Words(r io.Reader) (int, error) {
+	article, err := extract.Parse(r)
+	if err != nil {
+		return 0, err
+	}
+
+	return article.Words(), nil
+}
+
+// open returns the HTML of a bookmark, from its latest complete snapshot or
+// the live page.
+func open(ctx context.Context, c linkding.BookmarkClient, bookmark linkding.Bookmark, opts Options) (io.ReadCloser, error) {
+	if !opts.Live {
+		snapshot, err := extract.LatestSnapshot(ctx, c, bookmark.ID)
+		if err == nil {
+			return c.DownloadBookmarkAsset(bookmark.ID, snapshot.ID)
+		}
+		if !errors.Is(err, extract.ErrNoSnapshot) {
+			return nil, err
+		}
+	}
+
+	return fetch(ctx, cmp.Or(opts.HTTPClient, http.DefaultClient), bookmark.URL)
+}
+
+func fetch(ctx context.Context, client *http.Client, pageURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, fmt.Errorf("readtime: fetching %s: unexpected status %s", pageURL, res.Status)
+	}
+	contentType := res.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		res.Body.Close()
+		return nil, fmt.Errorf("readtime: %s is not an HTML page but %s", pageURL, mediaType)
+	}
+
+	text, err := charset.NewReader(res.Body, contentType)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{text, res.Body}, nil
+}
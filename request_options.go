@@ -0,0 +1,151 @@
+package linkding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestOption configures a single call of a service method, overriding the
+// defaults of the client for it:
+//
+//	bookmark, err := client.Bookmarks.Get(ctx, id, linkding.WithRequestTimeout(5*time.Second))
+type RequestOption func(*requestConfig)
+
+type requestConfig struct {
+	timeout time.Duration
+	header  http.Header
+	noRetry bool
+	noCache bool
+}
+
+// WithRequestTimeout limits the time of a request, including reading its
+// response, such as the content of a downloaded asset.
+func WithRequestTimeout(timeout time.Duration) RequestOption {
+	return func(config *requestConfig) {
+		config.timeout = timeout
+	}
+}
+
+// WithRequestHeader sends an additional header with a request. The headers
+// set by the client itself, such as Authorization, cannot be replaced.
+func WithRequestHeader(key, value string) RequestOption {
+	return func(config *requestConfig) {
+		if config.header == nil {
+			config.header = http.Header{}
+		}
+		config.header.Add(key, value)
+	}
+}
+
+// WithNoRetry sends a request only once, even if the client retries failed
+// requests, see WithRetries.
+func WithNoRetry() RequestOption {
+	return func(config *requestConfig) {
+		config.noRetry = true
+	}
+}
+
+// WithNoCache reads past the cache of WithCache, e.g. to check a bookmark
+// right before changing it. Reads with a timeout or additional headers are
+// never answered from the cache either.
+func WithNoCache() RequestOption {
+	return func(config *requestConfig) {
+		config.noCache = true
+	}
+}
+
+// retryDelay is the time before the first retry of a request, which doubles
+// with each further retry.
+const retryDelay = 500 * time.Millisecond
+
+// MaxRetryAfter is the longest wait asked for by a Retry-After header that is
+// honored. Requests asked to wait longer fail, e.g. with ErrTooManyRequests.
+const MaxRetryAfter = time.Minute
+
+// retryable reports whether a failed request may succeed when sent again:
+// when the server could not be reached, or a proxy in front of it reported it
+// unavailable, which is only retried for reads as changes may have been
+// applied. Requests rejected with 429 Too Many Requests were not processed, so
+// they are retried whatever their method.
+func retryable(method string, res *http.Response, err error) bool {
+	if err == nil && res.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// retryReason returns the error a request failed with before retrying it.
+func retryReason(res *http.Response, err error) error {
+	switch {
+	case err != nil:
+		return err
+	case res.StatusCode == http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	default:
+		return fmt.Errorf("linkding: %s", res.Status)
+	}
+}
+
+// retryAfter returns the time to wait before retrying as asked for by the
+// Retry-After header of res, given in seconds or as a date, if there is one.
+func retryAfter(res *http.Response, now time.Time) (time.Duration, bool) {
+	if res == nil {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(res.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancelBody releases the context of a request when its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
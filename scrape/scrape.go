@@ -0,0 +1,198 @@
+// Package scrape reads the metadata of web pages on the client, for when the
+// scraper of the Linkding server cannot reach a page, e.g. because it is only
+// available on the local network or requires cookies.
+//
+// The title and description are taken from the Open Graph tags of a page if
+// present, and from its title element and description meta tag otherwise,
+// like Linkding does.
+package scrape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// DefaultMaxBytes is the number of bytes of a page read when not configured.
+const DefaultMaxBytes = 1 << 20
+
+// DefaultUserAgent is the user agent sent when not configured.
+const DefaultUserAgent = "go-linkding-scraper"
+
+// Options configures a Scraper.
+type Options struct {
+	// The client used to fetch pages. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// The User-Agent header sent. Defaults to DefaultUserAgent.
+	UserAgent string
+	// The number of bytes of a page read at most. Metadata is expected in the
+	// head of a page, so the rest is not needed. Defaults to DefaultMaxBytes.
+	MaxBytes int64
+}
+
+// Scraper fetches web pages and reads their metadata. It is safe for
+// concurrent use.
+type Scraper struct {
+	opts Options
+}
+
+// New returns a scraper.
+func New(opts Options) *Scraper {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+
+	return &Scraper{opts: opts}
+}
+
+// Scrape fetches the page at pageURL and returns its metadata. The URL of the
+// metadata is the URL of the page after following redirects. Its signature
+// matches linkding.RefreshOptions.Scrape.
+func (s *Scraper) Scrape(ctx context.Context, pageURL string) (*linkding.Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", s.opts.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	res, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("scrape: unexpected status %s", res.Status)
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("scrape: unsupported content type %s", mediaType)
+	}
+
+	body, err := charset.NewReader(io.LimitReader(res.Body, s.opts.MaxBytes), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := Parse(body, res.Request.URL)
+	if err != nil {
+		return nil, err
+	}
+	metadata.URL = res.Request.URL.String()
+
+	return metadata, nil
+}
+
+// Scrape fetches the page at pageURL using a scraper with the default options.
+func Scrape(ctx context.Context, pageURL string) (*linkding.Metadata, error) {
+	return New(Options{}).Scrape(ctx, pageURL)
+}
+
+// Parse reads the metadata of an HTML document. Relative preview image URLs are
+// resolved against base, if it is not nil. Only the head of the document is
+// read.
+func Parse(r io.Reader, base *url.URL) (*linkding.Metadata, error) {
+	tokenizer := html.NewTokenizer(r)
+
+	var title, description, ogTitle, ogDescription, image string
+	inTitle := false
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			return newMetadata(title, description, ogTitle, ogDescription, image, base), nil
+		case html.TextToken:
+			if inTitle {
+				title += string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return newMetadata(title, description, ogTitle, ogDescription, image, base), nil
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = tokenType == html.StartTagToken && title == ""
+			case "body":
+				return newMetadata(title, description, ogTitle, ogDescription, image, base), nil
+			case "meta":
+				attrs := map[string]string{}
+				for hasAttr {
+					var key, value []byte
+					key, value, hasAttr = tokenizer.TagAttr()
+					attrs[string(key)] = string(value)
+				}
+
+				// Pages may set both name and property on the same tag, e.g.
+				// to cover Open Graph and Twitter cards at once.
+				content := attrs["content"]
+				for _, key := range []string{attrs["name"], attrs["property"]} {
+					switch strings.ToLower(key) {
+					case "description":
+						description = content
+					case "og:title":
+						ogTitle = content
+					case "og:description":
+						ogDescription = content
+					case "og:image":
+						image = content
+					}
+				}
+			}
+		}
+	}
+}
+
+func newMetadata(title, description, ogTitle, ogDescription, image string, base *url.URL) *linkding.Metadata {
+	metadata := &linkding.Metadata{
+		Title:       clean(title),
+		Description: clean(description),
+	}
+	if ogTitle := clean(ogTitle); ogTitle != "" {
+		metadata.Title = ogTitle
+	}
+	if ogDescription := clean(ogDescription); ogDescription != "" {
+		metadata.Description = ogDescription
+	}
+
+	if image = strings.TrimSpace(image); image != "" {
+		if ref, err := url.Parse(image); err == nil && base != nil {
+			image = base.ResolveReference(ref).String()
+		}
+		metadata.PreviewImage = image
+	}
+
+	return metadata
+}
+
+// clean collapses whitespace, which is common in titles spanning several
+// lines.
+func clean(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,171 @@
+package linkding
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SearchResult is a bookmark found by SearchAll.
+type SearchResult struct {
+	Bookmark
+	// Whether the bookmark was found among the archived bookmarks.
+	Archived bool
+}
+
+// SearchAllOptions configures SearchAll.
+type SearchAllOptions struct {
+	// Sort the active and archived bookmarks together, in the order of the
+	// Sort of the parameters, newest first by default. The active bookmarks
+	// are listed before the archived ones otherwise.
+	Interleave bool
+}
+
+// SearchAll retrieves every bookmark matching params, active and archived,
+// listing both concurrently. The limit of params sets the page size, as for
+// AllBookmarks.
+func (c *Client) SearchAll(ctx context.Context, params ListBookmarksParams, opts SearchAllOptions) ([]SearchResult, error) {
+	// Stop listing the other bookmarks when one list fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var active, archived []Bookmark
+	var activeErr, archivedErr error
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if active, activeErr = collect(AllBookmarks(ctx, c, params)); activeErr != nil {
+			cancel()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if archived, archivedErr = collect(AllArchivedBookmarks(ctx, c, params)); archivedErr != nil {
+			cancel()
+		}
+	}()
+	wg.Wait()
+
+	// Report the error that stopped the other list, not its cancellation.
+	if errors.Is(activeErr, context.Canceled) && archivedErr != nil {
+		activeErr = archivedErr
+	}
+	if err := cmp.Or(activeErr, archivedErr); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(active)+len(archived))
+	for _, bookmark := range active {
+		results = append(results, SearchResult{Bookmark: bookmark})
+	}
+	for _, bookmark := range archived {
+		results = append(results, SearchResult{Bookmark: bookmark, Archived: true})
+	}
+
+	if opts.Interleave {
+		slices.SortStableFunc(results, func(a, b SearchResult) int {
+			switch params.Sort {
+			case "added_asc":
+				return a.DateAdded.Compare(b.DateAdded)
+			case "title_asc":
+				return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+			case "title_desc":
+				return strings.Compare(strings.ToLower(b.Title), strings.ToLower(a.Title))
+			default:
+				return b.DateAdded.Compare(a.DateAdded)
+			}
+		})
+	}
+
+	return results, nil
+}
+
+// ListBookmarksByDomain retrieves every bookmark of a page on host, e.g.
+// "go.dev". The search of Linkding matches the host anywhere in a bookmark,
+// including its title and description, so the results are filtered to URLs of
+// the host itself. Hosts are compared ignoring case and a leading "www.", and
+// subdomains do not match.
+func (c *Client) ListBookmarksByDomain(host string) ([]Bookmark, error) {
+	host = domain(host)
+	if host == "" {
+		return []Bookmark{}, nil
+	}
+
+	bookmarks := []Bookmark{}
+	for bookmark, err := range AllBookmarks(context.Background(), c, ListBookmarksParams{Query: EscapeSearchTerm(host)}) {
+		if err != nil {
+			return nil, err
+		}
+		if u, err := url.Parse(bookmark.URL); err == nil && domain(u.Hostname()) == host {
+			bookmarks = append(bookmarks, bookmark)
+		}
+	}
+
+	return bookmarks, nil
+}
+
+// domain returns the host, given alone or as part of a URL, in lowercase and
+// without a leading "www.".
+func domain(host string) string {
+	host = strings.TrimSpace(host)
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// SearchMany runs several searches in parallel using at most concurrency
+// concurrent requests, e.g. for a dashboard showing saved searches, and
+// returns the page of results of each search, in the order of queries.
+// Searches with equal parameters are only run once and share their page.
+//
+// The first failing search cancels the others and its error is returned.
+func (c *Client) SearchMany(ctx context.Context, queries []ListBookmarksParams, concurrency int) ([]*ListBookmarksResponse, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	unique := []ListBookmarksParams{}
+	indexes := make([]int, len(queries))
+	seen := map[ListBookmarksParams]int{}
+	for i, params := range queries {
+		// Dates are compared as instants, whatever their location.
+		key := params
+		key.AddedSince, key.ModifiedSince = key.AddedSince.UTC(), key.ModifiedSince.UTC()
+
+		j, ok := seen[key]
+		if !ok {
+			j = len(unique)
+			seen[key] = j
+			unique = append(unique, params)
+		}
+		indexes[i] = j
+	}
+
+	pages := make([]*ListBookmarksResponse, len(unique))
+	runPool(ctx, len(unique), concurrency, func(ctx context.Context, i int) error {
+		page, err := c.Bookmarks.List(ctx, unique[i])
+		if err != nil {
+			cancel(err)
+			return err
+		}
+		pages[i] = page
+		return nil
+	})
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+
+	results := make([]*ListBookmarksResponse, len(queries))
+	for i, j := range indexes {
+		results[i] = pages[j]
+	}
+
+	return results, nil
+}
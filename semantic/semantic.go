@@ -0,0 +1,342 @@
+// Package semantic finds bookmarks by meaning rather than by words, e.g.
+// "articles about slow databases" finding a bookmark titled "Postgres query
+// tuning".
+//
+// The text of each bookmark is turned into a vector by an Embedder, which
+// wraps an embedding model such as the ones of OpenAI or Ollama. The package
+// does not depend on any provider: an Embedder is a single method that is
+// usually a few lines of code around the provider's client. Queries are
+// embedded with the same Embedder and compared with the bookmarks by cosine
+// similarity. The index is kept in memory and can be saved and loaded, so
+// bookmarks are not embedded again on every start.
+package semantic
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/larcher/go-linkding"
+)
+
+// DefaultBatchSize is the number of texts embedded per call when not
+// configured.
+const DefaultBatchSize = 32
+
+// Embedder turns texts into vectors. The vectors of all texts must have the
+// same length, and similar texts should have vectors pointing in similar
+// directions.
+type Embedder interface {
+	// Embed returns the vector of each text, in the same order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderFunc adapts a function to the Embedder interface.
+type EmbedderFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+// Embed calls f.
+func (f EmbedderFunc) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return f(ctx, texts)
+}
+
+// Options configures an Index.
+type Options struct {
+	// The number of texts embedded per call. Defaults to DefaultBatchSize.
+	BatchSize int
+	// Returns the text embedded for a bookmark. Defaults to Text.
+	Text func(linkding.Bookmark) string
+}
+
+// Match is a bookmark similar to a query.
+type Match struct {
+	ID    int
+	URL   string
+	Title string
+	// The cosine similarity to the query, from -1 to 1.
+	Score float64
+}
+
+// entry is an embedded bookmark.
+type entry struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	// The hash of the embedded text, to detect bookmarks that changed.
+	Hash   string    `json:"hash"`
+	Vector []float32 `json:"vector"`
+}
+
+// Index holds the vectors of bookmarks. It is safe for concurrent use.
+type Index struct {
+	embedder Embedder
+	opts     Options
+
+	mu      sync.RWMutex
+	entries map[int]entry
+}
+
+// New returns an empty index using the embedder.
+func New(embedder Embedder, opts Options) *Index {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Text == nil {
+		opts.Text = Text
+	}
+
+	return &Index{embedder: embedder, opts: opts, entries: map[int]entry{}}
+}
+
+// Text returns the text embedded for a bookmark by default: its title,
+// description, notes and tags.
+func Text(bookmark linkding.Bookmark) string {
+	parts := []string{
+		bookmark.EffectiveTitle(),
+		cmp.Or(bookmark.Description, bookmark.WebsiteDescription),
+		bookmark.Notes,
+	}
+	if len(bookmark.TagNames) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(bookmark.TagNames, ", "))
+	}
+
+	return strings.Join(slices.DeleteFunc(parts, func(part string) bool {
+		return part == ""
+	}), "\n")
+}
+
+// Len returns the number of bookmarks in the index.
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return len(i.entries)
+}
+
+// Add embeds the bookmarks and adds them to the index, replacing previous
+// versions. Bookmarks whose text did not change since they were added are not
+// embedded again, so Add can be called with every bookmark to bring the index
+// up to date. It returns the number of bookmarks embedded.
+func (i *Index) Add(ctx context.Context, bookmarks ...linkding.Bookmark) (int, error) {
+	type pending struct {
+		id    int
+		text  string
+		entry entry
+	}
+
+	i.mu.RLock()
+	todo := []pending{}
+	for _, bookmark := range bookmarks {
+		text := i.opts.Text(bookmark)
+		hash := hashText(text)
+		if existing, ok := i.entries[bookmark.ID]; ok && existing.Hash == hash {
+			continue
+		}
+		todo = append(todo, pending{
+			id:   bookmark.ID,
+			text: text,
+			entry: entry{
+				URL:   bookmark.URL,
+				Title: bookmark.EffectiveTitle(),
+				Hash:  hash,
+			},
+		})
+	}
+	i.mu.RUnlock()
+
+	embedded := 0
+	for batch := range slices.Chunk(todo, i.opts.BatchSize) {
+		texts := make([]string, len(batch))
+		for j, p := range batch {
+			texts[j] = p.text
+		}
+
+		vectors, err := i.embedder.Embed(ctx, texts)
+		if err != nil {
+			return embedded, err
+		}
+		if len(vectors) != len(batch) {
+			return embedded, fmt.Errorf("semantic: embedder returned %d vectors for %d texts", len(vectors), len(batch))
+		}
+
+		i.mu.Lock()
+		for j, p := range batch {
+			p.entry.Vector = normalize(vectors[j])
+			i.entries[p.id] = p.entry
+		}
+		i.mu.Unlock()
+		embedded += len(batch)
+	}
+
+	return embedded, nil
+}
+
+// Delete removes bookmarks from the index.
+func (i *Index) Delete(ids ...int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, id := range ids {
+		delete(i.entries, id)
+	}
+}
+
+// Sync brings the index up to date with every bookmark of the account, active
+// and archived: new and changed bookmarks are embedded and deleted bookmarks
+// are removed. It returns the number of bookmarks embedded.
+func (i *Index) Sync(ctx context.Context, c linkding.BookmarkClient) (int, error) {
+	bookmarks := []linkding.Bookmark{}
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return 0, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	embedded, err := i.Add(ctx, bookmarks...)
+	if err != nil {
+		return embedded, err
+	}
+
+	current := map[int]bool{}
+	for _, bookmark := range bookmarks {
+		current[bookmark.ID] = true
+	}
+	i.mu.Lock()
+	for id := range i.entries {
+		if !current[id] {
+			delete(i.entries, id)
+		}
+	}
+	i.mu.Unlock()
+
+	return embedded, nil
+}
+
+// Search returns the n bookmarks most similar to the text, most similar
+// first.
+func (i *Index) Search(ctx context.Context, text string, n int) ([]Match, error) {
+	vectors, err := i.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("semantic: embedder returned %d vectors for 1 text", len(vectors))
+	}
+
+	return i.nearest(normalize(vectors[0]), n, 0), nil
+}
+
+// Similar returns the n bookmarks most similar to the bookmark with the given
+// ID, which must be in the index, most similar first.
+func (i *Index) Similar(id int, n int) ([]Match, error) {
+	i.mu.RLock()
+	e, ok := i.entries[id]
+	i.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("semantic: bookmark %d is not in the index", id)
+	}
+
+	return i.nearest(e.Vector, n, id), nil
+}
+
+// nearest returns the n entries closest to the normalized vector, leaving out
+// the entry with the ID skip.
+func (i *Index) nearest(vector []float32, n int, skip int) []Match {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	matches := make([]Match, 0, len(i.entries))
+	for id, e := range i.entries {
+		if id == skip || len(e.Vector) != len(vector) {
+			continue
+		}
+		matches = append(matches, Match{ID: id, URL: e.URL, Title: e.Title, Score: dot(vector, e.Vector)})
+	}
+
+	slices.SortFunc(matches, func(a, b Match) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.ID, b.ID))
+	})
+	if n > 0 && len(matches) > n {
+		matches = matches[:n]
+	}
+
+	return matches
+}
+
+// savedIndex is the format of saved indexes.
+type savedIndex struct {
+	Version int           `json:"version"`
+	Entries map[int]entry `json:"entries"`
+}
+
+// Save writes the index as JSON, to be read back with Load.
+func (i *Index) Save(w io.Writer) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return json.NewEncoder(w).Encode(savedIndex{Version: 1, Entries: i.entries})
+}
+
+// Load replaces the contents of the index with an index written by Save. The
+// index must have been saved with the same embedder and text function, as
+// vectors of different models cannot be compared.
+func (i *Index) Load(r io.Reader) error {
+	var saved savedIndex
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return err
+	}
+	if saved.Version != 1 {
+		return errors.New("semantic: unsupported index version")
+	}
+	if saved.Entries == nil {
+		saved.Entries = map[int]entry{}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.entries = saved.Entries
+
+	return nil
+}
+
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:8])
+}
+
+// normalize scales a vector to unit length, so the cosine similarity of two
+// vectors is their dot product.
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return slices.Clone(vector)
+	}
+
+	norm := math.Sqrt(sum)
+	result := make([]float32, len(vector))
+	for j, v := range vector {
+		result[j] = float32(float64(v) / norm)
+	}
+
+	return result
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for j := range a {
+		sum += float64(a[j]) * float64(b[j])
+	}
+
+	return sum
+}
@@ -0,0 +1,318 @@
+// Package site generates a static, read-only HTML site from bookmarks, for
+// sharing a collection or archiving it in a form that needs nothing but a
+// browser.
+//
+// The site has an index page listing every bookmark and tag, a page per tag,
+// and a client-side search backed by an index generated along with the pages.
+// It works when served by any web server and when opened from disk. The
+// bookmarks can come from a server, see Account, or from a snapshot such as a
+// backup read with backup.ReadBookmarks.
+package site
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/favicon"
+)
+
+// Options configures Generate.
+type Options struct {
+	// The title of the site. Defaults to "Bookmarks".
+	Title string
+	// Only include shared bookmarks, for sites that are published.
+	SharedOnly bool
+	// Download the favicons of the bookmarks into the site, so it does not
+	// depend on the Linkding server. Favicons are only available if the
+	// server has favicons enabled. Without downloading, the favicons are
+	// linked from the server.
+	DownloadFavicons bool
+	// The client used to download favicons. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// entry is a bookmark as shown on the pages and in the search index.
+type entry struct {
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	Tags        []string  `json:"tags"`
+	Favicon     string    `json:"favicon,omitempty"`
+	Date        time.Time `json:"date"`
+
+	// Whether the favicon was downloaded into the site, in which case its
+	// path is relative to the site root.
+	LocalFavicon bool `json:"-"`
+}
+
+type tagPage struct {
+	Name    string
+	File    string
+	Entries []entry
+}
+
+type page struct {
+	Title string
+	// The path of the site root relative to the page.
+	Root    string
+	Heading string
+	Tags    []tagPage
+	Entries []entry
+	Search  bool
+}
+
+// Account fetches the active bookmarks of an account and generates a site of
+// them in dir.
+func Account(ctx context.Context, c linkding.BookmarkClient, dir string, opts Options) error {
+	bookmarks := []linkding.Bookmark{}
+	for bookmark, err := range linkding.AllBookmarks(ctx, c, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return Generate(ctx, dir, bookmarks, opts)
+}
+
+// Generate writes a site of the bookmarks into dir, creating it if needed.
+// Existing files of a previous run are overwritten, but files of tags that no
+// longer exist are not removed.
+func Generate(ctx context.Context, dir string, bookmarks []linkding.Bookmark, opts Options) error {
+	title := cmp.Or(opts.Title, "Bookmarks")
+
+	if opts.SharedOnly {
+		bookmarks = slices.DeleteFunc(slices.Clone(bookmarks), func(bookmark linkding.Bookmark) bool {
+			return !bookmark.Shared
+		})
+	}
+
+	favicons := map[int]string{}
+	if opts.DownloadFavicons {
+		var err error
+		if favicons, err = downloadFavicons(ctx, dir, bookmarks, opts); err != nil {
+			return err
+		}
+	}
+
+	entries := []entry{}
+	byTag := map[string]*tagPage{}
+	for _, bookmark := range bookmarks {
+		e := entry{
+			Title:       bookmark.EffectiveTitle(),
+			URL:         bookmark.URL,
+			Description: cmp.Or(bookmark.Description, bookmark.WebsiteDescription),
+			Tags:        bookmark.TagNames,
+			Favicon:     bookmark.FaviconURL,
+			Date:        bookmark.DateAdded,
+		}
+		if e.Tags == nil {
+			e.Tags = []string{}
+		}
+		if opts.DownloadFavicons {
+			e.Favicon = favicons[bookmark.ID]
+			e.LocalFavicon = e.Favicon != ""
+		}
+		entries = append(entries, e)
+
+		for _, tag := range bookmark.TagNames {
+			key := strings.ToLower(tag)
+			if byTag[key] == nil {
+				byTag[key] = &tagPage{Name: tag, File: "tags/" + fileName(key) + ".html"}
+			}
+			byTag[key].Entries = append(byTag[key].Entries, e)
+		}
+	}
+
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		return b.Date.Compare(a.Date)
+	})
+
+	tags := make([]tagPage, 0, len(byTag))
+	for _, tag := range byTag {
+		slices.SortStableFunc(tag.Entries, func(a, b entry) int {
+			return b.Date.Compare(a.Date)
+		})
+		tags = append(tags, *tag)
+	}
+	slices.SortFunc(tags, func(a, b tagPage) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	if err := os.MkdirAll(filepath.Join(dir, "tags"), 0o755); err != nil {
+		return err
+	}
+
+	err := writePage(filepath.Join(dir, "index.html"), page{
+		Title:   title,
+		Root:    ".",
+		Heading: title,
+		Tags:    tags,
+		Entries: entries,
+		Search:  true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		err := writePage(filepath.Join(dir, filepath.FromSlash(tag.File)), page{
+			Title:   tag.Name + " - " + title,
+			Root:    "..",
+			Heading: "#" + tag.Name,
+			Entries: tag.Entries,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeSearchIndex(dir, entries)
+}
+
+// writeSearchIndex writes the index as search.json, for other tools, and as
+// search.js, which the index page loads with a script element since browsers
+// do not allow fetching files when a page is opened from disk.
+func writeSearchIndex(dir string, entries []entry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "search.json"), data, 0o644); err != nil {
+		return err
+	}
+
+	script := append([]byte("window.bookmarkIndex = "), data...)
+	script = append(script, ";\n"...)
+
+	return os.WriteFile(filepath.Join(dir, "search.js"), script, 0o644)
+}
+
+func writePage(name string, p page) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if err := pageTemplate.Execute(file, p); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// downloadFavicons stores the favicons in the favicons directory and returns
+// the path of the favicon of each bookmark, relative to the site root, by ID.
+// Favicons that fail to download are left out.
+func downloadFavicons(ctx context.Context, dir string, bookmarks []linkding.Bookmark, opts Options) (map[int]string, error) {
+	cache, err := favicon.New(favicon.Options{
+		Dir:        filepath.Join(dir, "favicons"),
+		HTTPClient: opts.HTTPClient,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	favicons := cache.Prefetch(ctx, bookmarks, 0)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for id, name := range favicons {
+		favicons[id] = "favicons/" + filepath.Base(name)
+	}
+
+	return favicons, nil
+}
+
+// fileName turns a tag into a file name, keeping letters, digits, dashes and
+// underscores and replacing everything else.
+func fileName(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' {
+			return r
+		}
+		return '_'
+	}, tag)
+}
+
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format(time.DateOnly) },
+	"favicon": func(root string, e entry) string {
+		if e.LocalFavicon {
+			return root + "/" + e.Favicon
+		}
+		return e.Favicon
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 50rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+a { color: #1a5fb4; text-decoration: none; }
+a:hover { text-decoration: underline; }
+ul.bookmarks { list-style: none; padding: 0; }
+ul.bookmarks li { margin: 0 0 1rem; }
+ul.bookmarks img { width: 16px; height: 16px; vertical-align: middle; margin-right: .4rem; }
+.meta, .description { color: #666; font-size: .9rem; }
+.tags a { margin-right: .5rem; }
+input[type=search] { width: 100%; padding: .5rem; font-size: 1rem; box-sizing: border-box; }
+</style>
+</head>
+<body>
+{{- $root := .Root}}
+<h1>{{if ne .Root "."}}<a href="{{$root}}/index.html">&larr;</a> {{end}}{{.Heading}}</h1>
+{{- if .Search}}
+<p><input type="search" id="search" placeholder="Search" autocomplete="off"></p>
+{{- end}}
+{{- if .Tags}}
+<p class="tags">{{range .Tags}}<a href="{{.File}}">#{{.Name}}</a> {{end}}</p>
+{{- end}}
+<ul class="bookmarks" id="bookmarks">
+{{- range .Entries}}
+<li>{{if .Favicon}}<img src="{{favicon $root .}}" alt="">{{end}}<a href="{{.URL}}">{{.Title}}</a>
+{{- with .Description}}<div class="description">{{.}}</div>{{end}}
+<div class="meta">{{if not .Date.IsZero}}{{date .Date}}{{end}}{{range .Tags}} #{{.}}{{end}}</div></li>
+{{- end}}
+</ul>
+{{- if .Search}}
+<script src="search.js"></script>
+<script>
+(function () {
+  var input = document.getElementById("search");
+  var list = document.getElementById("bookmarks");
+  var original = list.innerHTML;
+  function text(value) { var span = document.createElement("span"); span.textContent = value; return span.innerHTML; }
+  input.addEventListener("input", function () {
+    var words = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    if (!words.length) { list.innerHTML = original; return; }
+    var html = "";
+    window.bookmarkIndex.forEach(function (b) {
+      var haystack = [b.title, b.url, b.description || ""].concat(b.tags.map(function (t) { return "#" + t; })).join(" ").toLowerCase();
+      if (words.every(function (w) { return haystack.indexOf(w) >= 0; })) {
+        var a = document.createElement("a"); a.href = b.url; a.textContent = b.title;
+        html += "<li>" + a.outerHTML + (b.description ? '<div class="description">' + text(b.description) + "</div>" : "") +
+          '<div class="meta">' + text(b.tags.map(function (t) { return "#" + t; }).join(" ")) + "</div></li>";
+      }
+    });
+    list.innerHTML = html;
+  });
+})();
+</script>
+{{- end}}
+</body>
+</html>
+`))
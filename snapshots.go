@@ -0,0 +1,73 @@
+package linkding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultPollInterval is the time between polls of WaitForAsset when no
+// interval is given.
+const DefaultPollInterval = 2 * time.Second
+
+// ErrAssetFailed is returned by WaitForAsset when the server failed to create
+// an asset, e.g. because the page of a snapshot could not be loaded.
+var ErrAssetFailed = errors.New("linkding: asset creation failed")
+
+// ErrUnsupported is returned for operations the Linkding API offers no
+// endpoint for, so callers can fall back to something else.
+var ErrUnsupported = errors.New("linkding: not supported by the server")
+
+// CreateSnapshot asks the server to create a new HTML snapshot of an existing
+// bookmark and returns the pending asset. The Linkding API has no endpoint for
+// this, the server only creates snapshots itself, so it currently always
+// returns ErrUnsupported.
+func (c *Client) CreateSnapshot(ctx context.Context, bookmarkID int) (*BookmarkAsset, error) {
+	return nil, fmt.Errorf("%w: creating a snapshot of bookmark %d", ErrUnsupported, bookmarkID)
+}
+
+// RefreshSnapshot creates a new snapshot of a bookmark with CreateSnapshot and
+// waits for it to complete with WaitForAsset.
+func (c *Client) RefreshSnapshot(ctx context.Context, bookmarkID int) (*BookmarkAsset, error) {
+	asset, err := c.CreateSnapshot(ctx, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.WaitForAsset(ctx, bookmarkID, asset.ID, 0)
+}
+
+// WaitForAsset polls an asset until it is complete and returns it, so a
+// snapshot can be downloaded once the server created it. It returns the asset
+// along with ErrAssetFailed when its creation failed, and the context's error
+// when ctx is done first. A pollInterval of zero or less polls every
+// DefaultPollInterval.
+func (c *Client) WaitForAsset(ctx context.Context, bookmarkID int, assetID int, pollInterval time.Duration) (*BookmarkAsset, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		asset, err := c.Assets.Get(ctx, bookmarkID, assetID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch asset.Status {
+		case AssetStatusComplete:
+			return asset, nil
+		case AssetStatusFailure:
+			return asset, ErrAssetFailed
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
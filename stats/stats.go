@@ -0,0 +1,144 @@
+// Package stats computes statistics about the bookmarks of a Linkding
+// account, such as the number of bookmarks per tag, domain and month.
+//
+// The statistics are computed while streaming the bookmarks, so large
+// accounts do not need to be held in memory.
+package stats
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// Count is the number of bookmarks sharing a key, such as a tag.
+type Count struct {
+	Key   string
+	Count int
+}
+
+// Report holds the statistics of a set of bookmarks.
+type Report struct {
+	Total    int
+	Archived int
+	Unread   int
+	Shared   int
+	Untagged int
+
+	// The share of unread and archived bookmarks, from 0 to 1.
+	UnreadRatio   float64
+	ArchivedRatio float64
+
+	// The date the oldest and the newest bookmark was added.
+	FirstAdded time.Time
+	LastAdded  time.Time
+
+	// The number of bookmarks per tag, in lowercase, and per domain, without
+	// a leading "www.", sorted by count and then by key.
+	ByTag    []Count
+	ByDomain []Count
+	// The number of bookmarks added per month, formatted as "2006-01" in UTC,
+	// sorted by month. Months without bookmarks are missing.
+	ByMonth []Count
+}
+
+// Compute reads every bookmark of the iterator and returns their statistics.
+// It stops at the first error.
+func Compute(bookmarks iter.Seq2[linkding.Bookmark, error]) (*Report, error) {
+	report := &Report{}
+	tags := map[string]int{}
+	domains := map[string]int{}
+	months := map[string]int{}
+
+	for bookmark, err := range bookmarks {
+		if err != nil {
+			return nil, err
+		}
+
+		report.Total++
+		if bookmark.IsArchived {
+			report.Archived++
+		}
+		if bookmark.Unread {
+			report.Unread++
+		}
+		if bookmark.Shared {
+			report.Shared++
+		}
+		if len(bookmark.TagNames) == 0 {
+			report.Untagged++
+		}
+
+		seen := map[string]bool{}
+		for _, name := range bookmark.TagNames {
+			tag := strings.ToLower(name)
+			if !seen[tag] {
+				seen[tag] = true
+				tags[tag]++
+			}
+		}
+
+		if u, err := url.Parse(bookmark.URL); err == nil && u.Hostname() != "" {
+			domains[strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")]++
+		}
+
+		if added := bookmark.DateAdded; !added.IsZero() {
+			months[added.UTC().Format("2006-01")]++
+			if report.FirstAdded.IsZero() || added.Before(report.FirstAdded) {
+				report.FirstAdded = added
+			}
+			if added.After(report.LastAdded) {
+				report.LastAdded = added
+			}
+		}
+	}
+
+	if report.Total > 0 {
+		report.UnreadRatio = float64(report.Unread) / float64(report.Total)
+		report.ArchivedRatio = float64(report.Archived) / float64(report.Total)
+	}
+
+	report.ByTag = byCount(tags)
+	report.ByDomain = byCount(domains)
+	report.ByMonth = counts(months)
+	slices.SortFunc(report.ByMonth, func(a, b Count) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return report, nil
+}
+
+// Account returns the statistics of every bookmark, active and archived, of
+// an account.
+func Account(ctx context.Context, c linkding.BookmarkClient) (*Report, error) {
+	return Compute(linkding.AllBookmarksIncludingArchived(ctx, c, linkding.ListBookmarksParams{}))
+}
+
+// Top returns the first n counts, or all of them if there are fewer.
+func Top(counts []Count, n int) []Count {
+	return counts[:min(n, len(counts))]
+}
+
+func counts(m map[string]int) []Count {
+	counts := make([]Count, 0, len(m))
+	for key, count := range m {
+		counts = append(counts, Count{Key: key, Count: count})
+	}
+
+	return counts
+}
+
+func byCount(m map[string]int) []Count {
+	counts := counts(m)
+	slices.SortFunc(counts, func(a, b Count) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Key, b.Key))
+	})
+
+	return counts
+}
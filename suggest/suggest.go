@@ -0,0 +1,271 @@
+// Package suggest proposes tags for bookmarks from the way the user tagged
+// similar bookmarks before, going beyond the URL patterns of Linkding's auto
+// tagging rules.
+//
+// A Model is trained on the tagged bookmarks of an account. The words of
+// their titles, descriptions and URLs are weighted by TF-IDF, so words common
+// to most bookmarks count little, and a new bookmark is compared with the
+// bookmarks it shares words with. The tags of the most similar bookmarks are
+// suggested, weighted by similarity, along with existing tags that appear in
+// its text.
+package suggest
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/larcher/go-linkding"
+)
+
+// DefaultNeighbors is the number of similar bookmarks whose tags are
+// considered when not configured.
+const DefaultNeighbors = 10
+
+// Options configures a Model.
+type Options struct {
+	// The number of similar bookmarks whose tags are considered. Defaults to
+	// DefaultNeighbors.
+	Neighbors int
+	// The score tags get for appearing in the text of the bookmark, relative
+	// to a tag carried by an identical bookmark. Defaults to 0.5.
+	NameBoost float64
+}
+
+// Suggestion is a proposed tag.
+type Suggestion struct {
+	Tag string
+	// The confidence of the suggestion, from 0 to 1.
+	Score float64
+}
+
+// document is a tagged bookmark of the training set.
+type document struct {
+	vector map[string]float64
+	tags   []string
+}
+
+// Model suggests tags learned from tagged bookmarks. It is safe for
+// concurrent use once trained.
+type Model struct {
+	opts Options
+	docs []document
+	// The inverse document frequency of each word.
+	idf map[string]float64
+	// The bookmarks containing each word, for finding candidates quickly.
+	postings map[string][]int
+	// The tags by lowercase name, with the spelling used most.
+	tags map[string]string
+}
+
+// Train returns a model learned from the tags of the bookmarks. Untagged
+// bookmarks are ignored.
+func Train(bookmarks []linkding.Bookmark, opts Options) *Model {
+	if opts.Neighbors <= 0 {
+		opts.Neighbors = DefaultNeighbors
+	}
+	if opts.NameBoost == 0 {
+		opts.NameBoost = 0.5
+	}
+
+	m := &Model{opts: opts, idf: map[string]float64{}, postings: map[string][]int{}, tags: map[string]string{}}
+
+	spellings := map[string]map[string]int{}
+	counts := []map[string]int{}
+	for _, bookmark := range bookmarks {
+		if len(bookmark.TagNames) == 0 {
+			continue
+		}
+
+		tags := []string{}
+		for _, tag := range bookmark.TagNames {
+			key := strings.ToLower(tag)
+			if spellings[key] == nil {
+				spellings[key] = map[string]int{}
+			}
+			spellings[key][tag]++
+			if !slices.Contains(tags, key) {
+				tags = append(tags, key)
+			}
+		}
+
+		words := termCounts(bookmark)
+		for word := range words {
+			m.idf[word]++
+		}
+		counts = append(counts, words)
+		m.docs = append(m.docs, document{tags: tags})
+	}
+
+	for word, df := range m.idf {
+		m.idf[word] = math.Log(float64(len(m.docs)+1)/(df+1)) + 1
+	}
+	for i, words := range counts {
+		m.docs[i].vector = m.weigh(words)
+		for word := range words {
+			m.postings[word] = append(m.postings[word], i)
+		}
+	}
+
+	for key, names := range spellings {
+		best, most := key, 0
+		for name, count := range names {
+			if count > most || count == most && name < best {
+				best, most = name, count
+			}
+		}
+		m.tags[key] = best
+	}
+
+	return m
+}
+
+// Account trains a model on the bookmarks of an account, active and archived.
+func Account(ctx context.Context, c linkding.BookmarkClient, opts Options) (*Model, error) {
+	bookmarks := []linkding.Bookmark{}
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return Train(bookmarks, opts), nil
+}
+
+// SuggestTags returns up to n tags for the bookmark, best first, leaving out
+// the tags it already carries. The bookmark only needs a URL, but a title and
+// description, e.g. from Bookmarks.Check, give better suggestions. A count of
+// 0 or less returns every suggestion.
+func (m *Model) SuggestTags(bookmark linkding.Bookmark, n int) []Suggestion {
+	words := termCounts(bookmark)
+	vector := m.weigh(words)
+
+	scores := map[string]float64{}
+
+	// The tags of the most similar bookmarks vote with their similarity.
+	similarity := map[int]float64{}
+	for word, weight := range vector {
+		for _, i := range m.postings[word] {
+			similarity[i] += weight * m.docs[i].vector[word]
+		}
+	}
+	neighbors := make([]int, 0, len(similarity))
+	for i := range similarity {
+		neighbors = append(neighbors, i)
+	}
+	slices.SortFunc(neighbors, func(a, b int) int {
+		return cmp.Or(cmp.Compare(similarity[b], similarity[a]), cmp.Compare(a, b))
+	})
+	neighbors = neighbors[:min(len(neighbors), m.opts.Neighbors)]
+
+	total := 0.0
+	for _, i := range neighbors {
+		total += similarity[i]
+	}
+	if total > 0 {
+		for _, i := range neighbors {
+			for _, tag := range m.docs[i].tags {
+				scores[tag] += similarity[i] / total
+			}
+		}
+	}
+
+	// Tags named in the text of the bookmark are likely to fit.
+	for word := range words {
+		if _, ok := m.tags[word]; ok {
+			scores[word] += m.opts.NameBoost
+		}
+	}
+
+	for _, tag := range bookmark.TagNames {
+		delete(scores, strings.ToLower(tag))
+	}
+
+	suggestions := make([]Suggestion, 0, len(scores))
+	for key, score := range scores {
+		suggestions = append(suggestions, Suggestion{Tag: m.tags[key], Score: min(score, 1)})
+	}
+	slices.SortFunc(suggestions, func(a, b Suggestion) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.Tag, b.Tag))
+	})
+	if n > 0 && len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+
+	return suggestions
+}
+
+// weigh returns the unit length TF-IDF vector of the word counts of a
+// bookmark. Words unknown to the model are left out.
+func (m *Model) weigh(words map[string]int) map[string]float64 {
+	vector := map[string]float64{}
+	sum := 0.0
+	for word, count := range words {
+		idf, ok := m.idf[word]
+		if !ok {
+			continue
+		}
+		weight := (1 + math.Log(float64(count))) * idf
+		vector[word] = weight
+		sum += weight * weight
+	}
+
+	norm := math.Sqrt(sum)
+	for word := range vector {
+		vector[word] /= norm
+	}
+
+	return vector
+}
+
+// termCounts returns the words of the title, description and URL of a
+// bookmark with their number of occurrences.
+func termCounts(bookmark linkding.Bookmark) map[string]int {
+	text := strings.Join([]string{
+		cmp.Or(bookmark.Title, bookmark.WebsiteTitle),
+		cmp.Or(bookmark.Description, bookmark.WebsiteDescription),
+		urlWords(bookmark.URL),
+	}, " ")
+
+	counts := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len(word) < 2 || stopWords[word] {
+			continue
+		}
+		counts[word]++
+	}
+
+	return counts
+}
+
+// urlWords returns the host and path of a URL, leaving out the parts common
+// to most URLs.
+func urlWords(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	host = host[:max(0, strings.LastIndex(host, "."))]
+
+	return host + " " + u.Path
+}
+
+// stopWords are words too common to tell bookmarks apart.
+var stopWords = func() map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.Fields(`a about an and are as at be by for from has have how
+		in is it its of on or that the this to was what when where which who why will with
+		you your html htm php aspx index`) {
+		words[word] = true
+	}
+	return words
+}()
@@ -0,0 +1,118 @@
+package syncer
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// Item is a bookmark kept in a local store.
+type Item struct {
+	linkding.Bookmark
+	// The bookmark was created or modified locally and has not been pushed to
+	// the server yet. Items created locally have no ID.
+	Dirty bool
+	// The bookmark was deleted locally and has not been deleted on the server
+	// yet. Deleted items are always dirty.
+	Deleted bool
+	// When the bookmark was last modified locally, used to resolve conflicts
+	// with NewestWins.
+	LocalModified time.Time
+}
+
+// Store is a local copy of the bookmarks of an account. Items are stored by
+// URL, which Linkding keeps unique. Sync matches synced items to the bookmarks
+// of the server by ID, and moves an item when its URL changed on the server.
+type Store interface {
+	// Get returns the item with the given URL, and whether it exists.
+	Get(url string) (Item, bool, error)
+	// Put creates or replaces the item with the URL of item.
+	Put(item Item) error
+	// Delete removes the item with the given URL. Deleting an item that does
+	// not exist is not an error.
+	Delete(url string) error
+	// Items returns every item of the store.
+	Items() ([]Item, error)
+	// Watermark returns the latest modification date of the server that was
+	// synced, or the zero time before the first sync.
+	Watermark() (time.Time, error)
+	// SetWatermark stores the watermark for the next sync.
+	SetWatermark(watermark time.Time) error
+}
+
+// MemoryStore is a Store that keeps items in memory. It is safe for
+// concurrent use.
+type MemoryStore struct {
+	mu        sync.Mutex
+	items     map[string]Item
+	watermark time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: map[string]Item{}}
+}
+
+// Get returns the item with the given URL, and whether it exists.
+func (s *MemoryStore) Get(url string) (Item, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[url]
+	return item, ok, nil
+}
+
+// Put creates or replaces the item with the URL of item.
+func (s *MemoryStore) Put(item Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item.TagNames = slices.Clone(item.TagNames)
+	s.items[item.URL] = item
+	return nil
+}
+
+// Delete removes the item with the given URL.
+func (s *MemoryStore) Delete(url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, url)
+	return nil
+}
+
+// Items returns every item, sorted by URL.
+func (s *MemoryStore) Items() ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]Item, 0, len(s.items))
+	for _, item := range s.items {
+		items = append(items, item)
+	}
+	slices.SortFunc(items, func(a, b Item) int { return strings.Compare(a.URL, b.URL) })
+
+	return items, nil
+}
+
+// Watermark returns the watermark of the last sync.
+func (s *MemoryStore) Watermark() (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.watermark, nil
+}
+
+// SetWatermark stores the watermark for the next sync.
+func (s *MemoryStore) SetWatermark(watermark time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watermark = watermark
+	return nil
+}
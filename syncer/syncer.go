@@ -0,0 +1,328 @@
+// Package syncer keeps a local store of bookmarks in sync with a Linkding
+// server in both directions.
+//
+// Changes on the server are pulled using the modification dates of bookmarks,
+// so each sync only transfers what changed since the previous one. Items
+// modified locally are marked as dirty in the store and pushed to the server.
+// When a bookmark changed on both sides, the conflict is resolved according to
+// the configured resolution.
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/larcher/go-linkding"
+)
+
+// Resolution decides which side wins when a bookmark was changed both
+// locally and on the server since the last sync.
+type Resolution int
+
+const (
+	// ServerWins discards the local changes.
+	ServerWins Resolution = iota
+	// LocalWins overwrites the server with the local changes.
+	LocalWins
+	// NewestWins keeps the side that was modified last, comparing the local
+	// modification date with the modification date of the server.
+	NewestWins
+)
+
+// Options configures Sync.
+type Options struct {
+	// How conflicts are resolved. Defaults to ServerWins.
+	Resolution Resolution
+	// List every bookmark of the server instead of only the changed ones, to
+	// detect bookmarks that were deleted on the server. The first sync of an
+	// empty store is always a full scan.
+	FullScan bool
+}
+
+// Conflict is a bookmark that was changed both locally and on the server.
+type Conflict struct {
+	Local Item
+	// The bookmark on the server. It is the zero bookmark if the bookmark was
+	// deleted on the server.
+	Server linkding.Bookmark
+	// The side that won.
+	Winner Resolution
+}
+
+// Summary is the outcome of a sync.
+type Summary struct {
+	// The number of bookmarks created or updated in the store from the server.
+	Pulled int
+	// The number of bookmarks created, updated or deleted on the server.
+	Pushed int
+	// The number of items removed from the store because they were deleted on
+	// the server.
+	Removed int
+	// The conflicts that were resolved.
+	Conflicts []Conflict
+	// The local changes that could not be pushed, by URL, with the reason.
+	// They stay dirty and are pushed again by the next sync.
+	Failed map[string]error
+}
+
+// Sync reconciles the store with the server: it pulls the bookmarks changed on
+// the server since the watermark of the store, pushes the dirty items of the
+// store, and advances the watermark.
+//
+// Failing pushes are reported in the returned summary. An error is returned
+// if the server or the store could not be read or written otherwise, in which
+// case the watermark is left unchanged and the next sync starts over.
+func Sync(ctx context.Context, c linkding.BookmarkClient, store Store, opts Options) (*Summary, error) {
+	summary := &Summary{Conflicts: []Conflict{}, Failed: map[string]error{}}
+
+	watermark, err := store.Watermark()
+	if err != nil {
+		return nil, err
+	}
+	fullScan := opts.FullScan || watermark.IsZero()
+
+	params := linkding.ListBookmarksParams{}
+	if !fullScan {
+		params.ModifiedSince = watermark
+	}
+
+	bookmarks, err := fetch(ctx, c, params)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := store.Items()
+	if err != nil {
+		return nil, err
+	}
+	synced := make(map[int]Item, len(items))
+	for _, item := range items {
+		if item.ID != 0 {
+			synced[item.ID] = item
+		}
+	}
+
+	latest := watermark
+	seen := make(map[int]bool, len(bookmarks))
+	pushed := map[int]bool{}
+	for _, bookmark := range bookmarks {
+		seen[bookmark.ID] = true
+		if bookmark.DateModified.After(latest) {
+			latest = bookmark.DateModified
+		}
+
+		if err := pull(c, store, synced, bookmark, opts, summary, pushed); err != nil {
+			return nil, err
+		}
+	}
+
+	items, err = store.Items()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case item.ID != 0 && pushed[item.ID]:
+			continue
+		case fullScan && item.ID != 0 && !seen[item.ID]:
+			if err := serverDeleted(c, store, item, opts, summary); err != nil {
+				return nil, err
+			}
+		case item.Dirty:
+			if err := push(c, store, item, summary); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Pushed bookmarks are listed again by the next pull, where they are
+	// skipped as the store already has their modification date.
+	if err := store.SetWatermark(latest); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// pull applies a bookmark changed on the server to the store. Synced items
+// are matched by the ID of their bookmark, so an item follows changes of the
+// URL on the server. Only items created locally, which have no ID yet, are
+// matched by URL.
+func pull(c linkding.BookmarkClient, store Store, synced map[int]Item, bookmark linkding.Bookmark, opts Options, summary *Summary, pushed map[int]bool) error {
+	local, found := synced[bookmark.ID]
+	if !found {
+		created, ok, err := store.Get(bookmark.URL)
+		if err != nil {
+			return err
+		}
+		local, found = created, ok && created.ID == 0
+	}
+
+	// The API compares dates with a precision of seconds, so bookmarks that
+	// were already pulled can be listed again.
+	if found && !bookmark.DateModified.After(local.DateModified) {
+		return nil
+	}
+
+	if found && local.Dirty {
+		winner := resolve(local, bookmark, opts.Resolution)
+		summary.Conflicts = append(summary.Conflicts, Conflict{Local: local, Server: bookmark, Winner: winner})
+
+		if winner == LocalWins {
+			local.ID = bookmark.ID
+			pushed[local.ID] = true
+			return push(c, store, local, summary)
+		}
+	}
+
+	if found && local.URL != bookmark.URL {
+		if err := remove(store, local.URL, local.ID); err != nil {
+			return err
+		}
+	}
+
+	summary.Pulled++
+	synced[bookmark.ID] = Item{Bookmark: bookmark}
+	return store.Put(Item{Bookmark: bookmark})
+}
+
+// remove deletes the item stored under url if it is the item of the bookmark
+// with the given ID, and not already another one.
+func remove(store Store, url string, id int) error {
+	item, found, err := store.Get(url)
+	if err != nil || !found || item.ID != id {
+		return err
+	}
+
+	return store.Delete(url)
+}
+
+// serverDeleted handles an item that was synced before but no longer exists
+// on the server.
+func serverDeleted(c linkding.BookmarkClient, store Store, item Item, opts Options, summary *Summary) error {
+	if item.Deleted {
+		return store.Delete(item.URL)
+	}
+
+	if item.Dirty {
+		winner := resolve(item, linkding.Bookmark{}, opts.Resolution)
+		summary.Conflicts = append(summary.Conflicts, Conflict{Local: item, Winner: winner})
+
+		if winner == LocalWins {
+			item.ID = 0
+			return push(c, store, item, summary)
+		}
+	}
+
+	summary.Removed++
+	return store.Delete(item.URL)
+}
+
+// push applies a dirty item to the server. Errors returned by the server are
+// recorded in the summary, while errors of the store are returned.
+func push(c linkding.BookmarkClient, store Store, item Item, summary *Summary) error {
+	if item.Deleted {
+		if item.ID != 0 {
+			if err := c.DeleteBookmark(item.ID); err != nil && !errors.Is(err, linkding.ErrNotFound) {
+				summary.Failed[item.URL] = err
+				return nil
+			}
+		}
+
+		summary.Pushed++
+		return store.Delete(item.URL)
+	}
+
+	bookmark, err := save(c, item)
+	if err != nil {
+		summary.Failed[item.URL] = err
+		return nil
+	}
+
+	// The server may have changed the URL, e.g. when normalizing it.
+	if bookmark.URL != item.URL {
+		if err := remove(store, item.URL, item.ID); err != nil {
+			return err
+		}
+	}
+
+	summary.Pushed++
+	return store.Put(Item{Bookmark: *bookmark})
+}
+
+// save creates or updates the bookmark of an item on the server, archiving or
+// unarchiving it as needed.
+func save(c linkding.BookmarkClient, item Item) (*linkding.Bookmark, error) {
+	payload := linkding.CreateBookmarkRequest{
+		URL:         item.URL,
+		Title:       item.Title,
+		Description: item.Description,
+		Notes:       item.Notes,
+		IsArchived:  item.IsArchived,
+		Unread:      item.Unread,
+		Shared:      item.Shared,
+		TagNames:    slices.Clone(item.TagNames),
+	}
+	if payload.TagNames == nil {
+		payload.TagNames = []string{}
+	}
+
+	var bookmark *linkding.Bookmark
+	var err error
+	if item.ID == 0 {
+		bookmark, err = c.CreateBookmark(payload)
+	} else {
+		bookmark, err = c.UpdateBookmark(item.ID, payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if bookmark.IsArchived != item.IsArchived {
+		if item.IsArchived {
+			err = c.ArchiveBookmark(bookmark.ID)
+		} else {
+			err = c.UnarchiveBookmark(bookmark.ID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bookmark %d: %w", bookmark.ID, err)
+		}
+
+		return c.GetBookmark(bookmark.ID)
+	}
+
+	return bookmark, nil
+}
+
+func resolve(local Item, server linkding.Bookmark, resolution Resolution) Resolution {
+	if resolution != NewestWins {
+		return resolution
+	}
+
+	if local.LocalModified.After(server.DateModified) {
+		return LocalWins
+	}
+
+	return ServerWins
+}
+
+// fetch lists the active and archived bookmarks of the server.
+func fetch(ctx context.Context, c linkding.BookmarkClient, params linkding.ListBookmarksParams) ([]linkding.Bookmark, error) {
+	bookmarks := []linkding.Bookmark{}
+
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, params) {
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return bookmarks, nil
+}
@@ -0,0 +1,339 @@
+package syncer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/linkdingtest"
+	"github.com/larcher/go-linkding/syncer"
+)
+
+// clock returns a time a second later on every call, so every change gets its
+// own modification date.
+func clock() func() time.Time {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func TestSync(t *testing.T) {
+	const goURL = "https://go.dev"
+
+	tests := []struct {
+		name   string
+		opts   syncer.Options
+		server func(t *testing.T, f *linkdingtest.Fake, id int)
+		local  func(t *testing.T, s *syncer.MemoryStore, now time.Time)
+		check  func(t *testing.T, f *linkdingtest.Fake, s *syncer.MemoryStore, id int, summary *syncer.Summary)
+	}{
+		{
+			name: "created on server",
+			server: func(t *testing.T, f *linkdingtest.Fake, _ int) {
+				create(t, f, "https://pkg.go.dev", "Packages")
+			},
+			check: func(t *testing.T, _ *linkdingtest.Fake, s *syncer.MemoryStore, _ int, summary *syncer.Summary) {
+				if item := get(t, s, "https://pkg.go.dev"); item.Title != "Packages" || item.Dirty {
+					t.Errorf("item = %+v, want the pulled bookmark", item)
+				}
+				if summary.Pulled != 1 {
+					t.Errorf("Pulled = %d, want 1", summary.Pulled)
+				}
+			},
+		},
+		{
+			name: "created locally",
+			local: func(t *testing.T, s *syncer.MemoryStore, now time.Time) {
+				put(t, s, syncer.Item{
+					Bookmark:      linkding.Bookmark{URL: "https://pkg.go.dev", Title: "Packages"},
+					Dirty:         true,
+					LocalModified: now,
+				})
+			},
+			check: func(t *testing.T, f *linkdingtest.Fake, s *syncer.MemoryStore, _ int, summary *syncer.Summary) {
+				item := get(t, s, "https://pkg.go.dev")
+				if item.ID == 0 || item.Dirty {
+					t.Fatalf("item = %+v, want the pushed bookmark", item)
+				}
+				if bookmark := server(t, f, item.ID); bookmark.Title != "Packages" {
+					t.Errorf("server title = %q, want %q", bookmark.Title, "Packages")
+				}
+				if summary.Pushed != 1 {
+					t.Errorf("Pushed = %d, want 1", summary.Pushed)
+				}
+			},
+		},
+		{
+			name: "updated on server",
+			server: func(t *testing.T, f *linkdingtest.Fake, id int) {
+				patchTitle(t, f, id, "Go!")
+			},
+			check: func(t *testing.T, _ *linkdingtest.Fake, s *syncer.MemoryStore, _ int, _ *syncer.Summary) {
+				if item := get(t, s, goURL); item.Title != "Go!" {
+					t.Errorf("local title = %q, want %q", item.Title, "Go!")
+				}
+			},
+		},
+		{
+			name: "updated locally",
+			local: func(t *testing.T, s *syncer.MemoryStore, now time.Time) {
+				modify(t, s, goURL, now, func(item *syncer.Item) { item.Title = "Go!" })
+			},
+			check: func(t *testing.T, f *linkdingtest.Fake, s *syncer.MemoryStore, id int, _ *syncer.Summary) {
+				if bookmark := server(t, f, id); bookmark.Title != "Go!" {
+					t.Errorf("server title = %q, want %q", bookmark.Title, "Go!")
+				}
+				if item := get(t, s, goURL); item.Dirty {
+					t.Error("item still dirty after push")
+				}
+			},
+		},
+		{
+			name: "deleted on server",
+			opts: syncer.Options{FullScan: true},
+			server: func(t *testing.T, f *linkdingtest.Fake, id int) {
+				if err := f.DeleteBookmark(id); err != nil {
+					t.Fatal(err)
+				}
+			},
+			check: func(t *testing.T, _ *linkdingtest.Fake, s *syncer.MemoryStore, _ int, summary *syncer.Summary) {
+				if _, found, _ := s.Get(goURL); found {
+					t.Error("item not removed from store")
+				}
+				if summary.Removed != 1 {
+					t.Errorf("Removed = %d, want 1", summary.Removed)
+				}
+			},
+		},
+		{
+			name: "deleted locally",
+			local: func(t *testing.T, s *syncer.MemoryStore, now time.Time) {
+				modify(t, s, goURL, now, func(item *syncer.Item) { item.Deleted = true })
+			},
+			check: func(t *testing.T, f *linkdingtest.Fake, s *syncer.MemoryStore, id int, _ *syncer.Summary) {
+				if _, err := f.GetBookmark(id); err != linkding.ErrNotFound {
+					t.Errorf("GetBookmark error = %v, want ErrNotFound", err)
+				}
+				if _, found, _ := s.Get(goURL); found {
+					t.Error("item not removed from store")
+				}
+			},
+		},
+		{
+			name: "URL changed on server",
+			server: func(t *testing.T, f *linkdingtest.Fake, id int) {
+				url := "https://go.dev/"
+				if _, err := f.PatchBookmark(id, linkding.PatchBookmarkRequest{URL: &url}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			check: func(t *testing.T, _ *linkdingtest.Fake, s *syncer.MemoryStore, id int, _ *syncer.Summary) {
+				if _, found, _ := s.Get(goURL); found {
+					t.Error("item still stored under the old URL")
+				}
+				if item := get(t, s, "https://go.dev/"); item.ID != id {
+					t.Errorf("ID = %d, want %d", item.ID, id)
+				}
+			},
+		},
+		{
+			name: "conflict, server wins",
+			opts: syncer.Options{Resolution: syncer.ServerWins},
+			server: func(t *testing.T, f *linkdingtest.Fake, id int) {
+				patchTitle(t, f, id, "Server")
+			},
+			local: func(t *testing.T, s *syncer.MemoryStore, now time.Time) {
+				modify(t, s, goURL, now, func(item *syncer.Item) { item.Title = "Local" })
+			},
+			check: func(t *testing.T, f *linkdingtest.Fake, s *syncer.MemoryStore, id int, summary *syncer.Summary) {
+				checkConflict(t, summary, syncer.ServerWins)
+				if item := get(t, s, goURL); item.Title != "Server" || item.Dirty {
+					t.Errorf("item = %+v, want the server bookmark", item)
+				}
+				if bookmark := server(t, f, id); bookmark.Title != "Server" {
+					t.Errorf("server title = %q, want %q", bookmark.Title, "Server")
+				}
+			},
+		},
+		{
+			name: "conflict, local wins",
+			opts: syncer.Options{Resolution: syncer.LocalWins},
+			server: func(t *testing.T, f *linkdingtest.Fake, id int) {
+				patchTitle(t, f, id, "Server")
+			},
+			local: func(t *testing.T, s *syncer.MemoryStore, now time.Time) {
+				modify(t, s, goURL, now, func(item *syncer.Item) { item.Title = "Local" })
+			},
+			check: func(t *testing.T, f *linkdingtest.Fake, s *syncer.MemoryStore, id int, summary *syncer.Summary) {
+				checkConflict(t, summary, syncer.LocalWins)
+				if item := get(t, s, goURL); item.Title != "Local" || item.Dirty {
+					t.Errorf("item = %+v, want the pushed local item", item)
+				}
+				if bookmark := server(t, f, id); bookmark.Title != "Local" {
+					t.Errorf("server title = %q, want %q", bookmark.Title, "Local")
+				}
+			},
+		},
+		{
+			name: "conflict, newest wins",
+			opts: syncer.Options{Resolution: syncer.NewestWins},
+			local: func(t *testing.T, s *syncer.MemoryStore, now time.Time) {
+				modify(t, s, goURL, now, func(item *syncer.Item) { item.Title = "Local" })
+			},
+			// The server changes after the local change, so it is newer.
+			server: func(t *testing.T, f *linkdingtest.Fake, id int) {
+				patchTitle(t, f, id, "Server")
+			},
+			check: func(t *testing.T, _ *linkdingtest.Fake, s *syncer.MemoryStore, _ int, summary *syncer.Summary) {
+				checkConflict(t, summary, syncer.ServerWins)
+				if item := get(t, s, goURL); item.Title != "Server" {
+					t.Errorf("local title = %q, want %q", item.Title, "Server")
+				}
+			},
+		},
+		{
+			name: "deleted on server, changed locally, local wins",
+			opts: syncer.Options{Resolution: syncer.LocalWins, FullScan: true},
+			server: func(t *testing.T, f *linkdingtest.Fake, id int) {
+				if err := f.DeleteBookmark(id); err != nil {
+					t.Fatal(err)
+				}
+			},
+			local: func(t *testing.T, s *syncer.MemoryStore, now time.Time) {
+				modify(t, s, goURL, now, func(item *syncer.Item) { item.Title = "Local" })
+			},
+			check: func(t *testing.T, f *linkdingtest.Fake, s *syncer.MemoryStore, id int, summary *syncer.Summary) {
+				checkConflict(t, summary, syncer.LocalWins)
+				item := get(t, s, goURL)
+				if item.ID == 0 || item.ID == id {
+					t.Fatalf("ID = %d, want the ID of a recreated bookmark", item.ID)
+				}
+				if bookmark := server(t, f, item.ID); bookmark.Title != "Local" {
+					t.Errorf("server title = %q, want %q", bookmark.Title, "Local")
+				}
+			},
+		},
+		{
+			name: "deleted on server, changed locally, server wins",
+			opts: syncer.Options{Resolution: syncer.ServerWins, FullScan: true},
+			server: func(t *testing.T, f *linkdingtest.Fake, id int) {
+				if err := f.DeleteBookmark(id); err != nil {
+					t.Fatal(err)
+				}
+			},
+			local: func(t *testing.T, s *syncer.MemoryStore, now time.Time) {
+				modify(t, s, goURL, now, func(item *syncer.Item) { item.Title = "Local" })
+			},
+			check: func(t *testing.T, f *linkdingtest.Fake, s *syncer.MemoryStore, _ int, summary *syncer.Summary) {
+				checkConflict(t, summary, syncer.ServerWins)
+				if _, found, _ := s.Get(goURL); found {
+					t.Error("item not removed from store")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			now := clock()
+			f := linkdingtest.NewFake()
+			f.Now = now
+			s := syncer.NewMemoryStore()
+
+			id := create(t, f, goURL, "Go").ID
+			if _, err := syncer.Sync(ctx, f, s, syncer.Options{}); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.local != nil {
+				tt.local(t, s, now())
+			}
+			if tt.server != nil {
+				tt.server(t, f, id)
+			}
+
+			summary, err := syncer.Sync(ctx, f, s, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(summary.Failed) > 0 {
+				t.Fatalf("Failed = %v", summary.Failed)
+			}
+			tt.check(t, f, s, id, summary)
+		})
+	}
+}
+
+func create(t *testing.T, f *linkdingtest.Fake, url, title string) *linkding.Bookmark {
+	t.Helper()
+
+	bookmark, err := f.CreateBookmark(linkding.NewBookmark(url).Title(title).Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bookmark
+}
+
+func patchTitle(t *testing.T, f *linkdingtest.Fake, id int, title string) {
+	t.Helper()
+
+	if _, err := f.PatchBookmark(id, linkding.PatchBookmarkRequest{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func server(t *testing.T, f *linkdingtest.Fake, id int) *linkding.Bookmark {
+	t.Helper()
+
+	bookmark, err := f.GetBookmark(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bookmark
+}
+
+func get(t *testing.T, s *syncer.MemoryStore, url string) syncer.Item {
+	t.Helper()
+
+	item, found, err := s.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatalf("no item for %s", url)
+	}
+	return item
+}
+
+func put(t *testing.T, s *syncer.MemoryStore, item syncer.Item) {
+	t.Helper()
+
+	if err := s.Put(item); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func modify(t *testing.T, s *syncer.MemoryStore, url string, now time.Time, change func(item *syncer.Item)) {
+	t.Helper()
+
+	item := get(t, s, url)
+	change(&item)
+	item.Dirty = true
+	item.LocalModified = now
+	put(t, s, item)
+}
+
+func checkConflict(t *testing.T, summary *syncer.Summary, winner syncer.Resolution) {
+	t.Helper()
+
+	if len(summary.Conflicts) != 1 {
+		t.Fatalf("got %d conflicts, want 1", len(summary.Conflicts))
+	}
+	if got := summary.Conflicts[0].Winner; got != winner {
+		t.Errorf("winner = %v, want %v", got, winner)
+	}
+}
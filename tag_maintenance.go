@@ -0,0 +1,112 @@
+package linkding
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// TagUpdateOptions configures operations rewriting tags across all
+// bookmarks.
+type TagUpdateOptions struct {
+	// Called after each bookmark has been visited, with the number of
+	// bookmarks visited so far and the total. Calls are serialized, but made
+	// from the goroutines updating the bookmarks.
+	Progress func(done, total int)
+}
+
+// RenameTag renames a tag on every bookmark, active or archived, carrying it.
+// The API has no way to rename a tag itself, so each bookmark is updated with
+// the new tag in place of the old one. Tags are matched ignoring case like
+// Linkding does, and the old tag is not added twice to bookmarks that already
+// carry the new one. The old tag remains in the tag list, without bookmarks.
+//
+// Renaming can be resumed: bookmarks already updated no longer carry the old
+// tag, so calling RenameTag again after an interruption only visits the
+// remaining ones. An error is only returned if the bookmarks carrying the old
+// tag could not be listed.
+func (c *Client) RenameTag(ctx context.Context, oldName, newName string, opts TagUpdateOptions) (*BulkResult, error) {
+	return c.replaceTags(ctx, []string{oldName}, newName, opts)
+}
+
+// MergeTags consolidates several tags into one on every bookmark, active or
+// archived, carrying any of the tags from. Each of them is replaced by into,
+// which is kept once on bookmarks carrying several of the tags or carrying
+// into already. Bookmarks only carrying into are not visited.
+//
+// The result holds an item for each bookmark touched. Like RenameTag, merging
+// can be resumed by calling it again. An error is only returned if the
+// bookmarks could not be listed.
+func (c *Client) MergeTags(ctx context.Context, into string, from ...string) (*BulkResult, error) {
+	from = slices.DeleteFunc(slices.Clone(from), func(tag string) bool {
+		return strings.EqualFold(tag, into)
+	})
+
+	return c.replaceTags(ctx, from, into, TagUpdateOptions{})
+}
+
+// replaceTags replaces the tags from with the tag to on every bookmark
+// carrying any of them.
+func (c *Client) replaceTags(ctx context.Context, from []string, to string, opts TagUpdateOptions) (*BulkResult, error) {
+	bookmarks := []Bookmark{}
+	seen := map[int]bool{}
+	for _, tag := range from {
+		matches, err := c.collectMatching(ctx, ListBookmarksParams{Query: EscapeTag(tag)}, true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, bookmark := range matches {
+			// The search also matches bookmarks mentioning the tag in their
+			// description, so the tags are checked again here.
+			if !seen[bookmark.ID] && containsTag(bookmark.TagNames, tag) {
+				seen[bookmark.ID] = true
+				bookmarks = append(bookmarks, bookmark)
+			}
+		}
+	}
+
+	return c.updateEach(ctx, bookmarks, withProgress(len(bookmarks), opts.Progress, func(ctx context.Context, bookmark Bookmark) (*Bookmark, error) {
+		tags := replaceTag(bookmark.TagNames, from, to)
+		return c.Bookmarks.Patch(ctx, bookmark.ID, PatchBookmarkRequest{TagNames: &tags})
+	})), nil
+}
+
+// withProgress wraps fn to report progress after each call, if progress is
+// not nil. Calls to progress are serialized.
+func withProgress(total int, progress func(done, total int), fn func(ctx context.Context, bookmark Bookmark) (*Bookmark, error)) func(ctx context.Context, bookmark Bookmark) (*Bookmark, error) {
+	if progress == nil {
+		return fn
+	}
+
+	var mu sync.Mutex
+	done := 0
+
+	return func(ctx context.Context, bookmark Bookmark) (*Bookmark, error) {
+		defer func() {
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			progress(done, total)
+		}()
+
+		return fn(ctx, bookmark)
+	}
+}
+
+// replaceTag returns names with the tags from replaced by to, at the position
+// of the first one replaced, keeping to only once.
+func replaceTag(names, from []string, to string) []string {
+	replaced := make([]string, 0, len(names))
+	for _, name := range names {
+		if slices.ContainsFunc(from, func(tag string) bool { return strings.EqualFold(tag, name) }) {
+			name = to
+		}
+		if !containsTag(replaced, name) {
+			replaced = append(replaced, name)
+		}
+	}
+
+	return replaced
+}
@@ -0,0 +1,109 @@
+package linkding
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultTagNormalizer lowercases tags, replaces whitespace with dashes and
+// strips diacritics.
+var DefaultTagNormalizer = TagNormalizer{
+	Lowercase:       true,
+	ReplaceSpaces:   true,
+	StripDiacritics: true,
+}
+
+// TagNormalizer rewrites tags to a consistent form, so that variants such as
+// "Golang", "golang" and "go lang" do not accumulate as separate tags.
+type TagNormalizer struct {
+	// Lowercase tags. Linkding matches tags ignoring case, but keeps the case
+	// a tag was first created with.
+	Lowercase bool
+	// Replace whitespace with dashes, which keeps tags from multiple words
+	// together, as Linkding splits tags at whitespace.
+	ReplaceSpaces bool
+	// Remove diacritics, e.g. turning "café" into "cafe".
+	StripDiacritics bool
+	// Truncate tags to at most this many characters. Tags are not truncated
+	// when zero.
+	MaxLength int
+}
+
+// Normalize returns the normalized form of a tag.
+func (n TagNormalizer) Normalize(tag string) string {
+	tag = strings.TrimSpace(tag)
+
+	if n.ReplaceSpaces {
+		tag = strings.Join(strings.Fields(tag), "-")
+	}
+	if n.Lowercase {
+		tag = strings.ToLower(tag)
+	}
+	if n.StripDiacritics {
+		tag = norm.NFC.String(strings.Map(func(r rune) rune {
+			if unicode.Is(unicode.Mn, r) {
+				return -1
+			}
+			return r
+		}, norm.NFD.String(tag)))
+	}
+	if runes := []rune(tag); n.MaxLength > 0 && len(runes) > n.MaxLength {
+		tag = string(runes[:n.MaxLength])
+	}
+
+	return tag
+}
+
+// NormalizeAll returns the normalized form of every tag, dropping tags that
+// become empty or a duplicate of an earlier one. A nil slice is returned as
+// is.
+func (n TagNormalizer) NormalizeAll(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = n.Normalize(tag); tag != "" && !containsTag(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	return normalized
+}
+
+// NormalizeTags normalizes the tags of every bookmark, active or archived, of
+// the account, to clean up tags created before a normalizer was configured
+// with WithTagNormalizer. Only bookmarks whose tags change are updated and
+// part of the result. The tags of the previous form remain in the tag list,
+// without bookmarks.
+//
+// An error is only returned if the bookmarks could not be listed.
+func (c *Client) NormalizeTags(ctx context.Context, n TagNormalizer, opts TagUpdateOptions) (*BulkResult, error) {
+	bookmarks, err := c.collectMatching(ctx, ListBookmarksParams{}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks = filterBookmarks(bookmarks, func(bookmark Bookmark) bool {
+		return !slices.Equal(n.NormalizeAll(bookmark.TagNames), bookmark.TagNames)
+	})
+
+	return c.updateEach(ctx, bookmarks, withProgress(len(bookmarks), opts.Progress, func(ctx context.Context, bookmark Bookmark) (*Bookmark, error) {
+		tags := n.NormalizeAll(bookmark.TagNames)
+		return c.Bookmarks.Patch(ctx, bookmark.ID, PatchBookmarkRequest{TagNames: &tags})
+	})), nil
+}
+
+// normalizeTags applies the tag normalizer of the client, if any.
+func (c *Client) normalizeTags(tags []string) []string {
+	if c.tagNormalizer == nil {
+		return tags
+	}
+
+	return c.tagNormalizer.NormalizeAll(tags)
+}
@@ -1,6 +1,7 @@
 package linkding
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,6 +25,9 @@ type ListTagsResponse struct {
 	Next     string `json:"next"`
 	Previous string `json:"previous"`
 	Results  []Tag  `json:"results"`
+
+	// Unknown fields, only collected when using DecodeTolerant.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Tag represents a tag object in the Linkding API.
@@ -31,6 +35,9 @@ type Tag struct {
 	ID        int       `json:"id"`
 	Name      string    `json:"name"`
 	DateAdded time.Time `json:"date_added"`
+
+	// Unknown fields, only collected when using DecodeTolerant.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // CreateTagRequest represents the request body when creating a new tag.
@@ -38,57 +45,89 @@ type CreateTagRequest struct {
 	Name string `json:"name"`
 }
 
-// ListTags retrieves a list of tags from Linkding based on the provided
+// TagsService handles the tag endpoints of the Linkding API.
+type TagsService interface {
+	List(ctx context.Context, params ListTagsParams, opts ...RequestOption) (*ListTagsResponse, error)
+	Get(ctx context.Context, id int, opts ...RequestOption) (*Tag, error)
+	Create(ctx context.Context, name string, opts ...RequestOption) (*Tag, error)
+}
+
+type tagsService struct {
+	client *Client
+}
+
+// List retrieves a list of tags from Linkding based on the provided
 // parameters.
-func (c *Client) ListTags(params ListTagsParams) (*ListTagsResponse, error) {
+func (s *tagsService) List(ctx context.Context, params ListTagsParams, opts ...RequestOption) (*ListTagsResponse, error) {
 	path := buildTagsQueryString("/api/tags", params)
 
-	body, err := c.makeRequest(http.MethodGet, path, nil)
+	body, err := s.client.makeRequest(ctx, http.MethodGet, path, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 	defer body.Close()
 
 	result := &ListTagsResponse{}
-	if err := json.NewDecoder(body).Decode(result); err != nil {
+	if err := s.client.decode(body, result); err != nil {
 		return nil, err
 	}
 
 	return result, nil
 }
 
-// GetTag retrieves a single tag from Linkding.
-func (c *Client) GetTag(id int) (*Tag, error) {
-	body, err := c.makeRequest(http.MethodGet, fmt.Sprintf("/api/tags/%d/", id), nil)
+// Get retrieves a single tag from Linkding.
+func (s *tagsService) Get(ctx context.Context, id int, opts ...RequestOption) (*Tag, error) {
+	body, err := s.client.makeRequest(ctx, http.MethodGet, fmt.Sprintf("/api/tags/%d/", id), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 	defer body.Close()
 
 	tag := &Tag{}
-	if err := json.NewDecoder(body).Decode(tag); err != nil {
+	if err := s.client.decode(body, tag); err != nil {
 		return nil, err
 	}
 
 	return tag, nil
 }
 
-// CreateTag creates a new tag in Linkding with the provided name.
-func (c *Client) CreateTag(name string) (*Tag, error) {
-	body, err := c.makeRequest(http.MethodPost, "/api/tags/", CreateTagRequest{Name: name})
+// Create creates a new tag in Linkding with the provided name.
+func (s *tagsService) Create(ctx context.Context, name string, opts ...RequestOption) (*Tag, error) {
+	if s.client.tagNormalizer != nil {
+		name = s.client.tagNormalizer.Normalize(name)
+	}
+
+	body, err := s.client.makeRequest(ctx, http.MethodPost, "/api/tags/", CreateTagRequest{Name: name}, opts...)
 	if err != nil {
 		return nil, err
 	}
 	defer body.Close()
 
 	tag := &Tag{}
-	if err := json.NewDecoder(body).Decode(tag); err != nil {
+	if err := s.client.decode(body, tag); err != nil {
 		return nil, err
 	}
 
 	return tag, nil
 }
 
+// ListTags retrieves a list of tags from Linkding based on the provided
+// parameters. It is a shorthand for Tags.List.
+func (c *Client) ListTags(params ListTagsParams) (*ListTagsResponse, error) {
+	return c.Tags.List(context.Background(), params)
+}
+
+// GetTag retrieves a single tag from Linkding. It is a shorthand for Tags.Get.
+func (c *Client) GetTag(id int) (*Tag, error) {
+	return c.Tags.Get(context.Background(), id)
+}
+
+// CreateTag creates a new tag in Linkding with the provided name. It is a
+// shorthand for Tags.Create.
+func (c *Client) CreateTag(name string) (*Tag, error) {
+	return c.Tags.Create(context.Background(), name)
+}
+
 func buildTagsQueryString(path string, params ListTagsParams) string {
 	values := url.Values{}
 
@@ -0,0 +1,129 @@
+package tagstats
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+)
+
+// Pair is two tags carried together by bookmarks. A is sorted before B.
+type Pair struct {
+	A, B string
+	// The number of bookmarks carrying both tags.
+	Count int
+	// The share of the bookmarks carrying either tag that carry both, from 0
+	// to 1. Pairs close to 1 are candidates for merging into one tag.
+	Jaccard float64
+}
+
+// CoOccurrence counts how often tags are carried together by bookmarks.
+// Tags are compared ignoring case and reported in lowercase.
+type CoOccurrence struct {
+	// The number of bookmarks carrying each tag.
+	Tags map[string]int
+	// The number of bookmarks carrying each pair of tags. The matrix is
+	// symmetric, and pairs that never occur together are missing.
+	Pairs map[string]map[string]int
+}
+
+// CountCoOccurrence computes the co-occurrence of the tags of bookmarks.
+func CountCoOccurrence(bookmarks []linkding.Bookmark) *CoOccurrence {
+	co := &CoOccurrence{Tags: map[string]int{}, Pairs: map[string]map[string]int{}}
+
+	for _, bookmark := range bookmarks {
+		tags := make([]string, 0, len(bookmark.TagNames))
+		for _, name := range bookmark.TagNames {
+			tags = append(tags, strings.ToLower(name))
+		}
+		slices.Sort(tags)
+		tags = slices.Compact(tags)
+
+		for i, a := range tags {
+			co.Tags[a]++
+			for _, b := range tags[i+1:] {
+				co.add(a, b)
+				co.add(b, a)
+			}
+		}
+	}
+
+	return co
+}
+
+func (co *CoOccurrence) add(a, b string) {
+	if co.Pairs[a] == nil {
+		co.Pairs[a] = map[string]int{}
+	}
+	co.Pairs[a][b]++
+}
+
+// Count returns the number of bookmarks carrying both tags.
+func (co *CoOccurrence) Count(a, b string) int {
+	return co.Pairs[strings.ToLower(a)][strings.ToLower(b)]
+}
+
+// TopPairs returns the n pairs carried together by the most bookmarks, or all
+// pairs if n is not positive. Ties are broken by the Jaccard index and then by
+// name.
+func (co *CoOccurrence) TopPairs(n int) []Pair {
+	pairs := []Pair{}
+	for a, related := range co.Pairs {
+		for b, count := range related {
+			if a < b {
+				pairs = append(pairs, co.pair(a, b, count))
+			}
+		}
+	}
+
+	slices.SortFunc(pairs, func(x, y Pair) int {
+		return cmp.Or(
+			cmp.Compare(y.Count, x.Count),
+			cmp.Compare(y.Jaccard, x.Jaccard),
+			strings.Compare(x.A, y.A),
+			strings.Compare(x.B, y.B),
+		)
+	})
+
+	if n > 0 && len(pairs) > n {
+		pairs = pairs[:n]
+	}
+	return pairs
+}
+
+// Related returns the n tags carried together with tag by the most bookmarks,
+// or all of them if n is not positive, e.g. to suggest tags in a frontend.
+// The first tag of each pair is tag itself.
+func (co *CoOccurrence) Related(tag string, n int) []Pair {
+	tag = strings.ToLower(tag)
+
+	pairs := []Pair{}
+	for other, count := range co.Pairs[tag] {
+		pair := co.pair(tag, other, count)
+		pair.A, pair.B = tag, other
+		pairs = append(pairs, pair)
+	}
+
+	slices.SortFunc(pairs, func(x, y Pair) int {
+		return cmp.Or(cmp.Compare(y.Count, x.Count), strings.Compare(x.B, y.B))
+	})
+
+	if n > 0 && len(pairs) > n {
+		pairs = pairs[:n]
+	}
+	return pairs
+}
+
+func (co *CoOccurrence) pair(a, b string, count int) Pair {
+	if b < a {
+		a, b = b, a
+	}
+
+	return Pair{
+		A:       a,
+		B:       b,
+		Count:   count,
+		Jaccard: float64(count) / float64(co.Tags[a]+co.Tags[b]-count),
+	}
+}
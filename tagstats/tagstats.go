@@ -0,0 +1,97 @@
+// Package tagstats analyzes how the tags of a Linkding account are used, as a
+// base for tag cleanup tools and tag related features of frontends.
+//
+// The functions computing statistics work on tags and bookmarks that were
+// already fetched, e.g. from a localstore, while Fetch collects them from a
+// server.
+package tagstats
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/larcher/go-linkding"
+)
+
+// TagUsage is the number of bookmarks carrying a tag.
+type TagUsage struct {
+	Tag linkding.Tag
+	// The number of bookmarks, active and archived, carrying the tag.
+	Bookmarks int
+	// The number of archived bookmarks among them.
+	Archived int
+}
+
+// Count returns the usage of every tag, sorted by tag name. Tags are matched to
+// the tags of bookmarks and sorted ignoring case, like Linkding does.
+func Count(tags []linkding.Tag, bookmarks []linkding.Bookmark) []TagUsage {
+	usage := make([]TagUsage, len(tags))
+	byName := make(map[string]*TagUsage, len(tags))
+	for i, tag := range tags {
+		usage[i].Tag = tag
+		byName[strings.ToLower(tag.Name)] = &usage[i]
+	}
+
+	for _, bookmark := range bookmarks {
+		for _, name := range bookmark.TagNames {
+			if u, ok := byName[strings.ToLower(name)]; ok {
+				u.Bookmarks++
+				if bookmark.IsArchived {
+					u.Archived++
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(usage, func(a, b TagUsage) int {
+		return strings.Compare(strings.ToLower(a.Tag.Name), strings.ToLower(b.Tag.Name))
+	})
+
+	return usage
+}
+
+// Unused returns the tags used by fewer than minBookmarks bookmarks. With a
+// minBookmarks of 1 or less, only the tags without any bookmarks are returned.
+// Linkding keeps tags after their last bookmark is deleted or retagged, so
+// these accumulate over time.
+func Unused(usage []TagUsage, minBookmarks int) []TagUsage {
+	minBookmarks = max(minBookmarks, 1)
+
+	return slices.DeleteFunc(slices.Clone(usage), func(u TagUsage) bool {
+		return u.Bookmarks >= minBookmarks
+	})
+}
+
+// Usage fetches the tags and bookmarks of an account and returns the usage of
+// every tag.
+func Usage(ctx context.Context, c linkding.BookmarkClient) ([]TagUsage, error) {
+	tags, bookmarks, err := Fetch(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return Count(tags, bookmarks), nil
+}
+
+// Fetch lists every tag and every bookmark, active and archived, of an
+// account.
+func Fetch(ctx context.Context, c linkding.BookmarkClient) ([]linkding.Tag, []linkding.Bookmark, error) {
+	tags := []linkding.Tag{}
+	for tag, err := range linkding.AllTags(ctx, c, linkding.ListTagsParams{}) {
+		if err != nil {
+			return nil, nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	bookmarks := []linkding.Bookmark{}
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, linkding.ListBookmarksParams{}) {
+		if err != nil {
+			return nil, nil, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return tags, bookmarks, nil
+}
@@ -0,0 +1,125 @@
+package linkding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrConflict is returned when a bookmark was modified since the caller read
+// it. The returned error is a *ConflictError holding the current bookmark.
+var ErrConflict = errors.New("linkding: bookmark was modified")
+
+// ConflictError reports that a bookmark was modified since the caller read it.
+type ConflictError struct {
+	// The bookmark as it is stored now, e.g. to merge the changes into.
+	Current *Bookmark
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("linkding: bookmark %d was modified at %s", e.Current.ID, e.Current.DateModified.Format(time.RFC3339))
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// UpdateBookmarkIfUnmodified updates a bookmark like UpdateBookmark, unless it
+// was modified after lastKnownModified, the DateModified of the bookmark as
+// the caller read it. A modified bookmark is left unchanged and a
+// *ConflictError is returned, so changes of other writers are not lost.
+//
+// The API has no conditional updates, so the bookmark is read again right
+// before updating it. A change made in between is still overwritten.
+func (c *Client) UpdateBookmarkIfUnmodified(id int, payload CreateBookmarkRequest, lastKnownModified time.Time) (*Bookmark, error) {
+	ctx := context.Background()
+
+	current, err := c.Bookmarks.Get(ctx, id, WithNoCache())
+	if err != nil {
+		return nil, err
+	}
+	if !current.DateModified.Equal(lastKnownModified) {
+		return nil, &ConflictError{Current: current}
+	}
+
+	return c.Bookmarks.Update(ctx, id, payload)
+}
+
+// UpdateBookmarkFields reads a bookmark, lets update change it, and writes
+// back only the fields update changed, e.g. to add a single tag:
+//
+//	client.UpdateBookmarkFields(id, func(b *linkding.Bookmark) error {
+//		b.TagNames = append(b.TagNames, "read-later")
+//		return nil
+//	})
+//
+// Only the URL, title, description, notes, tags, and the archived, unread and
+// shared flags can be changed; changes to the other fields are ignored. No
+// update is sent if nothing changed, and the bookmark is returned as read.
+// An error returned by update is returned as it is, without changing the
+// bookmark.
+//
+// Fields changed by others between reading and writing the bookmark are kept,
+// unless update changed them as well.
+func (c *Client) UpdateBookmarkFields(id int, update func(*Bookmark) error) (*Bookmark, error) {
+	ctx := context.Background()
+
+	current, err := c.Bookmarks.Get(ctx, id, WithNoCache())
+	if err != nil {
+		return nil, err
+	}
+
+	changed := *current
+	changed.TagNames = slices.Clone(current.TagNames)
+	if err := update(&changed); err != nil {
+		return nil, err
+	}
+
+	patch, ok := diffBookmarks(current, &changed)
+	if !ok {
+		return current, nil
+	}
+
+	return c.Bookmarks.Patch(ctx, id, patch)
+}
+
+// diffBookmarks returns the patch changing the writable fields of before to
+// those of after, and whether any of them changed.
+func diffBookmarks(before, after *Bookmark) (PatchBookmarkRequest, bool) {
+	var patch PatchBookmarkRequest
+	changed := false
+
+	diff := func(before, after string, field **string) {
+		if before != after {
+			*field = &after
+			changed = true
+		}
+	}
+	diff(before.URL, after.URL, &patch.URL)
+	diff(before.Title, after.Title, &patch.Title)
+	diff(before.Description, after.Description, &patch.Description)
+	diff(before.Notes, after.Notes, &patch.Notes)
+
+	diffFlag := func(before, after bool, field **bool) {
+		if before != after {
+			*field = &after
+			changed = true
+		}
+	}
+	diffFlag(before.IsArchived, after.IsArchived, &patch.IsArchived)
+	diffFlag(before.Unread, after.Unread, &patch.Unread)
+	diffFlag(before.Shared, after.Shared, &patch.Shared)
+
+	if !slices.Equal(before.TagNames, after.TagNames) {
+		tags := after.TagNames
+		if tags == nil {
+			tags = []string{}
+		}
+		patch.TagNames = &tags
+		changed = true
+	}
+
+	return patch, changed
+}
@@ -1,6 +1,7 @@
 package linkding
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 )
@@ -22,20 +23,38 @@ type UserPreferences struct {
 		Shared string `json:"shared"`
 		Unread string `json:"unread"`
 	} `json:"search_preferences"`
+
+	// Unknown fields, only collected when using DecodeTolerant.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
-// GetUserPreferences retrieves the user's preferences from Linkding.
-func (c *Client) GetUserPreferences() (*UserPreferences, error) {
-	body, err := c.makeRequest(http.MethodGet, "/api/user/profile/", nil)
+// UserService handles the user endpoints of the Linkding API.
+type UserService interface {
+	GetPreferences(ctx context.Context, opts ...RequestOption) (*UserPreferences, error)
+}
+
+type userService struct {
+	client *Client
+}
+
+// GetPreferences retrieves the user's preferences from Linkding.
+func (s *userService) GetPreferences(ctx context.Context, opts ...RequestOption) (*UserPreferences, error) {
+	body, err := s.client.makeRequest(ctx, http.MethodGet, "/api/user/profile/", nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 	defer body.Close()
 
 	userPreferences := &UserPreferences{}
-	if err := json.NewDecoder(body).Decode(userPreferences); err != nil {
+	if err := s.client.decode(body, userPreferences); err != nil {
 		return nil, err
 	}
 
 	return userPreferences, nil
 }
+
+// GetUserPreferences retrieves the user's preferences from Linkding. It is a
+// shorthand for User.GetPreferences.
+func (c *Client) GetUserPreferences() (*UserPreferences, error) {
+	return c.User.GetPreferences(context.Background())
+}
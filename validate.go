@@ -0,0 +1,106 @@
+package linkding
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The longest values Linkding stores, in characters. Descriptions and notes
+// are not limited.
+const (
+	MaxURLLength   = 2048
+	MaxTitleLength = 512
+	MaxTagLength   = 64
+)
+
+// FieldError is an invalid field of a request.
+type FieldError struct {
+	// The name of the field in the API, e.g. "tag_names".
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned for requests the server would reject, before
+// they are sent. It lists every invalid field.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Error()
+	}
+
+	return "linkding: invalid bookmark: " + strings.Join(messages, "; ")
+}
+
+// Validate checks the request for values the server would reject: a URL that
+// is missing, cannot be parsed or has no scheme, values longer than the
+// server stores, and tags that are empty or contain whitespace, which the
+// server splits into several tags. It returns a *ValidationError listing the
+// invalid fields, or nil.
+//
+// Requests are validated before they are sent by Bookmarks.Create and
+// Bookmarks.Update.
+func (r CreateBookmarkRequest) Validate() error {
+	return validateBookmark(&r.URL, &r.Title, &r.TagNames)
+}
+
+// Validate checks the fields of the request that are set, like
+// CreateBookmarkRequest.Validate. Requests are validated before they are
+// sent by Bookmarks.Patch.
+func (r PatchBookmarkRequest) Validate() error {
+	return validateBookmark(r.URL, r.Title, r.TagNames)
+}
+
+func validateBookmark(rawURL, title *string, tags *[]string) error {
+	var fields []FieldError
+	invalid := func(field, format string, args ...any) {
+		fields = append(fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if rawURL != nil {
+		if u, err := url.Parse(*rawURL); *rawURL == "" {
+			invalid("url", "missing")
+		} else if err != nil {
+			invalid("url", "cannot be parsed: %v", err)
+		} else if u.Scheme == "" {
+			invalid("url", "has no scheme, e.g. https://")
+		} else if n := utf8.RuneCountInString(*rawURL); n > MaxURLLength {
+			invalid("url", "%d characters long, at most %d are stored", n, MaxURLLength)
+		}
+	}
+
+	if title != nil {
+		if n := utf8.RuneCountInString(*title); n > MaxTitleLength {
+			invalid("title", "%d characters long, at most %d are stored", n, MaxTitleLength)
+		}
+	}
+
+	if tags != nil {
+		for _, tag := range *tags {
+			switch {
+			case strings.TrimSpace(tag) == "":
+				invalid("tag_names", "empty tag")
+			case strings.ContainsFunc(tag, unicode.IsSpace):
+				invalid("tag_names", "tag %q contains whitespace", tag)
+			case utf8.RuneCountInString(tag) > MaxTagLength:
+				invalid("tag_names", "tag %q is longer than %d characters", tag, MaxTagLength)
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+
+	return nil
+}
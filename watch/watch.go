@@ -0,0 +1,218 @@
+// Package watch polls a Linkding server for changes to bookmarks and reports
+// them as events, so daemons can react to changes without writing their own
+// poller.
+//
+// Linkding has no change feed, so the watcher keeps a cache of every
+// bookmark. Regular polls only list the bookmarks modified since the previous
+// poll, while periodic full scans detect deleted bookmarks.
+package watch
+
+import (
+	"context"
+	"time"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/cursor"
+)
+
+// DefaultInterval is the time between polls when no interval is configured.
+const DefaultInterval = time.Minute
+
+// DefaultFullScanEvery is the number of polls between full scans when not
+// configured.
+const DefaultFullScanEvery = 10
+
+// EventType is the kind of change an event reports.
+type EventType string
+
+const (
+	BookmarkCreated EventType = "bookmark.created"
+	BookmarkUpdated EventType = "bookmark.updated"
+	BookmarkDeleted EventType = "bookmark.deleted"
+	// Error reports a failed poll. The watcher keeps polling.
+	Error EventType = "error"
+)
+
+// Event is a change detected by the watcher.
+type Event struct {
+	Type EventType
+	// The bookmark as stored by the server, or the last known state of a
+	// deleted bookmark.
+	Bookmark linkding.Bookmark
+	// The previous state of an updated bookmark.
+	Previous *linkding.Bookmark
+	// The error of a failed poll.
+	Err error
+}
+
+// Options configures a Watcher.
+type Options struct {
+	// The time between polls. Defaults to DefaultInterval.
+	Interval time.Duration
+	// The number of polls between full scans, which list every bookmark to
+	// detect deletions. Defaults to DefaultFullScanEvery.
+	FullScanEvery int
+	// Never run full scans after the first poll, so deletions are not
+	// detected.
+	DisableDeletes bool
+	// Report every existing bookmark as created on the first poll, instead of
+	// only loading them into the cache.
+	EmitInitial bool
+	// Persists the latest modification date seen, so a restarted watcher
+	// reports the bookmarks changed while it was not running. Deletions in
+	// that time cannot be detected.
+	Cursor cursor.Cursor
+}
+
+// Watcher detects changes to the bookmarks, active and archived, of an
+// account. It is not safe for concurrent use.
+type Watcher struct {
+	client    linkding.BookmarkClient
+	opts      Options
+	cache     map[int]linkding.Bookmark
+	watermark time.Time
+	polls     int
+}
+
+// NewWatcher returns a watcher for the bookmarks of c.
+func NewWatcher(c linkding.BookmarkClient, opts Options) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.FullScanEvery <= 0 {
+		opts.FullScanEvery = DefaultFullScanEvery
+	}
+
+	return &Watcher{client: c, opts: opts}
+}
+
+// Watch returns a channel of the changes to the bookmarks of c, polling at the
+// given interval until ctx is done. It is a shorthand for NewWatcher and Run.
+func Watch(ctx context.Context, c linkding.BookmarkClient, interval time.Duration) <-chan Event {
+	return NewWatcher(c, Options{Interval: interval}).Run(ctx)
+}
+
+// Run polls in the background until ctx is done and sends the detected
+// changes on the returned channel, which is closed when polling stops. Events
+// are not dropped, so a slow receiver delays the next poll.
+func (w *Watcher) Run(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(w.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			changes, err := w.Poll(ctx)
+			if err != nil && ctx.Err() == nil {
+				changes = append(changes, Event{Type: Error, Err: err})
+			}
+
+			for _, event := range changes {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// Poll checks the server once and returns the changes since the previous
+// poll. The first poll loads every bookmark into the cache.
+func (w *Watcher) Poll(ctx context.Context) ([]Event, error) {
+	initial := w.cache == nil
+	if initial && w.opts.Cursor != nil {
+		watermark, err := w.opts.Cursor.Load()
+		if err != nil {
+			return nil, err
+		}
+		w.watermark = watermark
+	}
+	resumed := !w.watermark.IsZero()
+	full := initial || (!w.opts.DisableDeletes && w.polls%w.opts.FullScanEvery == 0)
+
+	params := linkding.ListBookmarksParams{}
+	if !full {
+		params.ModifiedSince = w.watermark
+	}
+
+	bookmarks, err := fetch(ctx, w.client, params)
+	if err != nil {
+		return nil, err
+	}
+	w.polls++
+
+	if initial {
+		w.cache = make(map[int]linkding.Bookmark, len(bookmarks))
+	}
+
+	resumeFrom := w.watermark
+	events := []Event{}
+	seen := make(map[int]bool, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		seen[bookmark.ID] = true
+		if bookmark.DateModified.After(w.watermark) {
+			w.watermark = bookmark.DateModified
+		}
+
+		previous, known := w.cache[bookmark.ID]
+		w.cache[bookmark.ID] = bookmark
+
+		switch {
+		case initial && resumed:
+			if bookmark.DateAdded.After(resumeFrom) {
+				events = append(events, Event{Type: BookmarkCreated, Bookmark: bookmark})
+			} else if bookmark.DateModified.After(resumeFrom) {
+				events = append(events, Event{Type: BookmarkUpdated, Bookmark: bookmark})
+			}
+		case initial && !w.opts.EmitInitial:
+		case !known:
+			events = append(events, Event{Type: BookmarkCreated, Bookmark: bookmark})
+		case bookmark.DateModified.After(previous.DateModified):
+			events = append(events, Event{Type: BookmarkUpdated, Bookmark: bookmark, Previous: &previous})
+		}
+	}
+
+	if w.opts.Cursor != nil {
+		if err := w.opts.Cursor.Save(w.watermark); err != nil {
+			return events, err
+		}
+	}
+
+	if full && !initial {
+		for id, bookmark := range w.cache {
+			if !seen[id] {
+				delete(w.cache, id)
+				events = append(events, Event{Type: BookmarkDeleted, Bookmark: bookmark})
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// fetch lists the active and archived bookmarks matching params.
+func fetch(ctx context.Context, c linkding.BookmarkClient, params linkding.ListBookmarksParams) ([]linkding.Bookmark, error) {
+	bookmarks := []linkding.Bookmark{}
+
+	for bookmark, err := range linkding.AllBookmarksIncludingArchived(ctx, c, params) {
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return bookmarks, nil
+}
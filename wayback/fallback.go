@@ -0,0 +1,156 @@
+package wayback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/larcher/go-linkding"
+)
+
+// ErrNoCapture is returned by Latest when a page was never archived.
+var ErrNoCapture = errors.New("wayback: page was never archived")
+
+// Source is where the content of a bookmark was fetched from.
+type Source string
+
+const (
+	// SourceLive is the page at the URL of the bookmark.
+	SourceLive Source = "live"
+	// SourceSnapshot is the snapshot of the bookmark, made by Linkding's web
+	// archive integration or by SaveBookmarks.
+	SourceSnapshot Source = "snapshot"
+	// SourceLatest is the latest capture of the page in the Wayback
+	// Machine.
+	SourceLatest Source = "latest"
+)
+
+// Content is the content of a bookmarked page. The caller must close it.
+type Content struct {
+	io.ReadCloser
+	Source Source
+	// The URL the content was fetched from.
+	URL         string
+	ContentType string
+}
+
+// Latest returns the URL of the latest successful capture of a page, or
+// ErrNoCapture if it was never archived.
+func (c *Client) Latest(ctx context.Context, pageURL string) (string, error) {
+	query := url.Values{
+		"url":    {pageURL},
+		"output": {"json"},
+		"fl":     {"timestamp,original"},
+		"filter": {"statuscode:200"},
+		"limit":  {"-1"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.opts.BaseURL+"/cdx/search/cdx?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res); err != nil {
+		return "", err
+	}
+
+	// The response is a table whose first row holds the field names. An
+	// empty body means no captures.
+	var rows [][]string
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if len(rows) < 2 || len(rows[len(rows)-1]) < 2 {
+		return "", ErrNoCapture
+	}
+	capture := rows[len(rows)-1]
+
+	return c.opts.BaseURL + "/web/" + capture[0] + "/" + capture[1], nil
+}
+
+// GetContentWithFallback returns the content of a bookmarked page, for readers
+// that keep working when pages disappear. It tries the live page first, then
+// the snapshot of the bookmark and finally the latest capture in the Wayback
+// Machine, returning the first that can be fetched. Archived pages are
+// fetched as originally captured, without the Wayback Machine's toolbar.
+func (c *Client) GetContentWithFallback(ctx context.Context, bookmark linkding.Bookmark) (*Content, error) {
+	errs := []error{}
+
+	content, err := c.fetch(ctx, bookmark.URL, SourceLive)
+	if err == nil {
+		return content, nil
+	}
+	errs = append(errs, err)
+
+	for _, snapshot := range []string{bookmark.WebArchiveSnapshotURL, SnapshotFromNotes(bookmark.Notes)} {
+		if snapshot == "" {
+			continue
+		}
+		content, err := c.fetch(ctx, rawCapture(snapshot), SourceSnapshot)
+		if err == nil {
+			return content, nil
+		}
+		errs = append(errs, err)
+	}
+
+	latest, err := c.Latest(ctx, bookmark.URL)
+	if err == nil {
+		content, err = c.fetch(ctx, rawCapture(latest), SourceLatest)
+		if err == nil {
+			return content, nil
+		}
+	}
+	errs = append(errs, err)
+
+	return nil, errors.Join(errs...)
+}
+
+func (c *Client) fetch(ctx context.Context, source string, kind Source) (*Content, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("wayback: fetching %s content: %w", kind, err)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, fmt.Errorf("wayback: fetching %s content: unexpected status %s", kind, res.Status)
+	}
+
+	return &Content{
+		ReadCloser:  res.Body,
+		Source:      kind,
+		URL:         res.Request.URL.String(),
+		ContentType: res.Header.Get("Content-Type"),
+	}, nil
+}
+
+var captureTimestamp = regexp.MustCompile(`/web/(\d+)/`)
+
+// rawCapture returns the URL of the original content of a Wayback Machine
+// capture, which is served without the toolbar and rewritten links when the
+// timestamp is followed by "id_".
+func rawCapture(capture string) string {
+	if loc := captureTimestamp.FindStringSubmatchIndex(capture); loc != nil {
+		return capture[:loc[3]] + "id_" + capture[loc[3]:]
+	}
+
+	return capture
+}
@@ -0,0 +1,333 @@
+// Package wayback archives bookmarked pages in the Internet Archive's Wayback
+// Machine, for servers with the web archive integration disabled or pages
+// added before it was enabled.
+//
+// Pages are submitted to the Save Page Now API, anonymously or, with keys
+// from https://archive.org/account/s3.php, as an authenticated user with
+// higher limits. Requests are spaced out to stay within the rate limits of the
+// API.
+//
+// Archived copies can be read back when the live page is gone, see
+// GetContentWithFallback.
+package wayback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larcher/go-linkding"
+)
+
+// DefaultBaseURL is the address of the Wayback Machine.
+const DefaultBaseURL = "https://web.archive.org"
+
+// DefaultInterval is the time between requests to the Save API when not
+// configured. Anonymous users may save about 15 pages a minute.
+const DefaultInterval = 5 * time.Second
+
+// DefaultTimeout is how long an authenticated save is waited for when not
+// configured.
+const DefaultTimeout = 2 * time.Minute
+
+// ErrRateLimited is returned when the Wayback Machine refuses a request
+// because too many were made.
+var ErrRateLimited = errors.New("wayback: rate limited")
+
+// Options configures a Client.
+type Options struct {
+	// The client used for requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// The address of the Wayback Machine. Defaults to DefaultBaseURL.
+	BaseURL string
+	// The keys of an archive.org account. Pages are saved anonymously
+	// without keys.
+	AccessKey string
+	SecretKey string
+	// The minimum time between requests to the Save API. Defaults to
+	// DefaultInterval.
+	Interval time.Duration
+	// How long an authenticated save is waited for. Defaults to
+	// DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Client saves pages in the Wayback Machine. It is safe for concurrent use;
+// concurrent saves wait for each other to respect the interval.
+type Client struct {
+	opts Options
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// New returns a client.
+func New(opts Options) *Client {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	return &Client{opts: opts}
+}
+
+// Save archives a page and returns the URL of the snapshot.
+func (c *Client) Save(ctx context.Context, pageURL string) (string, error) {
+	if c.opts.AccessKey != "" {
+		return c.saveAuthenticated(ctx, pageURL)
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.opts.BaseURL+"/save/"+pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if err := checkStatus(res); err != nil {
+		return "", err
+	}
+
+	// The API redirects to the snapshot, or names it in Content-Location.
+	if strings.HasPrefix(res.Request.URL.Path, "/web/") {
+		return res.Request.URL.String(), nil
+	}
+	if location := res.Header.Get("Content-Location"); strings.HasPrefix(location, "/web/") {
+		return c.opts.BaseURL + location, nil
+	}
+
+	return "", fmt.Errorf("wayback: no snapshot returned for %s", pageURL)
+}
+
+// saveAuthenticated saves a page with the Save Page Now 2 API, which queues a
+// capture job and reports its outcome at a status endpoint.
+func (c *Client) saveAuthenticated(ctx context.Context, pageURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	if err := c.wait(ctx); err != nil {
+		return "", err
+	}
+
+	form := url.Values{"url": {pageURL}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/save", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var job struct {
+		JobID   string `json:"job_id"`
+		Message string `json:"message"`
+	}
+	if err := c.doJSON(req, &job); err != nil {
+		return "", err
+	}
+	if job.JobID == "" {
+		return "", fmt.Errorf("wayback: saving %s failed: %s", pageURL, job.Message)
+	}
+
+	for {
+		timer := time.NewTimer(c.opts.Interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.opts.BaseURL+"/save/status/"+url.PathEscape(job.JobID), nil)
+		if err != nil {
+			return "", err
+		}
+
+		var status struct {
+			Status      string `json:"status"`
+			Timestamp   string `json:"timestamp"`
+			OriginalURL string `json:"original_url"`
+			Message     string `json:"message"`
+		}
+		if err := c.doJSON(req, &status); err != nil {
+			return "", err
+		}
+
+		switch status.Status {
+		case "success":
+			return c.opts.BaseURL + "/web/" + status.Timestamp + "/" + status.OriginalURL, nil
+		case "error":
+			return "", fmt.Errorf("wayback: saving %s failed: %s", pageURL, status.Message)
+		}
+	}
+}
+
+func (c *Client) doJSON(req *http.Request, v any) error {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "LOW "+c.opts.AccessKey+":"+c.opts.SecretKey)
+
+	res, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res); err != nil {
+		return err
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// wait blocks until the interval since the previous request has passed.
+func (c *Client) wait(ctx context.Context) error {
+	c.mu.Lock()
+	next := c.last.Add(c.opts.Interval)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	c.last = next
+	c.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func checkStatus(res *http.Response) error {
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case res.StatusCode < 200 || res.StatusCode >= 300:
+		return fmt.Errorf("wayback: unexpected status %s", res.Status)
+	}
+
+	return nil
+}
+
+// NotesPrefix starts the line of the notes of a bookmark holding its
+// snapshot, as written by SaveBookmarks.
+const NotesPrefix = "Wayback Machine: "
+
+// SaveOptions configures SaveBookmarks.
+type SaveOptions struct {
+	// Add the URL of the snapshot to the notes of each bookmark, replacing
+	// the one of a previous save. Linkding does not allow clients to set the
+	// snapshot URL of a bookmark, so the notes are used instead.
+	WriteBack bool
+	// Save bookmarks that already have a snapshot, from Linkding's own
+	// integration or a previous save.
+	Resave bool
+	// Called after each bookmark, if set.
+	Progress func(done, total int)
+}
+
+// Report is the outcome of SaveBookmarks.
+type Report struct {
+	// The snapshot URL of each saved bookmark by ID.
+	Saved map[int]string
+	// The number of bookmarks skipped because they have a snapshot.
+	Skipped int
+	// The error of each bookmark that could not be saved or updated, by ID.
+	Failed map[int]error
+}
+
+// SaveBookmarks archives the pages of the bookmarks one after the other.
+// Stopping early because ctx is done, or because the Wayback Machine rate
+// limits the client, returns the report so far along with the error.
+func (c *Client) SaveBookmarks(ctx context.Context, lc linkding.BookmarkClient, bookmarks []linkding.Bookmark, opts SaveOptions) (*Report, error) {
+	report := &Report{Saved: map[int]string{}, Failed: map[int]error{}}
+
+	for i, bookmark := range bookmarks {
+		if !opts.Resave && (bookmark.WebArchiveSnapshotURL != "" || SnapshotFromNotes(bookmark.Notes) != "") {
+			report.Skipped++
+		} else if snapshot, err := c.saveBookmark(ctx, lc, bookmark, opts); err == nil {
+			report.Saved[bookmark.ID] = snapshot
+		} else if ctx.Err() != nil || errors.Is(err, ErrRateLimited) {
+			return report, err
+		} else {
+			report.Failed[bookmark.ID] = err
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(bookmarks))
+		}
+	}
+
+	return report, nil
+}
+
+func (c *Client) saveBookmark(ctx context.Context, lc linkding.BookmarkClient, bookmark linkding.Bookmark, opts SaveOptions) (string, error) {
+	snapshot, err := c.Save(ctx, bookmark.URL)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.WriteBack {
+		notes := withSnapshot(bookmark.Notes, snapshot)
+		if _, err := lc.PatchBookmark(bookmark.ID, linkding.PatchBookmarkRequest{Notes: &notes}); err != nil {
+			return "", err
+		}
+	}
+
+	return snapshot, nil
+}
+
+// SnapshotFromNotes returns the snapshot URL written to the notes of a
+// bookmark by SaveBookmarks, or an empty string.
+func SnapshotFromNotes(notes string) string {
+	for _, line := range strings.Split(notes, "\n") {
+		if snapshot, ok := strings.CutPrefix(strings.TrimSpace(line), NotesPrefix); ok {
+			return strings.TrimSpace(snapshot)
+		}
+	}
+
+	return ""
+}
+
+// withSnapshot returns the notes with the snapshot line replaced or appended.
+func withSnapshot(notes, snapshot string) string {
+	lines := []string{}
+	for _, line := range strings.Split(notes, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), NotesPrefix) {
+			lines = append(lines, line)
+		}
+	}
+
+	notes = strings.TrimRight(strings.Join(lines, "\n"), "\n ")
+	if notes != "" {
+		notes += "\n\n"
+	}
+
+	return notes + NotesPrefix + snapshot
+}
@@ -0,0 +1,214 @@
+// Package webhook delivers the changes detected by a watch.Watcher to HTTP
+// endpoints, as Linkding itself has no webhooks.
+//
+// Each change is POSTed as a JSON payload. When an endpoint has a secret, the
+// payload is signed with HMAC-SHA256 and the signature is sent in the
+// X-Linkding-Signature header as "sha256=<hex digest>", so receivers can
+// verify where it came from.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/larcher/go-linkding"
+	"github.com/larcher/go-linkding/watch"
+)
+
+// DefaultMaxAttempts is the number of times a delivery is attempted when not
+// configured.
+const DefaultMaxAttempts = 3
+
+// DefaultBackoff is the delay before the first retry when not configured.
+const DefaultBackoff = time.Second
+
+// Endpoint is a URL that receives changes.
+type Endpoint struct {
+	URL string
+	// The key used to sign payloads. Payloads are not signed when empty.
+	Secret string
+	// The types of events delivered. Every change is delivered when empty.
+	Events []watch.EventType
+}
+
+// Payload is the JSON body POSTed to endpoints.
+type Payload struct {
+	Event     watch.EventType    `json:"event"`
+	Timestamp time.Time          `json:"timestamp"`
+	Bookmark  linkding.Bookmark  `json:"bookmark"`
+	Previous  *linkding.Bookmark `json:"previous,omitempty"`
+}
+
+// Options configures a Dispatcher.
+type Options struct {
+	// The client used to send requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// The number of times a delivery is attempted. Defaults to
+	// DefaultMaxAttempts.
+	MaxAttempts int
+	// The delay before the first retry, doubled for each further retry.
+	// Defaults to DefaultBackoff.
+	Backoff time.Duration
+	// Called when a delivery failed after all attempts, if set.
+	OnError func(endpoint Endpoint, payload Payload, err error)
+}
+
+// Dispatcher delivers events to endpoints. It is safe for concurrent use.
+type Dispatcher struct {
+	endpoints []Endpoint
+	opts      Options
+}
+
+// NewDispatcher returns a dispatcher delivering to the given endpoints.
+func NewDispatcher(endpoints []Endpoint, opts Options) *Dispatcher {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultBackoff
+	}
+
+	return &Dispatcher{endpoints: slices.Clone(endpoints), opts: opts}
+}
+
+// Run delivers the events received from a watcher until the channel is
+// closed or ctx is done. Failed deliveries are reported to OnError.
+func (d *Dispatcher) Run(ctx context.Context, events <-chan watch.Event) {
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			d.Dispatch(ctx, event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Dispatch delivers an event to every endpoint subscribed to its type, one
+// after the other, retrying failed deliveries. Error events are not
+// delivered. It returns the errors of the deliveries that failed.
+func (d *Dispatcher) Dispatch(ctx context.Context, event watch.Event) error {
+	if event.Type == watch.Error {
+		return nil
+	}
+
+	payload := Payload{
+		Event:     event.Type,
+		Timestamp: time.Now().UTC(),
+		Bookmark:  event.Bookmark,
+		Previous:  event.Previous,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, endpoint := range d.endpoints {
+		if len(endpoint.Events) > 0 && !slices.Contains(endpoint.Events, event.Type) {
+			continue
+		}
+
+		if err := d.deliver(ctx, endpoint, event.Type, body); err != nil {
+			if d.opts.OnError != nil {
+				d.opts.OnError(endpoint, payload, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", endpoint.URL, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// deliver sends a payload to an endpoint, retrying with exponential backoff
+// when the endpoint cannot be reached or responds with a server error or
+// 429 Too Many Requests.
+func (d *Dispatcher) deliver(ctx context.Context, endpoint Endpoint, eventType watch.EventType, body []byte) error {
+	delivery := newDeliveryID()
+	backoff := d.opts.Backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = d.send(ctx, endpoint, eventType, delivery, body)
+		if err == nil || !retry || attempt >= d.opts.MaxAttempts {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, endpoint Endpoint, eventType watch.EventType, delivery string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-linkding-webhook")
+	req.Header.Set("X-Linkding-Event", string(eventType))
+	req.Header.Set("X-Linkding-Delivery", delivery)
+	if endpoint.Secret != "" {
+		req.Header.Set("X-Linkding-Signature", Sign(endpoint.Secret, body))
+	}
+
+	res, err := d.opts.HTTPClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("webhook: unexpected status %s", res.Status)
+}
+
+// Sign returns the signature of a payload as sent in the X-Linkding-Signature
+// header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the valid signature of a payload,
+// comparing in constant time. Receivers written in Go can use it to check
+// incoming requests.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+func newDeliveryID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
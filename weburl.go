@@ -0,0 +1,55 @@
+package linkding
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// WebURL returns the link to the bookmark in the Linkding web interface,
+// opening its details, e.g. for an "open in Linkding" action. Archived
+// bookmarks are linked in the archive.
+func (c *Client) WebURL(bookmark Bookmark) string {
+	page := "/bookmarks"
+	if bookmark.IsArchived {
+		page = "/bookmarks/archived"
+	}
+
+	return c.webURL(page) + "?" + url.Values{"details": {strconv.Itoa(bookmark.ID)}}.Encode()
+}
+
+// EditURL returns the link to the form editing the bookmark in the Linkding
+// web interface.
+func (c *Client) EditURL(id int) string {
+	return c.webURL("/bookmarks/" + strconv.Itoa(id) + "/edit")
+}
+
+// SearchURL returns the link to the bookmarks matching params in the Linkding
+// web interface, e.g. to open a digest or notification pre-filtered:
+//
+//	link := client.SearchURL(linkding.ListBookmarksParams{Query: linkding.Search().Tag("go").String()})
+//
+// The query, unread filter and sort order are kept. The web interface pages
+// and filters by date on its own, so the limit, offset and dates are ignored.
+func (c *Client) SearchURL(params ListBookmarksParams) string {
+	return c.searchURL("/bookmarks", params)
+}
+
+// ArchivedSearchURL returns the link to the archived bookmarks matching params
+// in the Linkding web interface, like SearchURL.
+func (c *Client) ArchivedSearchURL(params ListBookmarksParams) string {
+	return c.searchURL("/bookmarks/archived", params)
+}
+
+func (c *Client) searchURL(page string, params ListBookmarksParams) string {
+	return buildBookmarksQueryString(c.webURL(page), ListBookmarksParams{
+		Query:  params.Query,
+		Unread: params.Unread,
+		Sort:   params.Sort,
+	})
+}
+
+// webURL returns the link to the page of the web interface at path.
+func (c *Client) webURL(path string) string {
+	return strings.TrimSuffix(c.baseURL, "/") + path
+}